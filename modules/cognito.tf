@@ -20,6 +20,9 @@ resource "aws_cognito_user_pool" "user_pool" {
     required            = true
   }
   username_attributes = ["email"]
+  lambda_config {
+    post_confirmation = aws_lambda_function.signup.arn
+  }
   tags = {
     Name = "User Pool"
   }
@@ -71,6 +74,13 @@ resource "aws_cognito_user_group" "api_administrator_user_pool_group" {
   precedence   = 0
 }
 
+resource "aws_cognito_user_group" "rider_user_pool_group" {
+  name         = var.user_pool_rider_group_name
+  user_pool_id = aws_cognito_user_pool.user_pool.id
+  description  = "FDS User group for e-bike couriers"
+  precedence   = 10
+}
+
 output "user_pool" {
   value = aws_cognito_user_pool.user_pool.id
 }
@@ -82,6 +92,10 @@ output "user_pool_admin_group" {
   value = var.user_pool_admin_group_name
 }
 
+output "user_pool_rider_group" {
+  value = var.user_pool_rider_group_name
+}
+
 output "cognito_login_url" {
   value = "https://${aws_cognito_user_pool_client.user_pool_client.id}.auth.${var.region}.amazoncognito.com/oauth2/authorize?client_id=${aws_cognito_user_pool_client.user_pool_client.id}&response_type=token&state=request&scope=aws.cognito.signin.user.admin+openid+email&redirect_uri=http://localhost:8080"
 }
@@ -18,3 +18,17 @@ resource "aws_dynamodb_table" "users_table" {
 output "users_table" {
   value = aws_dynamodb_table.users_table.id
 }
+
+# erasure audit receipts
+resource "aws_dynamodb_table" "audit_table" {
+  name         = "${var.app_prefix}Audit"
+  billing_mode = "PROVISIONED"
+  hash_key     = "receiptid"
+
+  read_capacity  = 5
+  write_capacity = 5
+  attribute {
+    name = "receiptid"
+    type = "S"
+  }
+}
@@ -0,0 +1,106 @@
+# Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+# SPDX-License-Identifier: MIT-0
+
+# rider offers of acknowledged orders, one item per attempt
+resource "aws_dynamodb_table" "dispatch_offers_table" {
+  name         = "${var.app_prefix}DispatchOffers"
+  billing_mode = "PROVISIONED"
+  hash_key     = "orderid"
+  range_key    = "attempt"
+
+  read_capacity  = 5
+  write_capacity = 5
+  attribute {
+    name = "orderid"
+    type = "S"
+  }
+  attribute {
+    name = "attempt"
+    type = "N"
+  }
+  attribute {
+    name = "status"
+    type = "S"
+  }
+  attribute {
+    name = "riderid"
+    type = "S"
+  }
+  attribute {
+    name = "expireson"
+    type = "N"
+  }
+  global_secondary_index {
+    name            = "status-expireson-index"
+    hash_key        = "status"
+    range_key       = "expireson"
+    projection_type = "ALL"
+    read_capacity   = 5
+    write_capacity  = 5
+  }
+  global_secondary_index {
+    name            = "riderid-expireson-index"
+    hash_key        = "riderid"
+    range_key       = "expireson"
+    projection_type = "ALL"
+    read_capacity   = 5
+    write_capacity  = 5
+  }
+}
+
+data "archive_file" "dispatcher_lambda_zip" {
+  type        = "zip"
+  output_path = "../dist/${var.app_prefix}.lambda.dispatcher.zip"
+  source_file = "../dist/dispatcher/bootstrap"
+}
+
+# invoked asynchronously with acknowledged orders and every minute for expired offers
+resource "aws_lambda_function" "dispatcher" {
+  filename         = data.archive_file.dispatcher_lambda_zip.output_path
+  function_name    = "${var.app_prefix}Dispatcher"
+  role             = aws_iam_role.lambda_role.arn
+  handler          = "bootstrap"
+  source_code_hash = data.archive_file.dispatcher_lambda_zip.output_base64sha256
+  runtime          = var.lambda_runtime[1]
+  architectures    = var.architectures
+  timeout          = var.lambda_timeout
+  tracing_config {
+    mode = var.lambda_tracing_config
+  }
+  environment {
+    variables = {
+      FDS_APPS_ORDERS_TABLE          = aws_dynamodb_table.orders_table.id
+      FDS_APPS_RESTAURANTS_TABLE     = aws_dynamodb_table.restaurants_table.id
+      FDS_APPS_RIDERS_TABLE          = aws_dynamodb_table.riders_table.id
+      FDS_APPS_DISPATCH_OFFERS_TABLE = aws_dynamodb_table.dispatch_offers_table.id
+      FDS_LOG_LEVEL                  = var.lambda_log_level
+      OTEL_EXPORTER_OTLP_ENDPOINT    = var.otel_exporter_otlp_endpoint
+    }
+  }
+}
+
+resource "aws_cloudwatch_event_rule" "dispatcher_schedule" {
+  name                = "${var.app_prefix}DispatcherSchedule"
+  description         = "dispatch the orders of expired rider offers"
+  schedule_expression = "rate(1 minute)"
+}
+
+resource "aws_cloudwatch_event_target" "dispatcher_schedule" {
+  rule = aws_cloudwatch_event_rule.dispatcher_schedule.name
+  arn  = aws_lambda_function.dispatcher.arn
+}
+
+resource "aws_lambda_permission" "allow_events_on_dispatcher" {
+  statement_id  = "${var.app_prefix}EventsPermission"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.dispatcher.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.dispatcher_schedule.arn
+}
+
+output "dispatch_offers_table" {
+  value = aws_dynamodb_table.dispatch_offers_table.id
+}
+output "dispatcher_lambda" {
+  value = aws_lambda_function.dispatcher.function_name
+}
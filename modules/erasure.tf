@@ -0,0 +1,62 @@
+# Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+# SPDX-License-Identifier: MIT-0
+
+# user erasure jobs. receipts are kept in the audit table
+data "archive_file" "eraser_lambda_zip" {
+  type        = "zip"
+  output_path = "../dist/${var.app_prefix}.lambda.eraser.zip"
+  source_file = "../dist/eraser/bootstrap"
+}
+
+resource "aws_lambda_function" "eraser" {
+  filename         = data.archive_file.eraser_lambda_zip.output_path
+  function_name    = "${var.app_prefix}UserEraser"
+  role             = aws_iam_role.lambda_role.arn
+  handler          = "bootstrap"
+  source_code_hash = data.archive_file.eraser_lambda_zip.output_base64sha256
+  runtime          = var.lambda_runtime[1]
+  architectures    = var.architectures
+  timeout          = 900
+  tracing_config {
+    mode = var.lambda_tracing_config
+  }
+  environment {
+    variables = {
+      FDS_APPS_USERS_TABLE        = aws_dynamodb_table.users_table.id
+      FDS_APPS_ORDERS_TABLE       = aws_dynamodb_table.orders_table.id
+      FDS_APPS_ADDRESS_TABLE      = aws_dynamodb_table.addresstable.id
+      FDS_APPS_FAVORITE_TABLE     = aws_dynamodb_table.favoritetable.id
+      FDS_APPS_AUDIT_TABLE        = aws_dynamodb_table.audit_table.id
+      FDS_APPS_EXPORTS_TABLE      = aws_dynamodb_table.exports_table.id
+      FDS_APPS_EXPORTS_BUCKET     = aws_s3_bucket.exports_bucket.id
+      FDS_LOG_LEVEL               = var.lambda_log_level
+      OTEL_EXPORTER_OTLP_ENDPOINT = var.otel_exporter_otlp_endpoint
+    }
+  }
+}
+
+resource "aws_iam_policy" "erasure_role_policy" {
+  name        = "${var.app_prefix}ErasureRolePolicy"
+  description = "FDS user erasure policy"
+
+  policy = <<EOF
+{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Action": [
+        "lambda:InvokeFunction"
+      ],
+      "Effect": "Allow",
+      "Resource": "${aws_lambda_function.eraser.arn}"
+    }
+  ]
+}
+EOF
+}
+
+resource "aws_iam_policy_attachment" "erasure_attach" {
+  name       = "${var.app_prefix}ErasurePolicyAttachment"
+  roles      = [aws_iam_role.lambda_role.name]
+  policy_arn = aws_iam_policy.erasure_role_policy.arn
+}
@@ -0,0 +1,121 @@
+# Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+# SPDX-License-Identifier: MIT-0
+
+# user data export jobs
+resource "aws_dynamodb_table" "exports_table" {
+  name         = "${var.app_prefix}Exports"
+  billing_mode = "PROVISIONED"
+  hash_key     = "exportid"
+
+  read_capacity  = 5
+  write_capacity = 5
+  attribute {
+    name = "exportid"
+    type = "S"
+  }
+  ttl {
+    attribute_name = "expiresat"
+    enabled        = true
+  }
+}
+
+resource "aws_s3_bucket" "exports_bucket" {
+  bucket_prefix = lower("${var.app_prefix}-exports-")
+}
+
+resource "aws_s3_bucket_lifecycle_configuration" "exports_bucket" {
+  bucket = aws_s3_bucket.exports_bucket.id
+  rule {
+    id     = "expire-exports"
+    status = "Enabled"
+    filter {
+      prefix = "exports/"
+    }
+    expiration {
+      days = 7
+    }
+  }
+}
+
+data "archive_file" "exporter_lambda_zip" {
+  type        = "zip"
+  output_path = "../dist/${var.app_prefix}.lambda.exporter.zip"
+  source_file = "../dist/exporter/bootstrap"
+}
+
+resource "aws_lambda_function" "exporter" {
+  filename         = data.archive_file.exporter_lambda_zip.output_path
+  function_name    = "${var.app_prefix}UserExporter"
+  role             = aws_iam_role.lambda_role.arn
+  handler          = "bootstrap"
+  source_code_hash = data.archive_file.exporter_lambda_zip.output_base64sha256
+  runtime          = var.lambda_runtime[1]
+  architectures    = var.architectures
+  timeout          = 900
+  tracing_config {
+    mode = var.lambda_tracing_config
+  }
+  environment {
+    variables = {
+      FDS_APPS_USERS_TABLE        = aws_dynamodb_table.users_table.id
+      FDS_APPS_ORDERS_TABLE       = aws_dynamodb_table.orders_table.id
+      FDS_APPS_ADDRESS_TABLE      = aws_dynamodb_table.addresstable.id
+      FDS_APPS_FAVORITE_TABLE     = aws_dynamodb_table.favoritetable.id
+      FDS_APPS_EXPORTS_TABLE      = aws_dynamodb_table.exports_table.id
+      FDS_APPS_EXPORTS_BUCKET     = aws_s3_bucket.exports_bucket.id
+      FDS_LOG_LEVEL               = var.lambda_log_level
+      OTEL_EXPORTER_OTLP_ENDPOINT = var.otel_exporter_otlp_endpoint
+    }
+  }
+}
+
+resource "aws_iam_policy" "exports_role_policy" {
+  name        = "${var.app_prefix}ExportsRolePolicy"
+  description = "FDS user data export policy"
+
+  policy = <<EOF
+{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Action": [
+        "s3:PutObject",
+        "s3:GetObject",
+        "s3:DeleteObject"
+      ],
+      "Effect": "Allow",
+      "Resource": "${aws_s3_bucket.exports_bucket.arn}/exports/*"
+    },
+    {
+      "Action": [
+        "s3:ListBucket"
+      ],
+      "Effect": "Allow",
+      "Resource": "${aws_s3_bucket.exports_bucket.arn}",
+      "Condition": {
+        "StringLike": {
+          "s3:prefix": "exports/*"
+        }
+      }
+    },
+    {
+      "Action": [
+        "lambda:InvokeFunction"
+      ],
+      "Effect": "Allow",
+      "Resource": "${aws_lambda_function.exporter.arn}"
+    }
+  ]
+}
+EOF
+}
+
+resource "aws_iam_policy_attachment" "exports_attach" {
+  name       = "${var.app_prefix}ExportsPolicyAttachment"
+  roles      = [aws_iam_role.lambda_role.name]
+  policy_arn = aws_iam_policy.exports_role_policy.arn
+}
+
+output "exports_bucket" {
+  value = aws_s3_bucket.exports_bucket.id
+}
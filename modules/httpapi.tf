@@ -5,105 +5,13 @@ resource "aws_api_gateway_rest_api" "rest_api" {
   endpoint_configuration {
     types = ["REGIONAL"]
   }
-  body = jsonencode({
-    openapi = "3.0.1"
-    info = {
-      title   = "FDS RestAPI Get Users (Go)",
-      version = "1.0"
-    },
-    components = {
-      securitySchemes = {
-        lambdaTokenAuthorizer = {
-          type                         = "apiKey"
-          name                         = "Authorization"
-          in                           = "header"
-          x-amazon-apigateway-authtype = "custom"
-          x-amazon-apigateway-authorizer = {
-            authorizerUri                = "arn:aws:apigateway:${var.region}:lambda:path/2015-03-31/functions/arn:aws:lambda:${var.region}:${data.aws_caller_identity.current.account_id}:function:${aws_lambda_function.users_authorizer.function_name}/invocations"
-            authorizerResultTtlInSeconds = 300
-            type                         = "token"
-          }
-        }
-      }
-    },
-    paths = {
-      "/users" = {
-        get = {
-          security = [
-            {
-              "lambdaTokenAuthorizer" : []
-            }
-          ]
-          x-amazon-apigateway-integration = {
-            httpMethod          = "POST"
-            type                = "aws_proxy"
-            passthroughBehavior = "WHEN_NO_MATCH"
-            uri                 = "arn:aws:apigateway:${var.region}:lambda:path/2015-03-31/functions/${aws_lambda_function.getusers.arn}/invocations"
-          }
-        },
-        put = {
-          security = [
-            {
-              "lambdaTokenAuthorizer" : []
-            }
-          ]
-          x-amazon-apigateway-integration = {
-            httpMethod          = "POST"
-            type                = "aws_proxy"
-            passthroughBehavior = "WHEN_NO_MATCH"
-            uri                 = "arn:aws:apigateway:${var.region}:lambda:path/2015-03-31/functions/${aws_lambda_function.putuser.arn}/invocations"
-          }
-        }
-      },
-
-      "/users/{id}" = {
-        get = {
-          security = [
-            {
-              "lambdaTokenAuthorizer" : []
-            }
-          ]
-          x-amazon-apigateway-integration = {
-            httpMethod          = "POST"
-            type                = "aws_proxy"
-            passthroughBehavior = "WHEN_NO_MATCH"
-            uri                 = "arn:aws:apigateway:${var.region}:lambda:path/2015-03-31/functions/${aws_lambda_function.getuser.arn}/invocations"
-          }
-        }
-      },
-
-      "/users/{id}" = {
-        delete = {
-          security = [
-            {
-              "lambdaTokenAuthorizer" : []
-            }
-          ]
-          x-amazon-apigateway-integration = {
-            httpMethod          = "POST"
-            type                = "aws_proxy"
-            passthroughBehavior = "WHEN_NO_MATCH"
-            uri                 = "arn:aws:apigateway:${var.region}:lambda:path/2015-03-31/functions/${aws_lambda_function.getuser.arn}/invocations"
-          }
-        }
-      },
-
-      "/user" = {
-        put = {
-          security = [
-            {
-              "lambdaTokenAuthorizer" : []
-            }
-          ]
-          x-amazon-apigateway-integration = {
-            httpMethod          = "POST"
-            type                = "aws_proxy"
-            passthroughBehavior = "WHEN_NO_MATCH"
-            uri                 = "arn:aws:apigateway:${var.region}:lambda:path/2015-03-31/functions/${aws_lambda_function.putuser.arn}/invocations"
-          }
-        }
-      }
-    }
+  # generated from the Go route registrations: npm run openapi
+  body = templatefile("${path.module}/openapi.json.tftpl", {
+    region              = var.region
+    authorizer_uri      = "arn:aws:apigateway:${var.region}:lambda:path/2015-03-31/functions/arn:aws:lambda:${var.region}:${data.aws_caller_identity.current.account_id}:function:${aws_lambda_function.users_authorizer.function_name}/invocations"
+    users_function_arn  = aws_lambda_function.getusers.arn
+    orders_function_arn = aws_lambda_function.placeorder.arn
+    riders_function_arn = aws_lambda_function.riders.arn
   })
 }
 
@@ -149,6 +57,28 @@ resource "aws_lambda_permission" "allow_api_on_putuser" {
   source_arn    = "${aws_api_gateway_rest_api.rest_api.execution_arn}/*/*/*"
 }
 
+resource "aws_lambda_permission" "allow_api_on_placeorder" {
+  statement_id  = "${var.app_prefix}LambdaPermission"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.placeorder.function_name
+  principal     = "apigateway.${var.region}.amazonaws.com"
+  source_arn    = "${aws_api_gateway_rest_api.rest_api.execution_arn}/*/*/*"
+}
+resource "aws_lambda_permission" "allow_api_on_getorder" {
+  statement_id  = "${var.app_prefix}LambdaPermission"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.getorder.function_name
+  principal     = "apigateway.${var.region}.amazonaws.com"
+  source_arn    = "${aws_api_gateway_rest_api.rest_api.execution_arn}/*/*/*"
+}
+resource "aws_lambda_permission" "allow_api_on_modifyorder" {
+  statement_id  = "${var.app_prefix}LambdaPermission"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.modifyorder.function_name
+  principal     = "apigateway.${var.region}.amazonaws.com"
+  source_arn    = "${aws_api_gateway_rest_api.rest_api.execution_arn}/*/*/*"
+}
+
 resource "aws_lambda_permission" "allow_api_on_authorizer" {
   statement_id  = "${var.app_prefix}LambdaPermission"
   action        = "lambda:InvokeFunction"
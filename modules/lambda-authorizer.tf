@@ -21,9 +21,12 @@ resource "aws_lambda_function" "users_authorizer" {
   }
   environment {
     variables = {
-      FDS_USER_POOL_ID          = aws_cognito_user_pool.user_pool.id
-      FDS_APPLICATION_CLIENT_ID = aws_cognito_user_pool_client.user_pool_client.id
-      FDS_ADMIN_GROUP_NAME      = var.user_pool_admin_group_name
+      FDS_USER_POOL_ID            = aws_cognito_user_pool.user_pool.id
+      FDS_APPLICATION_CLIENT_ID   = aws_cognito_user_pool_client.user_pool_client.id
+      FDS_ADMIN_GROUP_NAME        = var.user_pool_admin_group_name
+      FDS_RIDER_GROUP_NAME        = var.user_pool_rider_group_name
+      FDS_LOG_LEVEL               = var.lambda_log_level
+      OTEL_EXPORTER_OTLP_ENDPOINT = var.otel_exporter_otlp_endpoint
     }
   }
 }
@@ -44,6 +44,51 @@ resource "aws_iam_policy" "lambda_role_policy" {
       "Effect": "Allow",
       "Resource": "arn:*:dynamodb:${var.region}:${data.aws_caller_identity.current.account_id}:table/${var.app_prefix}*"
     },
+    {
+      "Action": [
+        "execute-api:ManageConnections"
+      ],
+      "Effect": "Allow",
+      "Resource": "arn:aws:execute-api:${var.region}:${data.aws_caller_identity.current.account_id}:*/*/POST/@connections/*"
+    },
+    {
+      "Action": [
+        "dynamodb:DescribeStream",
+        "dynamodb:GetRecords",
+        "dynamodb:GetShardIterator",
+        "dynamodb:ListStreams"
+      ],
+      "Effect": "Allow",
+      "Resource": "arn:*:dynamodb:${var.region}:${data.aws_caller_identity.current.account_id}:table/${var.app_prefix}*/stream/*"
+    },
+    {
+      "Action": [
+        "events:PutEvents"
+      ],
+      "Effect": "Allow",
+      "Resource": "arn:aws:events:${var.region}:${data.aws_caller_identity.current.account_id}:event-bus/${var.app_prefix}*"
+    },
+    {
+      "Action": [
+        "sns:Publish"
+      ],
+      "Effect": "Allow",
+      "Resource": "arn:aws:sns:${var.region}:${data.aws_caller_identity.current.account_id}:${var.app_prefix}*"
+    },
+    {
+      "Action": [
+        "sns:Publish"
+      ],
+      "Effect": "Allow",
+      "Resource": "*"
+    },
+    {
+      "Action": [
+        "ses:SendEmail"
+      ],
+      "Effect": "Allow",
+      "Resource": "arn:aws:ses:${var.region}:${data.aws_caller_identity.current.account_id}:identity/*"
+    },
     {
       "Action": [
         "logs:*"
@@ -31,7 +31,16 @@ resource "aws_lambda_function" "placeorder" {
   }
   environment {
     variables = {
-      FDS_APPS_ORDERS_TABLE = aws_dynamodb_table.orders_table.id
+      FDS_APPS_ORDERS_TABLE       = aws_dynamodb_table.orders_table.id
+      FDS_APPS_RESTAURANTS_TABLE  = aws_dynamodb_table.restaurants_table.id
+      FDS_APPS_ZONES_TABLE        = aws_dynamodb_table.zones_table.id
+      FDS_ADMIN_GROUP_NAME        = var.user_pool_admin_group_name
+      FDS_ETA_BIKE_SPEED_KMH      = var.eta_bike_speed_kmh
+      FDS_APPS_CONNECTIONS_TABLE  = aws_dynamodb_table.connections_table.id
+      FDS_WEBSOCKET_ENDPOINT      = local.websocket_endpoint
+      FDS_APPS_OUTBOX_TABLE       = aws_dynamodb_table.outbox_table.id
+      FDS_LOG_LEVEL               = var.lambda_log_level
+      OTEL_EXPORTER_OTLP_ENDPOINT = var.otel_exporter_otlp_endpoint
     }
   }
 }
@@ -50,7 +59,16 @@ resource "aws_lambda_function" "listorders" {
   }
   environment {
     variables = {
-      FDS_APPS_ORDERS_TABLE = aws_dynamodb_table.orders_table.id
+      FDS_APPS_ORDERS_TABLE       = aws_dynamodb_table.orders_table.id
+      FDS_APPS_RESTAURANTS_TABLE  = aws_dynamodb_table.restaurants_table.id
+      FDS_APPS_ZONES_TABLE        = aws_dynamodb_table.zones_table.id
+      FDS_ADMIN_GROUP_NAME        = var.user_pool_admin_group_name
+      FDS_ETA_BIKE_SPEED_KMH      = var.eta_bike_speed_kmh
+      FDS_APPS_CONNECTIONS_TABLE  = aws_dynamodb_table.connections_table.id
+      FDS_WEBSOCKET_ENDPOINT      = local.websocket_endpoint
+      FDS_APPS_OUTBOX_TABLE       = aws_dynamodb_table.outbox_table.id
+      FDS_LOG_LEVEL               = var.lambda_log_level
+      OTEL_EXPORTER_OTLP_ENDPOINT = var.otel_exporter_otlp_endpoint
     }
   }
 }
@@ -69,7 +87,43 @@ resource "aws_lambda_function" "getorder" {
   }
   environment {
     variables = {
-      FDS_APPS_ORDERS_TABLE = aws_dynamodb_table.orders_table.id
+      FDS_APPS_ORDERS_TABLE       = aws_dynamodb_table.orders_table.id
+      FDS_APPS_RESTAURANTS_TABLE  = aws_dynamodb_table.restaurants_table.id
+      FDS_APPS_ZONES_TABLE        = aws_dynamodb_table.zones_table.id
+      FDS_ADMIN_GROUP_NAME        = var.user_pool_admin_group_name
+      FDS_ETA_BIKE_SPEED_KMH      = var.eta_bike_speed_kmh
+      FDS_APPS_CONNECTIONS_TABLE  = aws_dynamodb_table.connections_table.id
+      FDS_WEBSOCKET_ENDPOINT      = local.websocket_endpoint
+      FDS_APPS_OUTBOX_TABLE       = aws_dynamodb_table.outbox_table.id
+      FDS_LOG_LEVEL               = var.lambda_log_level
+      OTEL_EXPORTER_OTLP_ENDPOINT = var.otel_exporter_otlp_endpoint
+    }
+  }
+}
+resource "aws_lambda_function" "modifyorder" {
+  filename         = data.archive_file.placeorder_lambda_zip.output_path
+  function_name    = "${var.app_prefix}ModifyOrder"
+  role             = aws_iam_role.lambda_role.arn
+  handler          = "bootstrap"
+  source_code_hash = data.archive_file.placeorder_lambda_zip.output_base64sha256
+  runtime          = var.lambda_runtime[1]
+  architectures    = var.architectures
+  timeout          = var.lambda_timeout
+  tracing_config {
+    mode = var.lambda_tracing_config
+  }
+  environment {
+    variables = {
+      FDS_APPS_ORDERS_TABLE       = aws_dynamodb_table.orders_table.id
+      FDS_APPS_RESTAURANTS_TABLE  = aws_dynamodb_table.restaurants_table.id
+      FDS_APPS_ZONES_TABLE        = aws_dynamodb_table.zones_table.id
+      FDS_ADMIN_GROUP_NAME        = var.user_pool_admin_group_name
+      FDS_ETA_BIKE_SPEED_KMH      = var.eta_bike_speed_kmh
+      FDS_APPS_CONNECTIONS_TABLE  = aws_dynamodb_table.connections_table.id
+      FDS_WEBSOCKET_ENDPOINT      = local.websocket_endpoint
+      FDS_APPS_OUTBOX_TABLE       = aws_dynamodb_table.outbox_table.id
+      FDS_LOG_LEVEL               = var.lambda_log_level
+      OTEL_EXPORTER_OTLP_ENDPOINT = var.otel_exporter_otlp_endpoint
     }
   }
 }
@@ -85,3 +139,6 @@ output "getorder_lambda" {
   #value = aws_lambda_function.getusers.function_name
   value = "${var.arn_aws_lambda_base}:${var.region}:${var.account_id}:function:${aws_lambda_function.getorder.function_name}"
 }
+output "modifyorder_lambda" {
+  value = "${var.arn_aws_lambda_base}:${var.region}:${var.account_id}:function:${aws_lambda_function.modifyorder.function_name}"
+}
@@ -21,7 +21,18 @@ resource "aws_lambda_function" "getusers" {
   }
   environment {
     variables = {
-      FDS_APPS_USERS_TABLE = aws_dynamodb_table.users_table.id
+      FDS_APPS_USERS_TABLE        = aws_dynamodb_table.users_table.id
+      FDS_APPS_ORDERS_TABLE       = aws_dynamodb_table.orders_table.id
+      FDS_APPS_ADDRESS_TABLE      = aws_dynamodb_table.addresstable.id
+      FDS_APPS_FAVORITE_TABLE     = aws_dynamodb_table.favoritetable.id
+      FDS_APPS_AUDIT_TABLE        = aws_dynamodb_table.audit_table.id
+      FDS_APPS_EXPORTS_TABLE      = aws_dynamodb_table.exports_table.id
+      FDS_APPS_EXPORTS_BUCKET     = aws_s3_bucket.exports_bucket.id
+      FDS_EXPORTER_FUNCTION       = aws_lambda_function.exporter.function_name
+      FDS_ERASER_FUNCTION         = aws_lambda_function.eraser.function_name
+      FDS_ADMIN_GROUP_NAME        = var.user_pool_admin_group_name
+      FDS_LOG_LEVEL               = var.lambda_log_level
+      OTEL_EXPORTER_OTLP_ENDPOINT = var.otel_exporter_otlp_endpoint
     }
   }
 }
@@ -40,7 +51,18 @@ resource "aws_lambda_function" "getuser" {
   }
   environment {
     variables = {
-      FDS_APPS_USERS_TABLE = aws_dynamodb_table.users_table.id
+      FDS_APPS_USERS_TABLE        = aws_dynamodb_table.users_table.id
+      FDS_APPS_ORDERS_TABLE       = aws_dynamodb_table.orders_table.id
+      FDS_APPS_ADDRESS_TABLE      = aws_dynamodb_table.addresstable.id
+      FDS_APPS_FAVORITE_TABLE     = aws_dynamodb_table.favoritetable.id
+      FDS_APPS_AUDIT_TABLE        = aws_dynamodb_table.audit_table.id
+      FDS_APPS_EXPORTS_TABLE      = aws_dynamodb_table.exports_table.id
+      FDS_APPS_EXPORTS_BUCKET     = aws_s3_bucket.exports_bucket.id
+      FDS_EXPORTER_FUNCTION       = aws_lambda_function.exporter.function_name
+      FDS_ERASER_FUNCTION         = aws_lambda_function.eraser.function_name
+      FDS_ADMIN_GROUP_NAME        = var.user_pool_admin_group_name
+      FDS_LOG_LEVEL               = var.lambda_log_level
+      OTEL_EXPORTER_OTLP_ENDPOINT = var.otel_exporter_otlp_endpoint
     }
   }
 }
@@ -59,11 +81,58 @@ resource "aws_lambda_function" "putuser" {
   }
   environment {
     variables = {
-      FDS_APPS_USERS_TABLE = aws_dynamodb_table.users_table.id
+      FDS_APPS_USERS_TABLE        = aws_dynamodb_table.users_table.id
+      FDS_APPS_ORDERS_TABLE       = aws_dynamodb_table.orders_table.id
+      FDS_APPS_ADDRESS_TABLE      = aws_dynamodb_table.addresstable.id
+      FDS_APPS_FAVORITE_TABLE     = aws_dynamodb_table.favoritetable.id
+      FDS_APPS_AUDIT_TABLE        = aws_dynamodb_table.audit_table.id
+      FDS_APPS_EXPORTS_TABLE      = aws_dynamodb_table.exports_table.id
+      FDS_APPS_EXPORTS_BUCKET     = aws_s3_bucket.exports_bucket.id
+      FDS_EXPORTER_FUNCTION       = aws_lambda_function.exporter.function_name
+      FDS_ERASER_FUNCTION         = aws_lambda_function.eraser.function_name
+      FDS_ADMIN_GROUP_NAME        = var.user_pool_admin_group_name
+      FDS_LOG_LEVEL               = var.lambda_log_level
+      OTEL_EXPORTER_OTLP_ENDPOINT = var.otel_exporter_otlp_endpoint
     }
   }
 }
 
+data "archive_file" "signup_lambda_zip" {
+  type        = "zip"
+  output_path = "../dist/${var.app_prefix}.lambda.signup.zip"
+  source_file = "../dist/signup/bootstrap"
+}
+
+# Cognito post confirmation trigger
+resource "aws_lambda_function" "signup" {
+  filename         = data.archive_file.signup_lambda_zip.output_path
+  function_name    = "${var.app_prefix}SignUp"
+  role             = aws_iam_role.lambda_role.arn
+  handler          = "bootstrap"
+  source_code_hash = data.archive_file.signup_lambda_zip.output_base64sha256
+  runtime          = var.lambda_runtime[1]
+  architectures    = var.architectures
+  timeout          = var.lambda_timeout
+  tracing_config {
+    mode = var.lambda_tracing_config
+  }
+  environment {
+    variables = {
+      FDS_APPS_USERS_TABLE        = aws_dynamodb_table.users_table.id
+      FDS_LOG_LEVEL               = var.lambda_log_level
+      OTEL_EXPORTER_OTLP_ENDPOINT = var.otel_exporter_otlp_endpoint
+    }
+  }
+}
+
+resource "aws_lambda_permission" "allow_cognito_on_signup" {
+  statement_id  = "${var.app_prefix}CognitoPermission"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.signup.function_name
+  principal     = "cognito-idp.amazonaws.com"
+  source_arn    = aws_cognito_user_pool.user_pool.arn
+}
+
 output "getusers_lambda" {
   #value = aws_lambda_function.getusers.function_name
   value = "${var.arn_aws_lambda_base}:${var.region}:${var.account_id}:function:${aws_lambda_function.getusers.function_name}"
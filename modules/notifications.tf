@@ -0,0 +1,65 @@
+# Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+# SPDX-License-Identifier: MIT-0
+
+# customer and restaurant notifications of the order events, see src/notifications
+data "archive_file" "notifications_lambda_zip" {
+  type        = "zip"
+  output_path = "../dist/${var.app_prefix}.lambda.notifications.zip"
+  source_file = "../dist/notifications/bootstrap"
+}
+
+resource "aws_lambda_function" "notifications" {
+  filename         = data.archive_file.notifications_lambda_zip.output_path
+  function_name    = "${var.app_prefix}Notifications"
+  role             = aws_iam_role.lambda_role.arn
+  handler          = "bootstrap"
+  source_code_hash = data.archive_file.notifications_lambda_zip.output_base64sha256
+  runtime          = var.lambda_runtime[1]
+  architectures    = var.architectures
+  timeout          = var.lambda_timeout
+  tracing_config {
+    mode = var.lambda_tracing_config
+  }
+  environment {
+    variables = {
+      FDS_APPS_USERS_TABLE         = aws_dynamodb_table.users_table.id
+      FDS_APPS_RESTAURANTS_TABLE   = aws_dynamodb_table.restaurants_table.id
+      FDS_NOTIFICATIONS_PROVIDER   = "aws"
+      FDS_NOTIFICATIONS_EMAIL_FROM = var.notifications_email_from
+      FDS_LOG_LEVEL                = var.lambda_log_level
+      OTEL_EXPORTER_OTLP_ENDPOINT  = var.otel_exporter_otlp_endpoint
+    }
+  }
+}
+
+resource "aws_cloudwatch_event_rule" "order_notifications" {
+  name           = "${var.app_prefix}OrderNotifications"
+  description    = "notify customers and restaurants of placed, acknowledged and cancelled orders"
+  event_bus_name = aws_cloudwatch_event_bus.orders_event_bus.name
+  event_pattern = jsonencode({
+    source      = ["fds.orders"]
+    detail-type = ["OrderPlaced", "OrderAcknowledged", "OrderCancelled"]
+  })
+}
+
+resource "aws_cloudwatch_event_target" "order_notifications" {
+  rule           = aws_cloudwatch_event_rule.order_notifications.name
+  event_bus_name = aws_cloudwatch_event_bus.orders_event_bus.name
+  arn            = aws_lambda_function.notifications.arn
+  retry_policy {
+    maximum_event_age_in_seconds = 3600
+    maximum_retry_attempts       = 5
+  }
+}
+
+resource "aws_lambda_permission" "allow_events_on_notifications" {
+  statement_id  = "${var.app_prefix}NotificationsEventsPermission"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.notifications.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.order_notifications.arn
+}
+
+output "notifications_lambda" {
+  value = aws_lambda_function.notifications.function_name
+}
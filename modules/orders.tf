@@ -18,6 +18,9 @@ resource "aws_dynamodb_table" "orders_table" {
     type = "S"
   }
 
+  # order change events, see streams.tf
+  stream_enabled   = true
+  stream_view_type = "NEW_AND_OLD_IMAGES"
 }
 
 output "orders_table" {
@@ -0,0 +1,110 @@
+# Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+# SPDX-License-Identifier: MIT-0
+
+# domain events written with the items that cause them, see src/internal/outbox.
+# pending records are in the sparse pending-index until the relay publishes them.
+resource "aws_dynamodb_table" "outbox_table" {
+  name         = "${var.app_prefix}Outbox"
+  billing_mode = "PROVISIONED"
+  hash_key     = "outboxid"
+
+  read_capacity  = 5
+  write_capacity = 5
+  attribute {
+    name = "outboxid"
+    type = "S"
+  }
+  attribute {
+    name = "pending"
+    type = "S"
+  }
+  attribute {
+    name = "createdon"
+    type = "N"
+  }
+  global_secondary_index {
+    name            = "pending-index"
+    hash_key        = "pending"
+    range_key       = "createdon"
+    projection_type = "ALL"
+    read_capacity   = 5
+    write_capacity  = 5
+  }
+  ttl {
+    attribute_name = "expiresat"
+    enabled        = true
+  }
+
+  # wakes the relay when records are written
+  stream_enabled   = true
+  stream_view_type = "KEYS_ONLY"
+}
+
+data "archive_file" "relay_lambda_zip" {
+  type        = "zip"
+  output_path = "../dist/${var.app_prefix}.lambda.relay.zip"
+  source_file = "../dist/relay/bootstrap"
+}
+
+# one relay at a time keeps the event order of the pending index
+resource "aws_lambda_function" "relay" {
+  filename                       = data.archive_file.relay_lambda_zip.output_path
+  function_name                  = "${var.app_prefix}OutboxRelay"
+  role                           = aws_iam_role.lambda_role.arn
+  handler                        = "bootstrap"
+  source_code_hash               = data.archive_file.relay_lambda_zip.output_base64sha256
+  runtime                        = var.lambda_runtime[1]
+  architectures                  = var.architectures
+  timeout                        = var.lambda_timeout
+  reserved_concurrent_executions = 1
+  tracing_config {
+    mode = var.lambda_tracing_config
+  }
+  environment {
+    variables = {
+      FDS_APPS_OUTBOX_TABLE       = aws_dynamodb_table.outbox_table.id
+      FDS_EVENT_BUS_NAME          = aws_cloudwatch_event_bus.orders_event_bus.name
+      FDS_LOG_LEVEL               = var.lambda_log_level
+      OTEL_EXPORTER_OTLP_ENDPOINT = var.otel_exporter_otlp_endpoint
+    }
+  }
+}
+
+resource "aws_lambda_event_source_mapping" "outbox_stream" {
+  event_source_arn                   = aws_dynamodb_table.outbox_table.stream_arn
+  function_name                      = aws_lambda_function.relay.arn
+  starting_position                  = "LATEST"
+  batch_size                         = 100
+  maximum_batching_window_in_seconds = 1
+
+  # only new records wake the relay, not its own publishedon updates or ttl deletes
+  filter_criteria {
+    filter {
+      pattern = jsonencode({ eventName = ["INSERT"] })
+    }
+  }
+}
+
+# records of failed drains are published within a minute
+resource "aws_cloudwatch_event_rule" "relay_schedule" {
+  name                = "${var.app_prefix}OutboxRelaySchedule"
+  description         = "publish the pending outbox records"
+  schedule_expression = "rate(1 minute)"
+}
+
+resource "aws_cloudwatch_event_target" "relay_schedule" {
+  rule = aws_cloudwatch_event_rule.relay_schedule.name
+  arn  = aws_lambda_function.relay.arn
+}
+
+resource "aws_lambda_permission" "allow_events_on_relay" {
+  statement_id  = "${var.app_prefix}RelayEventsPermission"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.relay.function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.relay_schedule.arn
+}
+
+output "outbox_table" {
+  value = aws_dynamodb_table.outbox_table.id
+}
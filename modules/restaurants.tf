@@ -0,0 +1,16 @@
+resource "aws_dynamodb_table" "restaurants_table" {
+  name         = "${var.app_prefix}Restaurants"
+  billing_mode = "PROVISIONED"
+  hash_key     = "restaurantid"
+
+  read_capacity  = 5
+  write_capacity = 5
+  attribute {
+    name = "restaurantid"
+    type = "S"
+  }
+}
+
+output "restaurants_table" {
+  value = aws_dynamodb_table.restaurants_table.id
+}
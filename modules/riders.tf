@@ -0,0 +1,92 @@
+# Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+# SPDX-License-Identifier: MIT-0
+
+# e-bike courier profiles and shifts keyed by the rider's cognito sub
+resource "aws_dynamodb_table" "riders_table" {
+  name         = "${var.app_prefix}Riders"
+  billing_mode = "PROVISIONED"
+  hash_key     = "riderid"
+
+  read_capacity  = 5
+  write_capacity = 5
+  attribute {
+    name = "riderid"
+    type = "S"
+  }
+}
+
+# rider location pings expire after a day
+resource "aws_dynamodb_table" "rider_locations_table" {
+  name         = "${var.app_prefix}RiderLocations"
+  billing_mode = "PROVISIONED"
+  hash_key     = "riderid"
+  range_key    = "recordedon"
+
+  read_capacity  = 5
+  write_capacity = 10
+  attribute {
+    name = "riderid"
+    type = "S"
+  }
+  attribute {
+    name = "recordedon"
+    type = "N"
+  }
+  ttl {
+    attribute_name = "expiresat"
+    enabled        = true
+  }
+}
+
+data "archive_file" "riders_lambda_zip" {
+  type        = "zip"
+  output_path = "../dist/${var.app_prefix}.lambda.riders.zip"
+  source_file = "../dist/riders/bootstrap"
+}
+
+resource "aws_lambda_function" "riders" {
+  filename         = data.archive_file.riders_lambda_zip.output_path
+  function_name    = "${var.app_prefix}Riders"
+  role             = aws_iam_role.lambda_role.arn
+  handler          = "bootstrap"
+  source_code_hash = data.archive_file.riders_lambda_zip.output_base64sha256
+  runtime          = var.lambda_runtime[1]
+  architectures    = var.architectures
+  timeout          = var.lambda_timeout
+  tracing_config {
+    mode = var.lambda_tracing_config
+  }
+  environment {
+    variables = {
+      FDS_APPS_RIDERS_TABLE          = aws_dynamodb_table.riders_table.id
+      FDS_APPS_RIDER_LOCATIONS_TABLE = aws_dynamodb_table.rider_locations_table.id
+      FDS_APPS_ORDERS_TABLE          = aws_dynamodb_table.orders_table.id
+      FDS_APPS_RESTAURANTS_TABLE     = aws_dynamodb_table.restaurants_table.id
+      FDS_APPS_DISPATCH_OFFERS_TABLE = aws_dynamodb_table.dispatch_offers_table.id
+      FDS_ADMIN_GROUP_NAME           = var.user_pool_admin_group_name
+      FDS_ETA_BIKE_SPEED_KMH         = var.eta_bike_speed_kmh
+      FDS_APPS_CONNECTIONS_TABLE     = aws_dynamodb_table.connections_table.id
+      FDS_WEBSOCKET_ENDPOINT         = local.websocket_endpoint
+      FDS_LOG_LEVEL                  = var.lambda_log_level
+      OTEL_EXPORTER_OTLP_ENDPOINT    = var.otel_exporter_otlp_endpoint
+    }
+  }
+}
+
+resource "aws_lambda_permission" "allow_api_on_riders" {
+  statement_id  = "${var.app_prefix}LambdaPermission"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.riders.function_name
+  principal     = "apigateway.${var.region}.amazonaws.com"
+  source_arn    = "${aws_api_gateway_rest_api.rest_api.execution_arn}/*/*/*"
+}
+
+output "riders_table" {
+  value = aws_dynamodb_table.riders_table.id
+}
+output "rider_locations_table" {
+  value = aws_dynamodb_table.rider_locations_table.id
+}
+output "riders_lambda" {
+  value = "${var.arn_aws_lambda_base}:${var.region}:${var.account_id}:function:${aws_lambda_function.riders.function_name}"
+}
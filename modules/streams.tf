@@ -0,0 +1,59 @@
+# Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+# SPDX-License-Identifier: MIT-0
+
+# order change events of the orders table stream. consumers match the event bus rules with
+# {"source": ["fds.orders"], "detail-type": ["OrderCancelled"]} or subscribe to the topic.
+resource "aws_cloudwatch_event_bus" "orders_event_bus" {
+  name = "${var.app_prefix}Orders"
+}
+
+resource "aws_sns_topic" "order_events_topic" {
+  name = "${var.app_prefix}OrderEvents"
+}
+
+data "archive_file" "streams_lambda_zip" {
+  type        = "zip"
+  output_path = "../dist/${var.app_prefix}.lambda.streams.zip"
+  source_file = "../dist/streams/bootstrap"
+}
+
+resource "aws_lambda_function" "streams" {
+  filename         = data.archive_file.streams_lambda_zip.output_path
+  function_name    = "${var.app_prefix}OrderStreams"
+  role             = aws_iam_role.lambda_role.arn
+  handler          = "bootstrap"
+  source_code_hash = data.archive_file.streams_lambda_zip.output_base64sha256
+  runtime          = var.lambda_runtime[1]
+  architectures    = var.architectures
+  timeout          = var.lambda_timeout
+  tracing_config {
+    mode = var.lambda_tracing_config
+  }
+  environment {
+    variables = {
+      FDS_EVENT_BUS_NAME          = aws_cloudwatch_event_bus.orders_event_bus.name
+      FDS_ORDER_EVENTS_TOPIC_ARN  = aws_sns_topic.order_events_topic.arn
+      FDS_LOG_LEVEL               = var.lambda_log_level
+      OTEL_EXPORTER_OTLP_ENDPOINT = var.otel_exporter_otlp_endpoint
+    }
+  }
+}
+
+# failed records are retried from the first failure, the remainder of the batch is not published twice
+resource "aws_lambda_event_source_mapping" "orders_stream" {
+  event_source_arn               = aws_dynamodb_table.orders_table.stream_arn
+  function_name                  = aws_lambda_function.streams.arn
+  starting_position              = "LATEST"
+  batch_size                     = 100
+  maximum_retry_attempts         = 10
+  bisect_batch_on_function_error = true
+  function_response_types        = ["ReportBatchItemFailures"]
+}
+
+output "orders_event_bus" {
+  value = aws_cloudwatch_event_bus.orders_event_bus.name
+}
+
+output "order_events_topic" {
+  value = aws_sns_topic.order_events_topic.arn
+}
@@ -0,0 +1,143 @@
+# Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+# SPDX-License-Identifier: MIT-0
+
+# websocket order subscriptions, one item per order and connection
+resource "aws_dynamodb_table" "connections_table" {
+  name         = "${var.app_prefix}Connections"
+  billing_mode = "PROVISIONED"
+  hash_key     = "orderid"
+  range_key    = "connectionid"
+
+  read_capacity  = 5
+  write_capacity = 5
+  attribute {
+    name = "orderid"
+    type = "S"
+  }
+  attribute {
+    name = "connectionid"
+    type = "S"
+  }
+  global_secondary_index {
+    name            = "connectionid-index"
+    hash_key        = "connectionid"
+    range_key       = "orderid"
+    projection_type = "ALL"
+    read_capacity   = 5
+    write_capacity  = 5
+  }
+  ttl {
+    attribute_name = "expiresat"
+    enabled        = true
+  }
+}
+
+data "archive_file" "tracking_lambda_zip" {
+  type        = "zip"
+  output_path = "../dist/${var.app_prefix}.lambda.tracking.zip"
+  source_file = "../dist/tracking/bootstrap"
+}
+
+# $connect, $disconnect, subscribe and unsubscribe routes of the websocket api
+resource "aws_lambda_function" "tracking" {
+  filename         = data.archive_file.tracking_lambda_zip.output_path
+  function_name    = "${var.app_prefix}Tracking"
+  role             = aws_iam_role.lambda_role.arn
+  handler          = "bootstrap"
+  source_code_hash = data.archive_file.tracking_lambda_zip.output_base64sha256
+  runtime          = var.lambda_runtime[1]
+  architectures    = var.architectures
+  timeout          = var.lambda_timeout
+  tracing_config {
+    mode = var.lambda_tracing_config
+  }
+  environment {
+    variables = {
+      FDS_APPS_ORDERS_TABLE       = aws_dynamodb_table.orders_table.id
+      FDS_APPS_CONNECTIONS_TABLE  = aws_dynamodb_table.connections_table.id
+      FDS_LOG_LEVEL               = var.lambda_log_level
+      OTEL_EXPORTER_OTLP_ENDPOINT = var.otel_exporter_otlp_endpoint
+    }
+  }
+}
+
+# https://registry.terraform.io/providers/hashicorp/aws/latest/docs/resources/apigatewayv2_api
+resource "aws_apigatewayv2_api" "tracking" {
+  name                       = "${var.app_prefix}Tracking"
+  protocol_type              = "WEBSOCKET"
+  route_selection_expression = "$request.body.action"
+}
+
+# browsers pass the cognito token with wss://...?token=
+resource "aws_apigatewayv2_authorizer" "tracking" {
+  api_id           = aws_apigatewayv2_api.tracking.id
+  name             = "${var.app_prefix}TrackingAuthorizer"
+  authorizer_type  = "REQUEST"
+  authorizer_uri   = aws_lambda_function.users_authorizer.invoke_arn
+  identity_sources = ["route.request.querystring.token"]
+}
+
+resource "aws_apigatewayv2_integration" "tracking" {
+  api_id           = aws_apigatewayv2_api.tracking.id
+  integration_type = "AWS_PROXY"
+  integration_uri  = aws_lambda_function.tracking.invoke_arn
+}
+
+resource "aws_apigatewayv2_route" "tracking_connect" {
+  api_id             = aws_apigatewayv2_api.tracking.id
+  route_key          = "$connect"
+  authorization_type = "CUSTOM"
+  authorizer_id      = aws_apigatewayv2_authorizer.tracking.id
+  target             = "integrations/${aws_apigatewayv2_integration.tracking.id}"
+}
+
+resource "aws_apigatewayv2_route" "tracking" {
+  for_each = toset(["$disconnect", "$default", "subscribe", "unsubscribe"])
+
+  api_id    = aws_apigatewayv2_api.tracking.id
+  route_key = each.key
+  target    = "integrations/${aws_apigatewayv2_integration.tracking.id}"
+}
+
+# the subscribe and unsubscribe replies are returned to the client
+resource "aws_apigatewayv2_route_response" "tracking" {
+  for_each = toset(["$default", "subscribe", "unsubscribe"])
+
+  api_id             = aws_apigatewayv2_api.tracking.id
+  route_id           = aws_apigatewayv2_route.tracking[each.key].id
+  route_response_key = "$default"
+}
+
+resource "aws_apigatewayv2_stage" "tracking" {
+  api_id      = aws_apigatewayv2_api.tracking.id
+  name        = "dev"
+  auto_deploy = true
+}
+
+resource "aws_lambda_permission" "allow_websocket_on_tracking" {
+  statement_id  = "${var.app_prefix}WebSocketPermission"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.tracking.function_name
+  principal     = "apigateway.${var.region}.amazonaws.com"
+  source_arn    = "${aws_apigatewayv2_api.tracking.execution_arn}/*/*"
+}
+
+resource "aws_lambda_permission" "allow_websocket_on_authorizer" {
+  statement_id  = "${var.app_prefix}WebSocketAuthorizerPermission"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.users_authorizer.function_name
+  principal     = "apigateway.${var.region}.amazonaws.com"
+  source_arn    = "${aws_apigatewayv2_api.tracking.execution_arn}/authorizers/${aws_apigatewayv2_authorizer.tracking.id}"
+}
+
+locals {
+  # management api endpoint of the lambdas pushing order updates
+  websocket_endpoint = "https://${aws_apigatewayv2_api.tracking.id}.execute-api.${var.region}.amazonaws.com/${aws_apigatewayv2_stage.tracking.name}"
+}
+
+output "tracking_websocket_url" {
+  value = aws_apigatewayv2_stage.tracking.invoke_url
+}
+output "tracking_lambda" {
+  value = aws_lambda_function.tracking.function_name
+}
@@ -35,4 +35,20 @@ variable "lambda_tracing_config" {
 }
 variable "user_pool_admin_group_name" {
   default = "FDSAppsPoolAdmins"
-}
\ No newline at end of file
+}
+variable "user_pool_rider_group_name" {
+  default = "FDSAppsPoolRiders"
+}
+variable "eta_bike_speed_kmh" {
+  default = "15"
+}
+variable "lambda_log_level" {
+  default = "info"
+}
+variable "otel_exporter_otlp_endpoint" {
+  default = ""
+}
+variable "notifications_email_from" {
+  description = "SES verified sender of order emails. email notifications are not sent when empty."
+  default     = ""
+}
@@ -0,0 +1,20 @@
+# Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+# SPDX-License-Identifier: MIT-0
+
+# restaurant and city delivery zones, GeoJSON polygons managed with the orders api
+resource "aws_dynamodb_table" "zones_table" {
+  name         = "${var.app_prefix}Zones"
+  billing_mode = "PROVISIONED"
+  hash_key     = "zoneid"
+
+  read_capacity  = 5
+  write_capacity = 5
+  attribute {
+    name = "zoneid"
+    type = "S"
+  }
+}
+
+output "zones_table" {
+  value = aws_dynamodb_table.zones_table.id
+}
@@ -2,12 +2,23 @@ package main
 
 import (
 	"context"
+	"crypto/rsa"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math/big"
 	"net/http"
 	"os"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/kscott5/fds/internal/config"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/internal/metrics"
+	"github.com/kscott5/fds/internal/tracing"
 
 	"github.com/golang-jwt/jwt/v5"
 
@@ -15,6 +26,8 @@ import (
 	"github.com/aws/aws-lambda-go/lambda"
 	_ "github.com/aws/aws-lambda-go/lambdacontext" // IMPORTANT: package level init() in use.
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -25,11 +38,9 @@ const (
 	pattern = `^[/.a-zA-Z0-9-\*]+$`
 )
 
-var (
-	UserPoolId     = os.Getenv("FDS_USER_POOL_ID")
-	AppClientId    = os.Getenv("FDS_APPLICATION_CLIENT_ID")
-	AdminGroupName = os.Getenv("FDS_ADMIN_GROUP_NAME")
-) 
+// Settings is loaded by main before the first request
+var Settings config.Authorizer
+
 type HttpEffect uint8
 const (
 	Allow HttpEffect = iota 
@@ -59,6 +70,7 @@ func (h HttpMethod) String() string {
 	case GET: return "GET"
 	case POST: return "POST"
 	case PUT: return "PUT"
+	case PATCH: return "PATCH"
 	case HEAD: return "HEAD"
 	case DELETE: return "DELETE"
 	case OPTIONS: return "OPTIONS"
@@ -157,7 +169,7 @@ func (pr *LocalAuthorizerResponse) getStatementForEffect(effect string, methods
 }
 
 func (pr *LocalAuthorizerResponse) Build(principalId string) error {
-	logger, _ := zap.NewDevelopment()
+	logger := logging.Logger()
 	logger.Info("build local authorizer response")
 
 	/*Generates the policy document based on the internal lists of allowed and denied
@@ -172,7 +184,7 @@ func (pr *LocalAuthorizerResponse) Build(principalId string) error {
 	pr.PolicyDocument.Version = version
 	pr.PolicyDocument.Statement = []events.IAMPolicyStatement{}
 
-	logger.Debug(fmt.Sprint(pr))
+	logger.Debug("policy document", zap.Int("allowMethods", len(pr.allowMethods)), zap.Int("denyMethods", len(pr.denyMethods)))
 
 	var allowMethodsStatement = pr.getStatementForEffect("Allow", pr.allowMethods)
 	var denyMethodsStatement = pr.getStatementForEffect("Deny", pr.denyMethods)
@@ -196,10 +208,53 @@ type WellKnowJwtKey struct {
 	Modulus			string `json:"n"`	// 9.3.  RSA Private Key Representations and Blinding
 }
 
+// PublicKey returns the RSA public key of the json web key modulus and exponent
+func (k WellKnowJwtKey) PublicKey() (*rsa.PublicKey, error) {
+	if k.KeyType != "RSA" {
+		return nil, fmt.Errorf("key %s type %s not RSA", k.KeyId, k.KeyType)
+	}
+
+	modulus, err := base64.RawURLEncoding.DecodeString(k.Modulus)
+	if err != nil {
+		return nil, fmt.Errorf("key %s modulus: %w", k.KeyId, err)
+	}
+	exponent, err := base64.RawURLEncoding.DecodeString(k.PublicExponent)
+	if err != nil {
+		return nil, fmt.Errorf("key %s exponent: %w", k.KeyId, err)
+	}
+
+	e := new(big.Int).SetBytes(exponent)
+	if len(modulus) == 0 || !e.IsInt64() || e.Int64() < 3 {
+		return nil, fmt.Errorf("key %s not a valid RSA public key", k.KeyId)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: int(e.Int64())}, nil
+}
+
 type CustomMapClaims struct {
 	jwt.MapClaims
 }
 
+// ValidateClient checks the token was issued to the app client. id tokens name the client
+// with aud, access tokens with client_id.
+func (c CustomMapClaims) ValidateClient(appClientId string) error {
+	tokenUse, _ := c.MapClaims["token_use"].(string)
+	switch tokenUse {
+	case "id":
+		if audience, err := c.GetAudience(); err != nil {
+			return err
+		} else if len(audience) != 1 || audience[0] != appClientId {
+			return fmt.Errorf("token audience %v not the app client", audience)
+		}
+	case "access":
+		if clientId, _ := c.MapClaims["client_id"].(string); clientId != appClientId {
+			return fmt.Errorf("token client id %s not the app client", clientId)
+		}
+	default:
+		return fmt.Errorf("token use %q not id or access", tokenUse)
+	}
+	return nil
+}
+
 func (c CustomMapClaims) GetTokenId() string {
 	if tokenId, ok := c.MapClaims["token_id"]; ok {
 		return tokenId.(string)
@@ -243,97 +298,211 @@ func (c CustomMapClaims) GetCognitoUserName() string {
 	return ""
 }
 
-func GetWellKnownJwksKeys(region, userPoolId string)([]WellKnowJwtKey, error) {
-	logger, _ := zap.NewDevelopment()
+// UserPoolIssuer is the iss claim of the user pool tokens
+func UserPoolIssuer(region, userPoolId string) string {
+	return fmt.Sprintf("https://cognito-idp.%s.amazonaws.com/%s", region, userPoolId)
+}
+
+func GetWellKnownJwksKeys(ctx context.Context, keysUrl string)(keys []WellKnowJwtKey, err error) {
+	logger := logging.FromContext(ctx)
 	logger.Info("get well known jwks keys")
 
-	// KEYS URL -- REPLACE WHEN CHANGING IDENTITY PROVIDER
-	keysUrl := fmt.Sprintf("https://cognito-idp.%s.amazonaws.com/%s/.well-known/jwks.json", region, userPoolId)
+	if path, found := jwksFile(keysUrl); found {
+		if body, err := os.ReadFile(path); err != nil {
+			return nil, err
+		} else {
+			return parseJwks(ctx, body)
+		}
+	}
+
+	ctx, span := tracing.Start(ctx, "GET jwks.json", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("http.request.method", "GET"), attribute.String("url.full", keysUrl)))
+	defer func() { tracing.End(span, err) }()
 
-	res, err := http.Get(keysUrl)
+	req, err := http.NewRequestWithContext(ctx, "GET", keysUrl, nil)
 	if err != nil {
-		logger.Debug(fmt.Sprint(err))
 		return nil, err
 	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		logger.Debug("jwks keys not available", zap.Error(err))
+		return nil, err
+	}
+	defer res.Body.Close()
 	
-	size := res.ContentLength
-	body := make([]byte, size)
-	
-	_, err = res.Body.Read(body)
+	span.SetAttributes(attribute.Int("http.response.status_code", res.StatusCode))
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks keys not available: %s", res.Status)
+	}
+
+	// chunked responses have no content length
+	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		logger.Debug(fmt.Sprint(err))
+		logger.Debug("jwks keys not readable", zap.Error(err))
 		return nil, err
 	}
+	return parseJwks(ctx, body)
+}
+
+// jwksFile returns the path of local key sets, e.g. /tmp/jwks.json or file:///tmp/jwks.json
+func jwksFile(keysUrl string) (string, bool) {
+	if path, found := strings.CutPrefix(keysUrl, "file://"); found {
+		return path, true
+	}
+	return keysUrl, !strings.Contains(keysUrl, "://")
+}
+
+func parseJwks(ctx context.Context, body []byte) ([]WellKnowJwtKey, error) {
+	logger := logging.FromContext(ctx)
 
-	keys := make(map[string][]WellKnowJwtKey,1)
-	if err := json.Unmarshal(body, &keys); err != nil {
-		logger.Debug(fmt.Sprint(err))
+	wellKnown := make(map[string][]WellKnowJwtKey,1)
+	if err := json.Unmarshal(body, &wellKnown); err != nil {
+		logger.Debug("jwks keys not valid json", zap.Error(err))
 		return nil, err
 	}
 
-	return keys["keys"], nil
+	return wellKnown["keys"], nil
+}
+
+// jwksReloadWait is the least time between jwks downloads of unknown key ids
+const jwksReloadWait = time.Minute
+
+// jwks keys are downloaded once per container. an unknown key id reloads the keys
+// after a user pool key rotation. local files are read every time, they change with
+// every minted key.
+var jwks = struct {
+	sync.Mutex
+	url      string
+	keys     []WellKnowJwtKey
+	loadedOn time.Time
+}{}
+
+// jwksKey returns the key of the key id from the cached keys of the url
+func jwksKey(ctx context.Context, keysUrl, kid string) (*WellKnowJwtKey, error) {
+	jwks.Lock()
+	defer jwks.Unlock()
+
+	find := func() *WellKnowJwtKey {
+		for i := range jwks.keys {
+			if jwks.keys[i].KeyId == kid {
+				return &jwks.keys[i]
+			}
+		}
+		return nil
+	}
+
+	if _, local := jwksFile(keysUrl); jwks.url == keysUrl && !local {
+		if key := find(); key != nil {
+			return key, nil
+		} else if time.Since(jwks.loadedOn) < jwksReloadWait {
+			return nil, fmt.Errorf("token key id %s not a user pool key", kid)
+		}
+	}
+
+	keys, err := GetWellKnownJwksKeys(ctx, keysUrl)
+	if err != nil {
+		return nil, err
+	}
+	jwks.url, jwks.keys, jwks.loadedOn = keysUrl, keys, time.Now()
+
+	if key := find(); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("token key id %s not a user pool key", kid)
 }
 
 // Don't forget go func public and private scope 
-func ValidateAuthToken(region, authToken string) (*CustomMapClaims, error) {
-	logger, _ := zap.NewDevelopment() // the reason this is defined
-	logger.Debug(fmt.Sprintf("validateAuthToken: %s %s********", region, authToken[:5]))
+func ValidateAuthToken(ctx context.Context, region, authToken string) (*CustomMapClaims, error) {
+	logger := logging.FromContext(ctx)
+	logger.Debug("validate auth token", zap.String("region", region)) // tokens are never logged
 
-	if wkjwkeys, err := GetWellKnownJwksKeys(region, UserPoolId); err != nil {
-		return &CustomMapClaims{}, err
-	} else {
-		rs256 := jwt.NewParser(jwt.WithValidMethods([]string{"RS256"}))
-	
-		token , err := rs256.ParseWithClaims(authToken, jwt.MapClaims{}, func(token *jwt.Token) (interface{}, error) {
-			// Don't forget to validate the alg is what you expect:
-			if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-				return nil, fmt.Errorf("parse with custom claim signing method: %v not RS256", token.Header["alg"])
-			}
+	// KEYS URL -- REPLACE WHEN CHANGING IDENTITY PROVIDER
+	issuer := UserPoolIssuer(region, Settings.UserPoolId)
+	keysUrl := issuer + "/.well-known/jwks.json"
+	if Settings.JwksUrl != "" {
+		logger.Warn("token signatures verified with a local jwks", zap.String("jwks", Settings.JwksUrl))
+		keysUrl = Settings.JwksUrl
+	}
 
-			header := token.Header
-			for _, v := range wkjwkeys {
-				kid := header["kid"].(string)
-				if v.KeyId == kid {
-					logger.Debug(fmt.Sprintf("parse with custom claim found with same header key id: %v", v.KeyId))
+	rs256 := jwt.NewParser(jwt.WithValidMethods([]string{"RS256"}), jwt.WithIssuer(issuer), jwt.WithExpirationRequired())
 
-					return token, nil
-				}
-			}
-			
-			logger.Debug("possible invalid parse")
-			return token, nil
-		})
+	token , err := rs256.ParseWithClaims(authToken, jwt.MapClaims{}, func(token *jwt.Token) (interface{}, error) {
+		// Don't forget to validate the alg is what you expect:
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("parse with custom claim signing method: %v not RS256", token.Header["alg"])
+		}
 
-		if err != nil {
-			logger.Debug(fmt.Sprint(err))
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, fmt.Errorf("token key id not available")
 		}
 
-		claim := CustomMapClaims{
-			MapClaims: token.Claims.(jwt.MapClaims),
+		// the signature is verified with the user pool key of the header key id
+		if key, err := jwksKey(ctx, keysUrl, kid); err != nil {
+			return nil, err
+		} else {
+			logger.Debug("parse with custom claim found with same header key id", zap.String("kid", key.KeyId))
+			return key.PublicKey()
 		}
-		return &claim, nil
-	
+	})
+
+	if err != nil {
+		logger.Debug("token parse failed", zap.Error(err))
+		return &CustomMapClaims{}, err
+	}
+
+	claim := CustomMapClaims{
+		MapClaims: token.Claims.(jwt.MapClaims),
 	}
+	if err := claim.ValidateClient(Settings.AppClientId); err != nil {
+		logger.Debug("token client not valid", zap.Error(err))
+		return &CustomMapClaims{}, err
+	}
+	return &claim, nil
+}
+
+// AuthorizerRequest is the TOKEN request of the rest api or the REQUEST of the WebSocket
+// $connect route. Browsers pass the WebSocket token with the token query string parameter.
+type AuthorizerRequest struct {
+	events.APIGatewayCustomAuthorizerRequest
+	QueryStringParameters map[string]string `json:"queryStringParameters"`
 }
 
 func main() {
-	logger, _ := zap.NewDevelopment()
-	logger.Info("FDS main authorizer")
+	logging.Logger().Info("FDS main authorizer")
+
+	config.MustLoad(&Settings)
+	lambdaHandler := lambda.NewHandler(func(ctx context.Context, request *AuthorizerRequest) (_ *events.APIGatewayCustomAuthorizerResponse, err error) {
+		ctx, span := tracing.StartInvocation(ctx, "authorize", attribute.String("aws.apigateway.method_arn", request.MethodArn))
+		defer func() { tracing.EndInvocation(ctx, span, err) }()
 
-	adminGroupName := os.Getenv("FDS_ADMIN_GROUP_NAME")
-	lambdaHandler := lambda.NewHandler(func(ctx context.Context, request *events.APIGatewayCustomAuthorizerRequest) (*events.APIGatewayCustomAuthorizerResponse, error) {
+		// every invocation is counted by decision and reason
+		decision, reason := Deny, "error"
+		defer func() {
+			metrics.Count(metrics.AuthorizerDecision, metrics.Dim("Decision", decision.String()), metrics.Dim("Reason", reason))
+		}()
+
+		logger := logging.FromContext(ctx).With(zap.String("methodArn", request.MethodArn))
+		ctx = logging.NewContext(ctx, logger)
 		logger.Info("FDS lambda.Start authorizer")
 
 		// Parse the input for the parameter values
 		// methodArn := []string{"arn", "aws", "execute-api", "{region}", "{accountid}" "{apiid}/{stage}/GET/request"}
 		methodArn := strings.Split(request.MethodArn, ":")
-		logger.Debug(fmt.Sprint(methodArn))
 		
 		if len(methodArn) < 6 {
+			reason = "method arn not available"
 			return nil, fmt.Errorf("request method arn not available")
 		}
 
-		if claim, err := ValidateAuthToken(/*region*/ methodArn[3], request.AuthorizationToken); err != nil {
+		// the Authorization header is the raw token or "Bearer <token>"
+		authToken := strings.TrimPrefix(request.AuthorizationToken, "Bearer ")
+		if authToken == "" {
+			authToken = request.QueryStringParameters["token"]
+		}
+		if claim, err := ValidateAuthToken(ctx, /*region*/ methodArn[3], authToken); err != nil {
+			reason = "token not valid"
 			return nil, err
 		} else {
 			apiGatewayArn := strings.Split(methodArn[5], "/")
@@ -351,7 +520,15 @@ func main() {
 			
 			principalId, _ := claim.GetSubject()
 			response.PrincipalID = principalId
-			logger.Debug(fmt.Sprintf("principal id: %s", principalId))
+			logger = logger.With(zap.String("principalId", principalId))
+
+			// available with request.RequestContext.Authorizer in the api handlers
+			response.Context = map[string]interface{}{
+				"sub":      principalId,
+				"username": claim.GetCognitoUserName(),
+				"email":    claim.GetEmail(),
+				"groups":   strings.Join(claim.GetCognitoGroups(), ","),
+			}
 
 			// *** Section 2 : authorization rules
 			// Allow all public resources/methods explicitly
@@ -361,20 +538,51 @@ func main() {
 			var singleResource = strings.Join([]string{"/users/", response.PrincipalID}, seperator)
 			var multiResource = strings.Join([]string{"/users/", response.PrincipalID, "/*"}, seperator)
 
+			response.AllowMethod(POST, "/users")
 			response.AllowMethod(GET, singleResource)
 			response.AllowMethod(PUT, singleResource)
 			response.AllowMethod(DELETE, singleResource)
 			response.AllowMethod(GET, multiResource)
 			response.AllowMethod(PUT, multiResource)
 			response.AllowMethod(DELETE, multiResource)
+
+			// orders are keyed by the principal id with the orders handlers
+			response.AllowMethod(PUT, "/orders")
+			response.AllowMethod(GET, "/orders/*")
+			response.AllowMethod(PUT, "/orders/*")
+			response.AllowMethod(PATCH, "/orders/*")
+			// POST is only the order cancellation
+			response.AllowMethod(POST, "/orders/*/cancel")
+
+			// WebSocket connections subscribe to the member's own orders
+			if len(apiGatewayArn) > 2 && apiGatewayArn[2] == "$connect" {
+				response.allowMethods = append(response.allowMethods, Method{ResourceArn: request.MethodArn})
+			}
 			
 			// Look for admin group in Cognito groups
 			// Assumption: admin group always has higher precedence
 
 			groupNames := claim.GetCognitoGroups()
 			for i := range groupNames {
-				 if groupNames[i] == adminGroupName {
+				if groupNames[i] == Settings.RiderGroupName {
+					logger.Debug("rider group manages its own rider state")
+					if reason != "admin" {
+						reason = "rider"
+					}
+
+					// riders are keyed by the principal id with the riders handlers
+					var riderResource = strings.Join([]string{"/riders/", response.PrincipalID}, seperator)
+					var riderMultiResource = strings.Join([]string{"/riders/", response.PrincipalID, "/*"}, seperator)
+
+					response.AllowMethod(GET, riderResource)
+					response.AllowMethod(PUT, riderResource)
+					response.AllowMethod(GET, riderMultiResource)
+					response.AllowMethod(POST, riderMultiResource)
+				}
+
+				 if groupNames[i] == Settings.AdminGroupName {
 					logger.Debug("admin group has higher precedence")
+					reason = "admin"
 
 					// add administrative privileges
 					response.AllowMethod(GET, "users")
@@ -382,20 +590,38 @@ func main() {
 				
 					response.AllowMethod(DELETE, "users")
 					response.AllowMethod(DELETE, "users/*")
+					response.AllowMethod(POST, "users")
+					response.AllowMethod(POST, "users/*")
 					response.AllowMethod(PUT, "users")
 					response.AllowMethod(PUT, "users/*")
+
+					response.AllowMethod(GET, "riders")
+					response.AllowMethod(GET, "riders/*")
+					response.AllowMethod(PUT, "riders/*")
+					response.AllowMethod(POST, "riders/*")
+
+					response.AllowMethod(GET, "zones")
+					response.AllowMethod(GET, "zones/*")
+					response.AllowMethod(PUT, "zones/*")
+					response.AllowMethod(DELETE, "zones/*")
 				 }
 			}
 
 			if len(response.allowMethods) == 0 {
+				reason = "no allowed methods"
 				return &events.APIGatewayCustomAuthorizerResponse{}, 
 					fmt.Errorf("resources %s or %s not allow with HttpVerbs. regexpr.match(%s)", 
 						singleResource, multiResource, pattern)
 			}
 
 			if err:= response.Build(principalId); err != nil {
+				reason = "policy not built"
 				return nil, err
 			} else {
+				decision = Allow
+				if reason != "admin" && reason != "rider" {
+					reason = "member"
+				}
 				return &response.APIGatewayCustomAuthorizerResponse, nil
 			}
 		}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestValidateClient(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		valid  bool
+	}{
+		{"id token", jwt.MapClaims{"token_use": "id", "aud": "app"}, true},
+		{"id token audience list", jwt.MapClaims{"token_use": "id", "aud": []interface{}{"app"}}, true},
+		{"id token of another client", jwt.MapClaims{"token_use": "id", "aud": "other"}, false},
+		{"id token without audience", jwt.MapClaims{"token_use": "id"}, false},
+		{"access token", jwt.MapClaims{"token_use": "access", "client_id": "app"}, true},
+		{"access token of another client", jwt.MapClaims{"token_use": "access", "client_id": "other"}, false},
+		{"access token audience", jwt.MapClaims{"token_use": "access", "aud": "app"}, false},
+		{"no token use", jwt.MapClaims{"aud": "app", "client_id": "app"}, false},
+		{"refresh token use", jwt.MapClaims{"token_use": "refresh", "client_id": "app"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := (CustomMapClaims{MapClaims: test.claims}).ValidateClient("app"); (err == nil) != test.valid {
+				t.Errorf("ValidateClient = %v, want valid %t", err, test.valid)
+			}
+		})
+	}
+}
+
+// jwksOf is the json web key set of the public keys by key id
+func jwksOf(keys map[string]*rsa.PrivateKey) []byte {
+	set := map[string][]WellKnowJwtKey{"keys": {}}
+	for kid, key := range keys {
+		set["keys"] = append(set["keys"], WellKnowJwtKey{
+			Algorithm:      "RS256",
+			KeyType:        "RSA",
+			PublicKeyUse:   "sig",
+			KeyId:          kid,
+			Modulus:        base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			PublicExponent: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	body, _ := json.Marshal(set)
+	return body
+}
+
+func TestJwksKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		// chunked, without a content length
+		w.(http.Flusher).Flush()
+		w.Write(jwksOf(map[string]*rsa.PrivateKey{"k-1": key}))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		kid       string
+		found     bool
+		downloads int
+	}{
+		{"first key", "k-1", true, 1},
+		{"cached key", "k-1", true, 1},
+		{"unknown key within the reload wait", "k-2", false, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			found, err := jwksKey(context.Background(), server.URL, test.kid)
+			if (err == nil) != test.found {
+				t.Errorf("jwksKey(%s) = %v, want found %t", test.kid, err, test.found)
+			} else if test.found && found.Modulus != base64.RawURLEncoding.EncodeToString(key.N.Bytes()) {
+				t.Errorf("jwksKey(%s) returned another key", test.kid)
+			} else if downloads != test.downloads {
+				t.Errorf("downloads = %d, want %d", downloads, test.downloads)
+			}
+		})
+	}
+}
+
+func TestValidateAuthToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the local key set of fdsctl token mint -jwks
+	Settings.UserPoolId, Settings.AppClientId = "us-east-1_pool", "app"
+	Settings.JwksUrl = filepath.Join(t.TempDir(), "jwks.json")
+	defer func() { Settings.JwksUrl = "" }()
+	if err := os.WriteFile(Settings.JwksUrl, jwksOf(map[string]*rsa.PrivateKey{"local": key}), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	issuer := UserPoolIssuer("us-east-1", "us-east-1_pool")
+	expires := time.Now().Add(time.Hour).Unix()
+	valid := func(changes jwt.MapClaims) jwt.MapClaims {
+		claims := jwt.MapClaims{"sub": "u-1", "token_use": "id", "iss": issuer, "aud": "app", "exp": expires}
+		for name, value := range changes {
+			if value == nil {
+				delete(claims, name)
+			} else {
+				claims[name] = value
+			}
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		kid    string
+		key    *rsa.PrivateKey
+		valid  bool
+	}{
+		{"minted token", valid(nil), "local", key, true},
+		{"another signing key", valid(nil), "local", other, false},
+		{"unknown key id", valid(nil), "other", key, false},
+		{"another user pool", valid(jwt.MapClaims{"iss": UserPoolIssuer("us-east-1", "us-east-1_other")}), "local", key, false},
+		{"another app client", valid(jwt.MapClaims{"aud": "other"}), "local", key, false},
+		{"expired", valid(jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()}), "local", key, false},
+		{"no expiry", valid(jwt.MapClaims{"exp": nil}), "local", key, false},
+		{"no issuer", valid(jwt.MapClaims{"iss": nil}), "local", key, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token := jwt.NewWithClaims(jwt.SigningMethodRS256, test.claims)
+			token.Header["kid"] = test.kid
+			signed, err := token.SignedString(test.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if claims, err := ValidateAuthToken(context.Background(), "us-east-1", signed); (err == nil) != test.valid {
+				t.Errorf("ValidateAuthToken = %v, want valid %t", err, test.valid)
+			} else if sub, _ := claims.GetSubject(); test.valid && sub != "u-1" {
+				t.Errorf("sub = %s, want u-1", sub)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/kscott5/fds/internal/client"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+type decision struct {
+	PrincipalId string                 `json:"principalid"`
+	MethodArn   string                 `json:"methodarn"`
+	Effect      string                 `json:"effect"`
+	Resource    string                 `json:"resource,omitempty"`
+	Context     map[string]interface{} `json:"context,omitempty"`
+}
+
+// evalAuthorizer invokes the authorizer with a token authorizer request and evaluates the
+// returned policy for the method arn. -invoke-url is a local lambda runtime interface emulator,
+// e.g. http://localhost:2026/2015-03-31/functions/function/invocations. The local authorizer
+// verifies tokens of fdsctl token mint -jwks when FDS_JWKS_URL is the key set file.
+func evalAuthorizer(ctx context.Context, args []string) (interface{}, error) {
+	flags := newFlags("auth eval")
+	token := flags.String("token", getEnv("FDS_TOKEN", ""), "authorization token. defaults to FDS_TOKEN")
+	methodArn := flags.String("method-arn", "", "arn:aws:execute-api:{region}:{accountid}:{apiid}/{stage}/{method}/{resource}")
+	function := flags.String("function", getEnv("FDS_AUTHORIZER_FUNCTION", ""), "authorizer function name. defaults to FDS_AUTHORIZER_FUNCTION")
+	invokeUrl := flags.String("invoke-url", "", "local lambda invocations url used instead of -function")
+	flags.Parse(args)
+
+	requires := map[string]string{"token": "string", "method-arn": "string", "function or invoke-url": "string"}
+	if *token == "" || *methodArn == "" || (*function == "" && *invokeUrl == "") {
+		return nil, fmt.Errorf("requires: %s", requires)
+	}
+
+	payload, err := json.Marshal(events.APIGatewayCustomAuthorizerRequest{
+		Type:               "TOKEN",
+		AuthorizationToken: *token,
+		MethodArn:          *methodArn,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []byte
+	if *invokeUrl != "" {
+		result, err = invokeLocal(ctx, *invokeUrl, payload)
+	} else {
+		result, err = invokeFunction(ctx, *function, payload)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response := events.APIGatewayCustomAuthorizerResponse{}
+	if err := json.Unmarshal(result, &response); err != nil {
+		return nil, fmt.Errorf("authorizer response not valid: %s", result)
+	}
+	return evaluate(response, *methodArn), nil
+}
+
+// evaluate applies the policy the way api gateway does. an explicit deny wins over an allow.
+func evaluate(response events.APIGatewayCustomAuthorizerResponse, methodArn string) decision {
+	out := decision{PrincipalId: response.PrincipalID, MethodArn: methodArn, Effect: "Deny", Context: response.Context}
+	for _, statement := range response.PolicyDocument.Statement {
+		for _, resource := range statement.Resource {
+			if !arnMatch(resource, methodArn) {
+				continue
+			}
+			if strings.EqualFold(statement.Effect, "Deny") {
+				out.Effect, out.Resource = "Deny", resource
+				return out
+			}
+			out.Effect, out.Resource = "Allow", resource
+		}
+	}
+	return out
+}
+
+// arnMatch matches an execute-api arn with * and ? wildcards
+func arnMatch(pattern, arn string) bool {
+	expression := regexp.QuoteMeta(pattern)
+	expression = strings.ReplaceAll(expression, `\*`, ".*")
+	expression = strings.ReplaceAll(expression, `\?`, ".")
+	matched, _ := regexp.MatchString("^"+expression+"$", arn)
+	return matched
+}
+
+func invokeLocal(ctx context.Context, url string, payload []byte) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	return io.ReadAll(response.Body)
+}
+
+func invokeFunction(ctx context.Context, function string, payload []byte) ([]byte, error) {
+	output, err := client.NewLambda().Invoke(ctx, &lambda.InvokeInput{
+		FunctionName: aws.String(function),
+		Payload:      payload,
+	})
+	if err != nil {
+		return nil, err
+	} else if output.FunctionError != nil {
+		return nil, fmt.Errorf("authorizer %s: %s", aws.ToString(output.FunctionError), output.Payload)
+	}
+	return output.Payload, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// the dynamodb json of the aws cli, e.g. {"userid": {"S": "12dc"}}. Used with tables seed and dump.
+
+func decodeItem(raw map[string]json.RawMessage) (map[string]types.AttributeValue, error) {
+	item := map[string]types.AttributeValue{}
+	for name, value := range raw {
+		if attr, err := decodeAttribute(value); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		} else {
+			item[name] = attr
+		}
+	}
+	return item, nil
+}
+
+func decodeAttribute(raw json.RawMessage) (types.AttributeValue, error) {
+	typed := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &typed); err != nil {
+		return nil, err
+	} else if len(typed) != 1 {
+		return nil, fmt.Errorf("requires exactly one attribute type")
+	}
+
+	for kind, value := range typed {
+		switch kind {
+		case "S":
+			out := &types.AttributeValueMemberS{}
+			return out, json.Unmarshal(value, &out.Value)
+		case "N":
+			out := &types.AttributeValueMemberN{}
+			return out, json.Unmarshal(value, &out.Value)
+		case "B":
+			out := &types.AttributeValueMemberB{}
+			return out, json.Unmarshal(value, &out.Value)
+		case "BOOL":
+			out := &types.AttributeValueMemberBOOL{}
+			return out, json.Unmarshal(value, &out.Value)
+		case "NULL":
+			out := &types.AttributeValueMemberNULL{}
+			return out, json.Unmarshal(value, &out.Value)
+		case "SS":
+			out := &types.AttributeValueMemberSS{}
+			return out, json.Unmarshal(value, &out.Value)
+		case "NS":
+			out := &types.AttributeValueMemberNS{}
+			return out, json.Unmarshal(value, &out.Value)
+		case "BS":
+			out := &types.AttributeValueMemberBS{}
+			return out, json.Unmarshal(value, &out.Value)
+		case "M":
+			members := map[string]json.RawMessage{}
+			if err := json.Unmarshal(value, &members); err != nil {
+				return nil, err
+			} else if m, err := decodeItem(members); err != nil {
+				return nil, err
+			} else {
+				return &types.AttributeValueMemberM{Value: m}, nil
+			}
+		case "L":
+			members := []json.RawMessage{}
+			if err := json.Unmarshal(value, &members); err != nil {
+				return nil, err
+			}
+			list := []types.AttributeValue{}
+			for _, member := range members {
+				if attr, err := decodeAttribute(member); err != nil {
+					return nil, err
+				} else {
+					list = append(list, attr)
+				}
+			}
+			return &types.AttributeValueMemberL{Value: list}, nil
+		default:
+			return nil, fmt.Errorf("attribute type %s not available", kind)
+		}
+	}
+	return nil, nil
+}
+
+func encodeItem(item map[string]types.AttributeValue) map[string]interface{} {
+	out := map[string]interface{}{}
+	for name, attr := range item {
+		out[name] = encodeAttribute(attr)
+	}
+	return out
+}
+
+func encodeAttribute(attr types.AttributeValue) map[string]interface{} {
+	switch v := attr.(type) {
+	case *types.AttributeValueMemberS:
+		return map[string]interface{}{"S": v.Value}
+	case *types.AttributeValueMemberN:
+		return map[string]interface{}{"N": v.Value}
+	case *types.AttributeValueMemberB:
+		return map[string]interface{}{"B": base64.StdEncoding.EncodeToString(v.Value)}
+	case *types.AttributeValueMemberBOOL:
+		return map[string]interface{}{"BOOL": v.Value}
+	case *types.AttributeValueMemberNULL:
+		return map[string]interface{}{"NULL": v.Value}
+	case *types.AttributeValueMemberSS:
+		return map[string]interface{}{"SS": v.Value}
+	case *types.AttributeValueMemberNS:
+		return map[string]interface{}{"NS": v.Value}
+	case *types.AttributeValueMemberBS:
+		return map[string]interface{}{"BS": v.Value}
+	case *types.AttributeValueMemberM:
+		return map[string]interface{}{"M": encodeItem(v.Value)}
+	case *types.AttributeValueMemberL:
+		list := []interface{}{}
+		for _, member := range v.Value {
+			list = append(list, encodeAttribute(member))
+		}
+		return map[string]interface{}{"L": list}
+	default:
+		return map[string]interface{}{}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/orders/changes"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// replayEvents converts recorded orders table stream records, e.g. data/streams/orders.json,
+// the way the streams lambda does. Events are published with -publish.
+func replayEvents(ctx context.Context, args []string) (interface{}, error) {
+	flags := newFlags("events replay")
+	file := flags.String("file", "", "dynamodb stream event json, {\"Records\": [...]}")
+	publish := flags.Bool("publish", false, "publish to FDS_EVENT_BUS_NAME and FDS_ORDER_EVENTS_TOPIC_ARN")
+	flags.Parse(args)
+
+	requires := map[string]string{"file": "string"}
+	if *file == "" {
+		return nil, fmt.Errorf("requires: %s", requires)
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := events.DynamoDBEvent{}
+	if err := json.Unmarshal(data, &batch); err != nil {
+		return nil, fmt.Errorf("%s: %w", *file, err)
+	}
+
+	recorder := &changes.Recorder{}
+	publishers := changes.Publishers{recorder}
+	if *publish {
+		publishers = append(publishers, changes.New(client.LoadConfig(), getEnv("FDS_EVENT_BUS_NAME", ""), getEnv("FDS_ORDER_EVENTS_TOPIC_ARN", "")))
+	}
+
+	response := changes.Process(ctx, publishers, batch)
+	if len(response.BatchItemFailures) > 0 {
+		return recorder.Events, fmt.Errorf("record %s not published", response.BatchItemFailures[0].ItemIdentifier)
+	}
+	return recorder.Events, nil
+}
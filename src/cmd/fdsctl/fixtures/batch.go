@@ -0,0 +1,58 @@
+package fixtures
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// BatchWriteLimit is the dynamodb maximum of write requests per batch
+	BatchWriteLimit = 25
+
+	// MaxBatchRetries of unprocessed items before BatchWrite gives up
+	MaxBatchRetries = 8
+)
+
+type UnprocessedError struct {
+	TableName string
+	Count     int
+}
+
+func (e *UnprocessedError) Error() string {
+	return fmt.Sprintf("%d items not processed with table %s", e.Count, e.TableName)
+}
+
+// BatchWrite writes the requests in batches of 25 and retries unprocessed items with backoff
+func BatchWrite(ctx context.Context, ddb *dynamodb.Client, tableName string, writes []types.WriteRequest) error {
+	for start := 0; start < len(writes); start += BatchWriteLimit {
+		end := min(start+BatchWriteLimit, len(writes))
+
+		wait := 100 * time.Millisecond
+		pending := map[string][]types.WriteRequest{tableName: writes[start:end]}
+		for retry := 0; len(pending[tableName]) > 0; retry++ {
+			if retry > MaxBatchRetries {
+				return &UnprocessedError{TableName: tableName, Count: len(pending[tableName])}
+			}
+
+			output, err := ddb.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{RequestItems: pending})
+			if err != nil {
+				return err
+			}
+
+			pending = output.UnprocessedItems
+			if len(pending[tableName]) > 0 {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(wait):
+				}
+				wait = wait * 2
+			}
+		}
+	}
+	return nil
+}
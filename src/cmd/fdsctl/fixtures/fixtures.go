@@ -0,0 +1,218 @@
+// Package fixtures loads YAML or JSON fixtures for users, addresses, restaurants and orders,
+// validates them with the api schemas and writes them to dynamodb.
+//
+//	users:
+//	  - userid: 3f0c...
+//	    username: username0
+//	    fullname: Somewhat Famous
+//	orders:
+//	  - userid: 3f0c...
+//	    restaurantid: r-0001
+//	    totalamount: 12.5
+//	    items: [{itemid: i-1, quanity: 1, amount: 12.5}]
+package fixtures
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/schema"
+	"github.com/kscott5/fds/orders/restaurants"
+	"github.com/kscott5/fds/orders/services"
+	"github.com/kscott5/fds/users/profiles"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// Address is the address table item. Each user has one address.
+type Address struct {
+	UserId string `json:"userid" dynamodbav:"userid"`
+	services.Address
+}
+
+type Fixtures struct {
+	Users       []profiles.User          `json:"users,omitempty"`
+	Addresses   []Address                `json:"addresses,omitempty"`
+	Restaurants []restaurants.Restaurant `json:"restaurants,omitempty"`
+	Orders      []services.Order         `json:"orders,omitempty"`
+}
+
+type Tables struct {
+	Users       string
+	Address     string
+	Restaurants string
+	Orders      string
+}
+
+func TablesFromEnv() Tables {
+	return Tables{
+		Users:       getEnv("FDS_APPS_USERS_TABLE", profiles.DefaultUsersTable),
+		Address:     getEnv("FDS_APPS_ADDRESS_TABLE", "FDSAppsAddress"),
+		Restaurants: getEnv("FDS_APPS_RESTAURANTS_TABLE", restaurants.DefaultRestaurantsTable),
+		Orders:      getEnv("FDS_APPS_ORDERS_TABLE", services.DefaultOrderTable),
+	}
+}
+
+var addressSchema = schema.Object(map[string]*schema.Field{
+	"userid":     schema.String().Required().MaxLength(64),
+	"street":     schema.String().Required().MaxLength(128),
+	"city":       schema.String().Required().MaxLength(64),
+	"state":      schema.String().MaxLength(64),
+	"postalcode": schema.String().MaxLength(16).Pattern(`^[0-9A-Za-z -]+$`),
+	"latitude":   schema.Number().Min(-90).Max(90),
+	"longitude":  schema.Number().Min(-180).Max(180),
+})
+
+// sections are validated with the api schemas. orders also require the owner user id.
+var sections = map[string]*schema.Field{
+	"users":       profiles.UserSchema,
+	"addresses":   addressSchema,
+	"restaurants": restaurants.RestaurantSchema,
+	"orders":      services.OrderSchema,
+}
+
+// Load reads a .yaml, .yml or .json fixtures file. Every violation is returned with its path.
+func Load(path string) (*Fixtures, []client.Violation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var document interface{}
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return nil, nil, err
+		} else if data, err = json.Marshal(document); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return Decode(data)
+}
+
+// Decode validates and decodes a json fixtures document
+func Decode(data []byte) (*Fixtures, []client.Violation, error) {
+	raw := map[string][]json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, err
+	}
+
+	violations := []client.Violation{}
+	for section, records := range raw {
+		recordSchema, found := sections[section]
+		if !found {
+			violations = append(violations, client.Violation{Path: "$." + section, Message: "unknown field"})
+			continue
+		}
+
+		for i, record := range records {
+			path := fmt.Sprintf("$.%s[%d]", section, i)
+			for _, v := range recordSchema.Validate(record) {
+				violations = append(violations, client.Violation{Path: path + strings.TrimPrefix(v.Path, "$"), Message: v.Message})
+			}
+			if section == "orders" {
+				order := struct {
+					UserId string `json:"userid"`
+				}{}
+				if json.Unmarshal(record, &order); order.UserId == "" {
+					violations = append(violations, client.Violation{Path: path + ".userid", Message: "is required"})
+				}
+			}
+		}
+	}
+	if len(violations) > 0 {
+		sort.Slice(violations, func(i, j int) bool { return violations[i].Path < violations[j].Path })
+		return nil, violations, nil
+	}
+
+	// the schemas reject unknown fields, so decoding is strict as well
+	fixtures := Fixtures{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&fixtures); err != nil {
+		return nil, nil, err
+	}
+
+	fixtures.setDefaults(time.Now())
+	return &fixtures, nil, nil
+}
+
+// setDefaults assigns uuid ids, placed status and timestamps that fixtures leave out
+func (f *Fixtures) setDefaults(now time.Time) {
+	for i := range f.Users {
+		if f.Users[i].UserId == "" {
+			f.Users[i].UserId = uuid.New().String()
+		}
+	}
+	for i := range f.Orders {
+		order := &f.Orders[i]
+		if order.OrderId == "" {
+			order.OrderId = uuid.New().String()
+		}
+		if order.Status == services.Invalid {
+			order.Status = services.Placed
+		}
+		if order.PlacedOn == 0 {
+			order.PlacedOn = services.UnixMilliTime(now.UnixMilli())
+		}
+		if order.ModifiedOn == 0 {
+			order.ModifiedOn = order.PlacedOn
+		}
+	}
+}
+
+// WriteRequests returns the put requests by table name. Users include their username claim items.
+func (f *Fixtures) WriteRequests(tables Tables) (map[string][]types.WriteRequest, error) {
+	requests := map[string][]types.WriteRequest{}
+	put := func(tableName string, v interface{}) error {
+		item, err := attributevalue.MarshalMap(v)
+		if err != nil {
+			return err
+		}
+		requests[tableName] = append(requests[tableName], types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+		return nil
+	}
+
+	for _, user := range f.Users {
+		claim := profiles.UserNameClaim{ClaimId: profiles.ClaimIdFrom(user.UserName), ClaimedBy: user.UserId}
+		if err := put(tables.Users, user); err != nil {
+			return nil, err
+		} else if err := put(tables.Users, claim); err != nil {
+			return nil, err
+		}
+	}
+	for _, address := range f.Addresses {
+		if err := put(tables.Address, address); err != nil {
+			return nil, err
+		}
+	}
+	for _, restaurant := range f.Restaurants {
+		if err := put(tables.Restaurants, restaurant); err != nil {
+			return nil, err
+		}
+	}
+	for _, order := range f.Orders {
+		if err := put(tables.Orders, services.OrderItem{OrderId: order.OrderId, UserId: order.UserId, Data: order}); err != nil {
+			return nil, err
+		}
+	}
+	return requests, nil
+}
+
+func getEnv(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,115 @@
+package fixtures
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/kscott5/fds/orders/restaurants"
+	"github.com/kscott5/fds/orders/services"
+	"github.com/kscott5/fds/users/profiles"
+
+	"github.com/google/uuid"
+)
+
+// synthetic customers and restaurants are placed within about 5km of the city center
+const (
+	centerLatitude  = 47.6062
+	centerLongitude = -122.3321
+	radiusDegrees   = 0.045
+)
+
+var (
+	firstNames = []string{"Ada", "Grace", "Alan", "Edsger", "Barbara", "Ken", "Radia", "Donald", "Frances", "Linus"}
+	lastNames  = []string{"Lovelace", "Hopper", "Turing", "Dijkstra", "Liskov", "Thompson", "Perlman", "Knuth", "Allen", "Torvalds"}
+	streets    = []string{"Pine St", "Pike St", "Union St", "Madison St", "Broadway", "1st Ave", "3rd Ave", "Denny Way"}
+	cuisines   = []string{"thai", "pizza", "ramen", "tacos", "burgers", "salads", "pho", "curry"}
+)
+
+type GenerateOptions struct {
+	Customers         int
+	OrdersPerCustomer int
+	Restaurants       int
+	MaxItemsPerOrder  int
+	Seed              int64
+}
+
+// Generate returns synthetic customers with addresses, restaurants and orders for load tests.
+// The same seed generates the same names, places and amounts, with new ids.
+func Generate(options GenerateOptions) *Fixtures {
+	random := rand.New(rand.NewSource(options.Seed))
+	if options.Restaurants < 1 {
+		options.Restaurants = 1
+	}
+	if options.MaxItemsPerOrder < 1 {
+		options.MaxItemsPerOrder = 3
+	}
+
+	fixtures := &Fixtures{}
+	for i := 0; i < options.Restaurants; i++ {
+		fixtures.Restaurants = append(fixtures.Restaurants, restaurants.Restaurant{
+			RestaurantId: fmt.Sprintf("r-%04d", i),
+			Name:         fmt.Sprintf("%s %s kitchen", pick(random, lastNames), pick(random, cuisines)),
+			Cuisine:      pick(random, cuisines),
+			Address:      address(random),
+		})
+	}
+
+	now := time.Now()
+	for i := 0; i < options.Customers; i++ {
+		user := profiles.User{
+			UserId:   uuid.New().String(),
+			UserName: fmt.Sprintf("customer%06d", i),
+			FullName: fmt.Sprintf("%s %s", pick(random, firstNames), pick(random, lastNames)),
+		}
+		home := address(random)
+		fixtures.Users = append(fixtures.Users, user)
+		fixtures.Addresses = append(fixtures.Addresses, Address{UserId: user.UserId, Address: home})
+
+		for j := 0; j < options.OrdersPerCustomer; j++ {
+			order := services.Order{
+				OrderId:         uuid.New().String(),
+				UserId:          user.UserId,
+				RestaurantId:    fixtures.Restaurants[random.Intn(len(fixtures.Restaurants))].RestaurantId,
+				DeliveryAddress: home,
+				Status:          services.Placed,
+				PlacedOn:        services.UnixMilliTime(now.Add(-time.Duration(random.Intn(30*24)) * time.Hour).UnixMilli()),
+			}
+			order.ModifiedOn = order.PlacedOn
+
+			for k := 0; k <= random.Intn(options.MaxItemsPerOrder); k++ {
+				item := services.Items{
+					ItemId:      fmt.Sprintf("i-%03d", random.Intn(100)),
+					Description: pick(random, cuisines),
+					Quanity:     1 + random.Intn(3),
+					Amount:      cents(4 + random.Float64()*16),
+				}
+				order.Items = append(order.Items, item)
+				order.TotalAmount = cents(order.TotalAmount + item.Amount*float64(item.Quanity))
+			}
+			order.Tip = cents(order.TotalAmount * 0.15)
+			fixtures.Orders = append(fixtures.Orders, order)
+		}
+	}
+	return fixtures
+}
+
+func pick(random *rand.Rand, values []string) string {
+	return values[random.Intn(len(values))]
+}
+
+func address(random *rand.Rand) services.Address {
+	return services.Address{
+		Street:     fmt.Sprintf("%d %s", 100+random.Intn(2900), pick(random, streets)),
+		City:       "Seattle",
+		State:      "WA",
+		PostalCode: fmt.Sprintf("981%02d", random.Intn(100)),
+		Latitude:   centerLatitude + (random.Float64()*2-1)*radiusDegrees,
+		Longitude:  centerLongitude + (random.Float64()*2-1)*radiusDegrees,
+	}
+}
+
+func cents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,127 @@
+// fdsctl is the FDS operator command line.
+//
+//	fdsctl [-o json|table] users list|get|create|delete
+//	fdsctl [-o json|table] orders list|get|transition
+//	fdsctl [-o json|table] fixtures load|generate
+//	fdsctl [-o json|table] tables seed|dump
+//	fdsctl [-o json|table] migrate up|status
+//	fdsctl token mint [-jwks file]
+//	fdsctl [-o json|table] auth eval
+//	fdsctl [-o json|table] events replay
+//	fdsctl [-o json|table] outbox pending|drain
+//	fdsctl [-o json|table] notifications send
+//
+// users commands call the rest api with FDS_API_URL and FDS_TOKEN. orders, fixtures,
+// tables and migrate commands use dynamodb directly with the AWS_* environment variables.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+type command func(ctx context.Context, args []string) (interface{}, error)
+
+var commands = map[string]map[string]command{
+	"users": {
+		"list":   listUsers,
+		"get":    getUser,
+		"create": createUser,
+		"delete": deleteUser,
+	},
+	"orders": {
+		"list":       listOrders,
+		"get":        getOrder,
+		"transition": transitionOrder,
+	},
+	"fixtures": {
+		"load":     loadFixtures,
+		"generate": generateFixtures,
+	},
+	"migrate": {
+		"up":     migrateUp,
+		"status": migrateStatus,
+	},
+	"tables": {
+		"seed": seedTables,
+		"dump": dumpTable,
+	},
+	"token": {
+		"mint": mintToken,
+	},
+	"auth": {
+		"eval": evalAuthorizer,
+	},
+	"events": {
+		"replay": replayEvents,
+	},
+	"outbox": {
+		"pending": pendingOutbox,
+		"drain":   drainOutbox,
+	},
+	"notifications": {
+		"send": sendNotifications,
+	},
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "usage: fdsctl [-o json|table] <group> <command> [flags]\n\n")
+	groups := []string{}
+	for group := range commands {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	for _, group := range groups {
+		names := []string{}
+		for name := range commands[group] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(flag.CommandLine.Output(), "  %-8s %s\n", group, strings.Join(names, "|"))
+	}
+	fmt.Fprintln(flag.CommandLine.Output())
+	flag.PrintDefaults()
+}
+
+func main() {
+	output := flag.String("o", "json", "output format: json or table")
+	flag.Usage = usage
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	run, found := commands[args[0]][args[1]]
+	if !found {
+		fmt.Fprintf(os.Stderr, "fdsctl: unknown command %s %s\n", args[0], args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	if out, err := run(context.Background(), args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "fdsctl: %s\n", err)
+		os.Exit(1)
+	} else if err := write(os.Stdout, *output, out); err != nil {
+		fmt.Fprintf(os.Stderr, "fdsctl: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// newFlags returns the flag set of a command. Commands exit with usage on parse errors.
+func newFlags(name string) *flag.FlagSet {
+	return flag.NewFlagSet("fdsctl "+name, flag.ExitOnError)
+}
+
+func getEnv(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+
+	"github.com/kscott5/fds/cmd/fdsctl/migrations"
+	"github.com/kscott5/fds/internal/client"
+)
+
+// migrateUp creates missing tables and applies pending backfills. Run it before deploying
+// handlers that depend on a new migration.
+func migrateUp(ctx context.Context, args []string) (interface{}, error) {
+	flags := newFlags("migrate up")
+	prefix := flags.String("prefix", getEnv("FDS_APP_PREFIX", migrations.DefaultPrefix), "table name prefix. defaults to FDS_APP_PREFIX")
+	flags.Parse(args)
+
+	runner := migrations.NewRunner(client.NewDynamodb(), *prefix)
+	return runner.Up(ctx, migrations.All)
+}
+
+func migrateStatus(ctx context.Context, args []string) (interface{}, error) {
+	flags := newFlags("migrate status")
+	prefix := flags.String("prefix", getEnv("FDS_APP_PREFIX", migrations.DefaultPrefix), "table name prefix. defaults to FDS_APP_PREFIX")
+	flags.Parse(args)
+
+	runner := migrations.NewRunner(client.NewDynamodb(), *prefix)
+	return runner.Status(ctx, migrations.All)
+}
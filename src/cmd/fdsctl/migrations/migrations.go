@@ -0,0 +1,330 @@
+// Package migrations creates the dynamodb tables and applies backfills in version order.
+// The tables match the terraform modules so DynamoDB Local has the same keys as aws.
+// Applied versions are recorded in the migrations table and never applied twice.
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// DefaultPrefix is the terraform app_prefix of every table name
+	DefaultPrefix = "FDSApps"
+
+	MigrationsTable = "Migrations"
+
+	tableWaitTime = 2 * time.Minute
+)
+
+type Key struct {
+	Name string
+	Type types.ScalarAttributeType
+}
+
+type Index struct {
+	Name     string
+	HashKey  Key
+	RangeKey *Key
+}
+
+// Table is declared with the name without the prefix, e.g. Users for FDSAppsUsers
+type Table struct {
+	Name         string
+	HashKey      Key
+	RangeKey     *Key
+	Indexes      []Index
+	TTLAttribute string
+	// StreamViewType enables the table stream, e.g. NEW_AND_OLD_IMAGES
+	StreamViewType types.StreamViewType
+}
+
+// Migration creates or updates tables and then runs the backfill, if any
+type Migration struct {
+	Version     int
+	Description string
+	Tables      []Table
+	Backfill    func(ctx context.Context, r *Runner) error
+}
+
+// Applied is the migrations table item. Notes are the findings of the backfill.
+type Applied struct {
+	Version     int      `json:"version" dynamodbav:"version"`
+	Description string   `json:"description" dynamodbav:"description"`
+	AppliedOn   int64    `json:"appliedon,omitempty" dynamodbav:"appliedon"`
+	Notes       []string `json:"notes,omitempty" dynamodbav:"notes,omitempty"`
+}
+
+type Runner struct {
+	DDB    *dynamodb.Client
+	Prefix string
+
+	// notes of the running backfill
+	notes []string
+}
+
+func NewRunner(ddb *dynamodb.Client, prefix string) *Runner {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return &Runner{DDB: ddb, Prefix: prefix}
+}
+
+func (r *Runner) TableName(name string) string {
+	return r.Prefix + name
+}
+
+// Note records a finding of the backfill, e.g. data it could not migrate, with the applied version
+func (r *Runner) Note(format string, args ...interface{}) {
+	r.notes = append(r.notes, fmt.Sprintf(format, args...))
+}
+
+// Up applies every migration after the last applied version and returns the applied migrations
+func (r *Runner) Up(ctx context.Context, migrations []Migration) ([]Applied, error) {
+	metadata := Table{Name: MigrationsTable, HashKey: Key{Name: "version", Type: types.ScalarAttributeTypeN}}
+	if err := r.EnsureTable(ctx, metadata); err != nil {
+		return nil, err
+	}
+
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	out := []Applied{}
+	for _, migration := range migrations {
+		if _, found := applied[migration.Version]; found {
+			continue
+		}
+
+		for _, table := range migration.Tables {
+			if err := r.EnsureTable(ctx, table); err != nil {
+				return out, fmt.Errorf("migration %d: %w", migration.Version, err)
+			}
+		}
+		r.notes = nil
+		if migration.Backfill != nil {
+			if err := migration.Backfill(ctx, r); err != nil {
+				return out, fmt.Errorf("migration %d: %w", migration.Version, err)
+			}
+		}
+
+		record := Applied{Version: migration.Version, Description: migration.Description, AppliedOn: time.Now().UnixMilli(), Notes: r.notes}
+		if item, err := attributevalue.MarshalMap(record); err != nil {
+			return out, err
+		} else if _, err := r.DDB.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(r.TableName(MigrationsTable)), Item: item}); err != nil {
+			return out, err
+		}
+		out = append(out, record)
+	}
+	return out, nil
+}
+
+// Status returns every migration with the time it was applied, or zero when pending
+func (r *Runner) Status(ctx context.Context, migrations []Migration) ([]Applied, error) {
+	applied, err := r.applied(ctx)
+	var notFound *types.ResourceNotFoundException
+	if err != nil && !errors.As(err, &notFound) {
+		return nil, err
+	}
+
+	out := []Applied{}
+	for _, migration := range migrations {
+		out = append(out, Applied{Version: migration.Version, Description: migration.Description, AppliedOn: applied[migration.Version].AppliedOn, Notes: applied[migration.Version].Notes})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+func (r *Runner) applied(ctx context.Context) (map[int]Applied, error) {
+	applied := map[int]Applied{}
+	paginator := dynamodb.NewScanPaginator(r.DDB, &dynamodb.ScanInput{TableName: aws.String(r.TableName(MigrationsTable))})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return applied, err
+		}
+
+		records := []Applied{}
+		if err := attributevalue.UnmarshalListOfMaps(output.Items, &records); err != nil {
+			return applied, err
+		}
+		for _, record := range records {
+			applied[record.Version] = record
+		}
+	}
+	return applied, nil
+}
+
+// EnsureTable creates the table, missing global secondary indexes, the stream and time to live.
+// Existing tables with different keys are reported, never replaced.
+func (r *Runner) EnsureTable(ctx context.Context, table Table) error {
+	tableName := r.TableName(table.Name)
+
+	var notFound *types.ResourceNotFoundException
+	output, err := r.DDB.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)})
+	if errors.As(err, &notFound) {
+		if err := r.createTable(ctx, tableName, table); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else if err := checkKeys(tableName, output.Table.KeySchema, table); err != nil {
+		return err
+	} else if err := r.createIndexes(ctx, tableName, table, output.Table.GlobalSecondaryIndexes); err != nil {
+		return err
+	} else if err := r.enableStream(ctx, tableName, table, output.Table.StreamSpecification); err != nil {
+		return err
+	}
+
+	if table.TTLAttribute == "" {
+		return nil
+	}
+
+	ttl, err := r.DDB.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{TableName: aws.String(tableName)})
+	if err != nil {
+		return err
+	} else if description := ttl.TimeToLiveDescription; description != nil && description.TimeToLiveStatus == types.TimeToLiveStatusEnabled {
+		return nil
+	}
+
+	_, err = r.DDB.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(tableName),
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: aws.String(table.TTLAttribute),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	return err
+}
+
+func (r *Runner) createTable(ctx context.Context, tableName string, table Table) error {
+	attributes := map[string]types.ScalarAttributeType{}
+	params := dynamodb.CreateTableInput{
+		TableName:   aws.String(tableName),
+		BillingMode: types.BillingModePayPerRequest,
+		KeySchema:   keySchema(table.HashKey, table.RangeKey, attributes),
+	}
+	for _, index := range table.Indexes {
+		params.GlobalSecondaryIndexes = append(params.GlobalSecondaryIndexes, types.GlobalSecondaryIndex{
+			IndexName:  aws.String(index.Name),
+			KeySchema:  keySchema(index.HashKey, index.RangeKey, attributes),
+			Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+		})
+	}
+	params.AttributeDefinitions = definitions(attributes)
+	if table.StreamViewType != "" {
+		params.StreamSpecification = &types.StreamSpecification{StreamEnabled: aws.Bool(true), StreamViewType: table.StreamViewType}
+	}
+
+	if _, err := r.DDB.CreateTable(ctx, &params); err != nil {
+		return err
+	}
+	return dynamodb.NewTableExistsWaiter(r.DDB).Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)}, tableWaitTime)
+}
+
+func (r *Runner) createIndexes(ctx context.Context, tableName string, table Table, existing []types.GlobalSecondaryIndexDescription) error {
+	found := map[string]bool{}
+	for _, index := range existing {
+		found[aws.ToString(index.IndexName)] = true
+	}
+
+	for _, index := range table.Indexes {
+		if found[index.Name] {
+			continue
+		}
+
+		attributes := map[string]types.ScalarAttributeType{}
+		params := dynamodb.UpdateTableInput{
+			TableName: aws.String(tableName),
+			GlobalSecondaryIndexUpdates: []types.GlobalSecondaryIndexUpdate{{
+				Create: &types.CreateGlobalSecondaryIndexAction{
+					IndexName:  aws.String(index.Name),
+					KeySchema:  keySchema(index.HashKey, index.RangeKey, attributes),
+					Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+				},
+			}},
+		}
+		params.AttributeDefinitions = definitions(attributes)
+
+		// one index is created at a time
+		if _, err := r.DDB.UpdateTable(ctx, &params); err != nil {
+			return err
+		} else if err := dynamodb.NewTableExistsWaiter(r.DDB).Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)}, tableWaitTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enableStream enables the stream of existing tables. A stream with another view type is
+// reported, the stream must be disabled before the view type changes.
+func (r *Runner) enableStream(ctx context.Context, tableName string, table Table, existing *types.StreamSpecification) error {
+	if table.StreamViewType == "" {
+		return nil
+	} else if existing != nil && aws.ToBool(existing.StreamEnabled) {
+		if existing.StreamViewType != table.StreamViewType {
+			return fmt.Errorf("table %s stream view type %s, requires %s", tableName, existing.StreamViewType, table.StreamViewType)
+		}
+		return nil
+	}
+
+	params := dynamodb.UpdateTableInput{
+		TableName:           aws.String(tableName),
+		StreamSpecification: &types.StreamSpecification{StreamEnabled: aws.Bool(true), StreamViewType: table.StreamViewType},
+	}
+	if _, err := r.DDB.UpdateTable(ctx, &params); err != nil {
+		return err
+	}
+	return dynamodb.NewTableExistsWaiter(r.DDB).Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)}, tableWaitTime)
+}
+
+func keySchema(hashKey Key, rangeKey *Key, attributes map[string]types.ScalarAttributeType) []types.KeySchemaElement {
+	attributes[hashKey.Name] = hashKey.Type
+	schema := []types.KeySchemaElement{{AttributeName: aws.String(hashKey.Name), KeyType: types.KeyTypeHash}}
+	if rangeKey != nil {
+		attributes[rangeKey.Name] = rangeKey.Type
+		schema = append(schema, types.KeySchemaElement{AttributeName: aws.String(rangeKey.Name), KeyType: types.KeyTypeRange})
+	}
+	return schema
+}
+
+func definitions(attributes map[string]types.ScalarAttributeType) []types.AttributeDefinition {
+	names := []string{}
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := []types.AttributeDefinition{}
+	for _, name := range names {
+		out = append(out, types.AttributeDefinition{AttributeName: aws.String(name), AttributeType: attributes[name]})
+	}
+	return out
+}
+
+func checkKeys(tableName string, schema []types.KeySchemaElement, table Table) error {
+	expected := map[types.KeyType]string{types.KeyTypeHash: table.HashKey.Name}
+	if table.RangeKey != nil {
+		expected[types.KeyTypeRange] = table.RangeKey.Name
+	}
+
+	actual := map[types.KeyType]string{}
+	for _, element := range schema {
+		actual[element.KeyType] = aws.ToString(element.AttributeName)
+	}
+
+	if len(actual) != len(expected) || actual[types.KeyTypeHash] != expected[types.KeyTypeHash] || actual[types.KeyTypeRange] != expected[types.KeyTypeRange] {
+		return fmt.Errorf("table %s keys %v not the declared keys %v", tableName, actual, expected)
+	}
+	return nil
+}
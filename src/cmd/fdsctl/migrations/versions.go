@@ -0,0 +1,230 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/kscott5/fds/orders/restaurants"
+	"github.com/kscott5/fds/users/profiles"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var (
+	stringKey = func(name string) Key { return Key{Name: name, Type: types.ScalarAttributeTypeS} }
+	numberKey = func(name string) *Key { return &Key{Name: name, Type: types.ScalarAttributeTypeN} }
+	userid    = stringKey("userid")
+)
+
+// All migrations in version order. Append new versions; never edit an applied version.
+var All = []Migration{
+	{
+		Version:     1,
+		Description: "create the terraform tables",
+		Tables: []Table{
+			{Name: "Users", HashKey: userid},
+			{Name: "Orders", HashKey: userid, RangeKey: &Key{Name: "orderid", Type: types.ScalarAttributeTypeS}},
+			{Name: "Address", HashKey: userid},
+			{Name: "Favorite", HashKey: userid},
+			{Name: "Audit", HashKey: stringKey("receiptid")},
+			{Name: "Exports", HashKey: stringKey("exportid"), TTLAttribute: "expiresat"},
+			{Name: "Restaurants", HashKey: stringKey("restaurantid")},
+		},
+	},
+	{
+		Version:     2,
+		Description: "rename order item attribute quanity to quantity",
+		Backfill:    renameItemQuantity,
+	},
+	{
+		Version:     3,
+		Description: "create the riders and rider locations tables",
+		Tables: []Table{
+			{Name: "Riders", HashKey: stringKey("riderid")},
+			{Name: "RiderLocations", HashKey: stringKey("riderid"), RangeKey: &Key{Name: "recordedon", Type: types.ScalarAttributeTypeN}, TTLAttribute: "expiresat"},
+		},
+	},
+	{
+		Version:     4,
+		Description: "create the dispatch offers table",
+		Tables: []Table{
+			{Name: "DispatchOffers", HashKey: stringKey("orderid"), RangeKey: numberKey("attempt"), Indexes: []Index{
+				{Name: "status-expireson-index", HashKey: stringKey("status"), RangeKey: numberKey("expireson")},
+				{Name: "riderid-expireson-index", HashKey: stringKey("riderid"), RangeKey: numberKey("expireson")},
+			}},
+		},
+	},
+	{
+		Version:     5,
+		Description: "create the delivery zones table",
+		Tables: []Table{
+			{Name: "Zones", HashKey: stringKey("zoneid")},
+		},
+	},
+	{
+		Version:     6,
+		Description: "create the websocket order subscriptions table",
+		Tables: []Table{
+			{Name: "Connections", HashKey: stringKey("orderid"), RangeKey: &Key{Name: "connectionid", Type: types.ScalarAttributeTypeS}, TTLAttribute: "expiresat", Indexes: []Index{
+				{Name: "connectionid-index", HashKey: stringKey("connectionid"), RangeKey: &Key{Name: "orderid", Type: types.ScalarAttributeTypeS}},
+			}},
+		},
+	},
+	{
+		Version:     7,
+		Description: "enable the orders stream of order change events",
+		Tables: []Table{
+			{Name: "Orders", HashKey: userid, RangeKey: &Key{Name: "orderid", Type: types.ScalarAttributeTypeS}, StreamViewType: types.StreamViewTypeNewAndOldImages},
+		},
+	},
+	{
+		Version:     8,
+		Description: "create the outbox table of domain events",
+		Tables: []Table{
+			{Name: "Outbox", HashKey: stringKey("outboxid"), TTLAttribute: "expiresat", StreamViewType: types.StreamViewTypeKeysOnly, Indexes: []Index{
+				{Name: "pending-index", HashKey: stringKey("pending"), RangeKey: numberKey("createdon")},
+			}},
+		},
+	},
+	{
+		Version:     9,
+		Description: "claim the usernames of existing users",
+		Backfill:    claimUserNames,
+	},
+	{
+		Version:     10,
+		Description: "find restaurants without a location",
+		Backfill:    findRestaurantsWithoutLocation,
+	},
+}
+
+// renameItemQuantity rewrites data.items with the quantity attribute. The json name is unchanged.
+func renameItemQuantity(ctx context.Context, r *Runner) error {
+	tableName := r.TableName("Orders")
+	paginator := dynamodb.NewScanPaginator(r.DDB, &dynamodb.ScanInput{TableName: aws.String(tableName)})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range output.Items {
+			data, ok := item["data"].(*types.AttributeValueMemberM)
+			if !ok {
+				continue
+			}
+			items, ok := data.Value["items"].(*types.AttributeValueMemberL)
+			if !ok {
+				continue
+			}
+
+			renamed := false
+			for _, member := range items.Value {
+				if m, ok := member.(*types.AttributeValueMemberM); ok {
+					if quanity, found := m.Value["quanity"]; found {
+						if _, found := m.Value["quantity"]; !found {
+							m.Value["quantity"] = quanity
+						}
+						delete(m.Value, "quanity")
+						renamed = true
+					}
+				}
+			}
+			if !renamed {
+				continue
+			}
+
+			params := dynamodb.UpdateItemInput{
+				TableName:                 aws.String(tableName),
+				Key:                       map[string]types.AttributeValue{"userid": item["userid"], "orderid": item["orderid"]},
+				UpdateExpression:          aws.String("SET #data.#items = :items"),
+				ExpressionAttributeNames:  map[string]string{"#data": "data", "#items": "items"},
+				ExpressionAttributeValues: map[string]types.AttributeValue{":items": items},
+			}
+			if _, err := r.DDB.UpdateItem(ctx, &params); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// claimUserNames puts the username claim of every user created before claims. A username
+// used by more than one user is claimed by the first user scanned; the others are noted
+// so an operator can rename them.
+func claimUserNames(ctx context.Context, r *Runner) error {
+	tableName := r.TableName("Users")
+	paginator := dynamodb.NewScanPaginator(r.DDB, &dynamodb.ScanInput{TableName: aws.String(tableName)})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range output.Items {
+			user := profiles.User{}
+			if err := attributevalue.UnmarshalMap(item, &user); err != nil {
+				return err
+			} else if strings.HasPrefix(user.UserId, profiles.UserNameClaimPrefix) {
+				continue
+			} else if strings.TrimSpace(user.UserName) == "" {
+				r.Note("user %s has no username", user.UserId)
+				continue
+			}
+
+			claim, err := attributevalue.MarshalMap(profiles.UserNameClaim{ClaimId: profiles.ClaimIdFrom(user.UserName), ClaimedBy: user.UserId})
+			if err != nil {
+				return err
+			}
+
+			params := dynamodb.PutItemInput{
+				TableName:                           aws.String(tableName),
+				Item:                                claim,
+				ConditionExpression:                 aws.String("attribute_not_exists(userid) OR claimedby = :userid"),
+				ExpressionAttributeValues:           map[string]types.AttributeValue{":userid": &types.AttributeValueMemberS{Value: user.UserId}},
+				ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+			}
+
+			var conditionFailed *types.ConditionalCheckFailedException
+			if _, err := r.DDB.PutItem(ctx, &params); errors.As(err, &conditionFailed) {
+				held := profiles.UserNameClaim{}
+				attributevalue.UnmarshalMap(conditionFailed.Item, &held)
+				r.Note("username %s of user %s is claimed by user %s", user.UserName, user.UserId, held.ClaimedBy)
+			} else if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// findRestaurantsWithoutLocation notes the restaurants created before delivery zones without
+// address coordinates. Their bike distance is not checked until an operator geocodes them,
+// e.g. with fdsctl fixtures load of the restaurant records.
+func findRestaurantsWithoutLocation(ctx context.Context, r *Runner) error {
+	paginator := dynamodb.NewScanPaginator(r.DDB, &dynamodb.ScanInput{
+		TableName:                aws.String(r.TableName("Restaurants")),
+		ProjectionExpression:     aws.String("restaurantid, #address"),
+		ExpressionAttributeNames: map[string]string{"#address": "address"},
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range output.Items {
+			restaurant := restaurants.Restaurant{}
+			if err := attributevalue.UnmarshalMap(item, &restaurant); err != nil {
+				return err
+			} else if restaurant.Address.Latitude == 0 && restaurant.Address.Longitude == 0 {
+				r.Note("restaurant %s has no location", restaurant.RestaurantId)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/notify"
+	"github.com/kscott5/fds/notifications/notifier"
+	"github.com/kscott5/fds/notifications/providers"
+	"github.com/kscott5/fds/notifications/templates"
+	"github.com/kscott5/fds/orders/restaurants"
+	"github.com/kscott5/fds/users/profiles"
+)
+
+// sendNotifications notifies the recipients of order events, e.g. the output of events
+// replay, with the development providers. Recipients are read from the users and
+// restaurants tables.
+func sendNotifications(ctx context.Context, args []string) (interface{}, error) {
+	flags := newFlags("notifications send")
+	file := flags.String("file", "", "json array of order events")
+	provider := flags.String("provider", "stdout", "stdout, file or aws")
+	path := flags.String("path", "notifications.jsonl", "messages file of the file provider")
+	flags.Parse(args)
+
+	requires := map[string]string{"file": "string"}
+	if *file == "" {
+		return nil, fmt.Errorf("requires: %s", requires)
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return nil, err
+	}
+
+	orderEvents := []notifier.OrderEvent{}
+	if err := json.Unmarshal(data, &orderEvents); err != nil {
+		return nil, fmt.Errorf("%s: %w", *file, err)
+	}
+
+	var sender notify.Provider
+	switch *provider {
+	case "stdout":
+		sender = notify.Writer{W: os.Stderr} // stdout is the command output
+	case "file":
+		sender = &notify.File{Path: *path}
+	case "aws":
+		sender = providers.New(client.LoadConfig(), getEnv("FDS_NOTIFICATIONS_EMAIL_FROM", ""))
+	default:
+		return nil, fmt.Errorf("provider %s not available. requires stdout, file or aws", *provider)
+	}
+
+	service := notifier.Notifier{
+		Directory: notifier.Tables{
+			DDB:              client.NewDynamodb(),
+			UsersTable:       getEnv("FDS_APPS_USERS_TABLE", profiles.DefaultUsersTable),
+			RestaurantsTable: getEnv("FDS_APPS_RESTAURANTS_TABLE", restaurants.DefaultRestaurantsTable),
+		},
+		Templates: templates.MustLoad(),
+		Provider:  sender,
+	}
+
+	sent := map[string]int{}
+	for _, event := range orderEvents {
+		count, err := service.Notify(ctx, event)
+		if err != nil {
+			return sent, err
+		}
+		sent[event.Id] = count
+	}
+	return sent, nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/push"
+	"github.com/kscott5/fds/orders/restaurants"
+	"github.com/kscott5/fds/orders/services"
+	"github.com/kscott5/fds/riders/dispatch"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+func ordersTable() string {
+	return getEnv("FDS_APPS_ORDERS_TABLE", services.DefaultOrderTable)
+}
+
+func listOrders(ctx context.Context, args []string) (interface{}, error) {
+	flags := newFlags("orders list")
+	userid := flags.String("user", "", "user id. all users when empty.")
+	flags.Parse(args)
+
+	tableName := ordersTable()
+	ddb := client.NewDynamodb()
+
+	items := []map[string]types.AttributeValue{}
+	if *userid == "" {
+		paginator := dynamodb.NewScanPaginator(ddb, &dynamodb.ScanInput{TableName: aws.String(tableName)})
+		for paginator.HasMorePages() {
+			if output, err := paginator.NextPage(ctx); err != nil {
+				return nil, err
+			} else {
+				items = append(items, output.Items...)
+			}
+		}
+	} else {
+		value, _ := attributevalue.Marshal(*userid)
+		paginator := dynamodb.NewQueryPaginator(ddb, &dynamodb.QueryInput{
+			TableName:                 aws.String(tableName),
+			KeyConditionExpression:    aws.String("userid = :userid"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{":userid": value},
+		})
+		for paginator.HasMorePages() {
+			if output, err := paginator.NextPage(ctx); err != nil {
+				return nil, err
+			} else {
+				items = append(items, output.Items...)
+			}
+		}
+	}
+
+	orders := []services.OrderItem{}
+	if err := attributevalue.UnmarshalListOfMaps(items, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func getOrder(ctx context.Context, args []string) (interface{}, error) {
+	flags := newFlags("orders get")
+	userid := flags.String("user", "", "user id")
+	orderid := flags.String("id", "", "order id")
+	flags.Parse(args)
+
+	requires := map[string]string{"user": "string", "id": "string"}
+	if *userid == "" || *orderid == "" {
+		return nil, fmt.Errorf("requires: %s", requires)
+	}
+
+	user, _ := attributevalue.Marshal(*userid)
+	order, _ := attributevalue.Marshal(*orderid)
+	tableName := ordersTable()
+	ddb := client.NewDynamodb()
+	params := dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key:       map[string]types.AttributeValue{"userid": user, "orderid": order},
+	}
+
+	out := services.OrderItem{}
+	if output, err := ddb.GetItem(ctx, &params); err != nil {
+		return nil, err
+	} else if output.Item == nil {
+		return nil, fmt.Errorf("order %s not found", *orderid)
+	} else if err := attributevalue.UnmarshalMap(output.Item, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func transitionOrder(ctx context.Context, args []string) (interface{}, error) {
+	flags := newFlags("orders transition")
+	userid := flags.String("user", "", "user id")
+	orderid := flags.String("id", "", "order id")
+	status := flags.String("status", "", "placed, acknowledged, paused or cancelled")
+	reason := flags.String("reason", "", "reason recorded in the order timeline")
+	websocket := flags.String("websocket", getEnv("FDS_WEBSOCKET_ENDPOINT", ""), "websocket stage endpoint of order updates. none when empty.")
+	dispatcher := flags.String("dispatcher", getEnv("FDS_DISPATCHER_FUNCTION", "FDSAppsDispatcher"), "dispatcher function of acknowledged orders. none when empty.")
+	flags.Parse(args)
+
+	requires := map[string]string{"user": "string", "id": "string", "status": "string"}
+	if *userid == "" || *orderid == "" || *status == "" {
+		return nil, fmt.Errorf("requires: %s", requires)
+	}
+
+	var to services.OrderStatus
+	if err := json.Unmarshal([]byte(fmt.Sprintf("%q", *status)), &to); err != nil {
+		return nil, fmt.Errorf("status %s not available", *status)
+	}
+
+	tableName := ordersTable()
+	ddb := client.NewDynamodb()
+	services.Push = push.New(client.LoadConfig(), ddb, getEnv("FDS_APPS_CONNECTIONS_TABLE", push.DefaultConnectionsTable), *websocket)
+	order, err := services.TransitionOrder(ctx, ddb, tableName, *userid, *orderid, to, services.OperatorActor, *reason)
+	if err != nil {
+		return nil, err
+	}
+
+	// the delivery estimate follows the status and is pushed to the order subscribers
+	tables := services.EtaTables{Orders: tableName, Restaurants: getEnv("FDS_APPS_RESTAURANTS_TABLE", restaurants.DefaultRestaurantsTable)}
+	if refreshed, err := services.RefreshEta(ctx, ddb, tables, *userid, *orderid, nil, 0); err != nil {
+		return nil, err
+	} else if refreshed != nil {
+		order = refreshed
+	}
+
+	if to != services.Acknowledged || *dispatcher == "" {
+		return order, nil
+	}
+
+	// acknowledged orders are offered to riders asynchronously
+	payload, _ := json.Marshal(dispatch.Request{OrderId: *orderid, UserId: *userid})
+	if _, err := client.NewLambda().Invoke(ctx, &lambda.InvokeInput{
+		FunctionName:   aws.String(*dispatcher),
+		InvocationType: lambdatypes.InvocationTypeEvent,
+		Payload:        payload,
+	}); err != nil {
+		return nil, fmt.Errorf("order %s acknowledged. dispatch not available: %w", *orderid, err)
+	}
+	return order, nil
+}
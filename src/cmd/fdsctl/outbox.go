@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/outbox"
+
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+)
+
+func outboxTable() string {
+	return getEnv("FDS_APPS_OUTBOX_TABLE", outbox.DefaultTable)
+}
+
+func pendingOutbox(ctx context.Context, args []string) (interface{}, error) {
+	flags := newFlags("outbox pending")
+	limit := flags.Int("limit", 100, "maximum records, oldest first")
+	flags.Parse(args)
+
+	store := outbox.Table{DDB: client.NewDynamodb(), TableName: outboxTable()}
+	return store.Pending(ctx, int32(*limit))
+}
+
+// drainOutbox publishes the pending records like the relay lambda
+func drainOutbox(ctx context.Context, args []string) (interface{}, error) {
+	flags := newFlags("outbox drain")
+	bus := flags.String("bus", getEnv("FDS_EVENT_BUS_NAME", ""), "event bus name")
+	flags.Parse(args)
+
+	requires := map[string]string{"bus": "string"}
+	if *bus == "" {
+		return nil, fmt.Errorf("requires: %s", requires)
+	}
+
+	relay := outbox.Relay{
+		Store:     outbox.Table{DDB: client.NewDynamodb(), TableName: outboxTable()},
+		Publisher: outbox.EventBridge{Client: eventbridge.NewFromConfig(client.LoadConfig()), BusName: *bus},
+	}
+	published, err := relay.Drain(ctx, time.Now)
+	return map[string]int{"published": published}, err
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+func write(w io.Writer, format string, out interface{}) error {
+	if out == nil {
+		return nil
+	}
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(out)
+	case "table":
+		return writeTable(w, out)
+	default:
+		return fmt.Errorf("output format %s not available. requires json or table", format)
+	}
+}
+
+// writeTable writes one row per object with the top level attributes as columns.
+// Nested values are written as compact json.
+func writeTable(w io.Writer, out interface{}) error {
+	data, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	rows := []map[string]interface{}{}
+	switch v := value.(type) {
+	case []interface{}:
+		for _, row := range v {
+			if object, ok := row.(map[string]interface{}); ok {
+				rows = append(rows, object)
+			} else {
+				rows = append(rows, map[string]interface{}{"value": row})
+			}
+		}
+	case map[string]interface{}:
+		rows = append(rows, v)
+	default:
+		rows = append(rows, map[string]interface{}{"value": v})
+	}
+
+	columns := []string{}
+	seen := map[string]bool{}
+	for _, row := range rows {
+		for name := range row {
+			if !seen[name] {
+				seen[name] = true
+				columns = append(columns, name)
+			}
+		}
+	}
+	sort.Strings(columns)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(columns, "\t")))
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, name := range columns {
+			cells[i] = cell(row[name])
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+func cell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/kscott5/fds/cmd/fdsctl/fixtures"
+	"github.com/kscott5/fds/internal/client"
+)
+
+// loadFixtures validates the fixtures file and writes it unless -dry-run
+func loadFixtures(ctx context.Context, args []string) (interface{}, error) {
+	flags := newFlags("fixtures load")
+	file := flags.String("file", "data/fixtures.yaml", "yaml or json fixtures file")
+	dryRun := flags.Bool("dry-run", false, "validate without writing")
+	flags.Parse(args)
+
+	f, violations, err := fixtures.Load(*file)
+	if err != nil {
+		return nil, err
+	} else if len(violations) > 0 {
+		write(os.Stderr, "table", violations)
+		return nil, fmt.Errorf("%s has %d violations", *file, len(violations))
+	} else if *dryRun {
+		return counts(f), nil
+	}
+	return writeFixtures(ctx, f)
+}
+
+// generateFixtures writes synthetic fixtures, or prints them with -print
+func generateFixtures(ctx context.Context, args []string) (interface{}, error) {
+	flags := newFlags("fixtures generate")
+	options := fixtures.GenerateOptions{}
+	flags.IntVar(&options.Customers, "customers", 10, "customers with an address")
+	flags.IntVar(&options.OrdersPerCustomer, "orders", 5, "orders per customer")
+	flags.IntVar(&options.Restaurants, "restaurants", 5, "restaurants")
+	flags.IntVar(&options.MaxItemsPerOrder, "items", 3, "maximum items per order")
+	flags.Int64Var(&options.Seed, "seed", 1, "random seed")
+	print := flags.Bool("print", false, "print the fixtures without writing")
+	flags.Parse(args)
+
+	f := fixtures.Generate(options)
+	if *print {
+		return f, nil
+	}
+	return writeFixtures(ctx, f)
+}
+
+func writeFixtures(ctx context.Context, f *fixtures.Fixtures) (interface{}, error) {
+	requests, err := f.WriteRequests(fixtures.TablesFromEnv())
+	if err != nil {
+		return nil, err
+	}
+
+	written := map[string]int{}
+	for tableName, writes := range requests {
+		if err := fixtures.BatchWrite(ctx, client.NewDynamodb(), tableName, writes); err != nil {
+			return nil, err
+		}
+		written[tableName] = len(writes)
+	}
+	return written, nil
+}
+
+func counts(f *fixtures.Fixtures) map[string]int {
+	return map[string]int{
+		"users":       len(f.Users),
+		"addresses":   len(f.Addresses),
+		"restaurants": len(f.Restaurants),
+		"orders":      len(f.Orders),
+	}
+}
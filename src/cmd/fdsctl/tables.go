@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/kscott5/fds/cmd/fdsctl/fixtures"
+	"github.com/kscott5/fds/internal/client"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// batchFile is the aws dynamodb batch-write-item --request-items file format
+type batchFile map[string][]struct {
+	PutRequest struct {
+		Item map[string]json.RawMessage
+	}
+}
+
+func seedTables(ctx context.Context, args []string) (interface{}, error) {
+	flags := newFlags("tables seed")
+	file := flags.String("file", "", "batch-write-item request items file, e.g. tables dump output")
+	flags.Parse(args)
+
+	if *file == "" {
+		return nil, fmt.Errorf("requires: -file")
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return nil, err
+	}
+	batch := batchFile{}
+	if err := json.Unmarshal(data, &batch); err != nil {
+		return nil, err
+	}
+
+	written := map[string]int{}
+	for tableName, requests := range batch {
+		writes := []types.WriteRequest{}
+		for i, request := range requests {
+			if item, err := decodeItem(request.PutRequest.Item); err != nil {
+				return nil, fmt.Errorf("%s[%d]: %w", tableName, i, err)
+			} else {
+				writes = append(writes, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+			}
+		}
+
+		if err := fixtures.BatchWrite(ctx, client.NewDynamodb(), tableName, writes); err != nil {
+			return nil, err
+		}
+		written[tableName] = len(writes)
+	}
+	return written, nil
+}
+
+// dumpTable writes the table items in the seed file format
+func dumpTable(ctx context.Context, args []string) (interface{}, error) {
+	flags := newFlags("tables dump")
+	tableName := flags.String("table", "", "table name")
+	flags.Parse(args)
+
+	if *tableName == "" {
+		return nil, fmt.Errorf("requires: -table")
+	}
+
+	requests := []interface{}{}
+	ddb := client.NewDynamodb()
+	paginator := dynamodb.NewScanPaginator(ddb, &dynamodb.ScanInput{TableName: aws.String(*tableName)})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range output.Items {
+			requests = append(requests, map[string]interface{}{
+				"PutRequest": map[string]interface{}{"Item": encodeItem(item)},
+			})
+		}
+	}
+	return map[string]interface{}{*tableName: requests}, nil
+}
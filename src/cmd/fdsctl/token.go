@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mintToken signs a cognito style id token for local development. Tokens are signed with
+// the -key private key, or a new key when -key is empty. -jwks writes the json web key set
+// of the signing key; a local authorizer with FDS_JWKS_URL set to the file verifies the
+// minted tokens. The issuer and audience are the -region, -pool and -client the authorizer
+// is configured with.
+func mintToken(ctx context.Context, args []string) (interface{}, error) {
+	flags := newFlags("token mint")
+	sub := flags.String("sub", "", "subject, the user id")
+	username := flags.String("username", "", "cognito:username")
+	email := flags.String("email", "", "email")
+	groups := flags.String("groups", "", "comma separated cognito:groups")
+	kid := flags.String("kid", "local", "key id header")
+	ttl := flags.Duration("ttl", time.Hour, "time to live")
+	keyFile := flags.String("key", "", "PEM encoded RSA private key")
+	jwksFile := flags.String("jwks", "", "json web key set file of the signing key, used with FDS_JWKS_URL")
+	region := flags.String("region", getEnv("AWS_REGION", ""), "user pool region of the issuer. defaults to AWS_REGION")
+	pool := flags.String("pool", getEnv("FDS_USER_POOL_ID", ""), "user pool id of the issuer. defaults to FDS_USER_POOL_ID")
+	audience := flags.String("client", getEnv("FDS_APPLICATION_CLIENT_ID", ""), "app client id audience. defaults to FDS_APPLICATION_CLIENT_ID")
+	flags.Parse(args)
+
+	requires := map[string]string{"sub": "string", "region": "string", "pool": "string", "client": "string"}
+	if *sub == "" || *region == "" || *pool == "" || *audience == "" {
+		return nil, fmt.Errorf("requires: %s", requires)
+	}
+
+	key, err := privateKey(*keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub":              *sub,
+		"cognito:username": *username,
+		"email":            *email,
+		"token_use":        "id",
+		"iss":              fmt.Sprintf("https://cognito-idp.%s.amazonaws.com/%s", *region, *pool),
+		"aud":              *audience,
+		"iat":              now.Unix(),
+		"exp":              now.Add(*ttl).Unix(),
+	}
+	if *groups != "" {
+		claims["cognito:groups"] = strings.Split(*groups, ",")
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = *kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string]interface{}{"token": signed, "expires": now.Add(*ttl).Format(time.RFC3339)}
+	if *jwksFile != "" {
+		if err := writeJwks(*jwksFile, *kid, &key.PublicKey); err != nil {
+			return nil, err
+		}
+		out["jwks"] = *jwksFile
+	}
+	return out, nil
+}
+
+// writeJwks writes the json web key set of the public key, the format of the user pool
+// .well-known/jwks.json
+func writeJwks(path, kid string, key *rsa.PublicKey) error {
+	set := map[string][]map[string]string{"keys": {{
+		"alg": "RS256",
+		"kty": "RSA",
+		"use": "sig",
+		"kid": kid,
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+
+	if data, err := json.MarshalIndent(set, "", "  "); err != nil {
+		return err
+	} else {
+		return os.WriteFile(path, data, 0644)
+	}
+}
+
+func privateKey(keyFile string) (*rsa.PrivateKey, error) {
+	if keyFile == "" {
+		return rsa.GenerateKey(rand.Reader, 2048)
+	}
+
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s not PEM encoded", keyFile)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	} else if parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+		return nil, err
+	} else if key, ok := parsed.(*rsa.PrivateKey); ok {
+		return key, nil
+	} else {
+		return nil, fmt.Errorf("%s not an RSA private key", keyFile)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/kscott5/fds/pkg/fdsclient"
+)
+
+// apiFlags adds the rest api url and token flags
+func apiFlags(name string) (*flag.FlagSet, *string, *string) {
+	flags := newFlags(name)
+	url := flags.String("url", getEnv("FDS_API_URL", ""), "rest api url. defaults to FDS_API_URL")
+	token := flags.String("token", getEnv("FDS_TOKEN", ""), "access or id token. defaults to FDS_TOKEN")
+	return flags, url, token
+}
+
+func listUsers(ctx context.Context, args []string) (interface{}, error) {
+	flags, url, token := apiFlags("users list")
+	deleted := flags.Bool("deleted", false, "include soft deleted users. administrators only.")
+	flags.Parse(args)
+
+	return fdsclient.New(*url, *token).Users.List(ctx, *deleted)
+}
+
+func getUser(ctx context.Context, args []string) (interface{}, error) {
+	flags, url, token := apiFlags("users get")
+	userid := flags.String("id", "", "user id")
+	flags.Parse(args)
+
+	if *userid == "" {
+		return nil, fmt.Errorf("requires: -id")
+	}
+	return fdsclient.New(*url, *token).Users.Get(ctx, *userid)
+}
+
+func createUser(ctx context.Context, args []string) (interface{}, error) {
+	flags, url, token := apiFlags("users create")
+	username := flags.String("username", "", "user name")
+	fullname := flags.String("fullname", "", "full name")
+	flags.Parse(args)
+
+	user := fdsclient.User{UserName: *username, FullName: *fullname}
+	if userid, err := fdsclient.New(*url, *token).Users.Create(ctx, user); err != nil {
+		return nil, err
+	} else {
+		return map[string]string{"userid": userid}, nil
+	}
+}
+
+func deleteUser(ctx context.Context, args []string) (interface{}, error) {
+	flags, url, token := apiFlags("users delete")
+	userid := flags.String("id", "", "user id")
+	flags.Parse(args)
+
+	if *userid == "" {
+		return nil, fmt.Errorf("requires: -id")
+	} else if err := fdsclient.New(*url, *token).Users.Delete(ctx, *userid); err != nil {
+		return nil, err
+	} else {
+		return map[string]string{"userid": *userid, "status": "deleted"}, nil
+	}
+}
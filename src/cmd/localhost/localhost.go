@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"go.uber.org/zap"
+	"golang.org/x/net/websocket"
 )
 
 func main() {
@@ -21,6 +22,11 @@ func main() {
 		res.Write([]byte("Example: javascript:document.location.hash"))
 	})
 
+	// in-process order updates, see websocket.go
+	sockets := NewSockets(logger)
+	http.Handle("/ws", websocket.Handler(sockets.Connect))
+	http.HandleFunc("/push/", sockets.Publish)
+
 	logger.Info("Starting localhost on port: 8080")
 
 	if err := http.ListenAndServe(":8080", nil); err != nil {
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kscott5/fds/internal/push"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/websocket"
+)
+
+// Sockets is the in-process equivalent of the WebSocket api. Clients connect to /ws, send the
+// subscribe and unsubscribe actions of the order tracking lambda and receive the updates
+// published with POST /push/{orderid}.
+type Sockets struct {
+	Hub    push.Hub
+	logger *zap.Logger
+
+	mu    sync.Mutex
+	conns map[string]*websocket.Conn
+}
+
+func NewSockets(logger *zap.Logger) *Sockets {
+	sockets := &Sockets{logger: logger, conns: map[string]*websocket.Conn{}}
+	sockets.Hub = push.Hub{Store: push.NewMemory(), Sender: sockets}
+	return sockets
+}
+
+// Send writes the message to the connection. push.ErrGone is returned for closed connections.
+func (s *Sockets) Send(ctx context.Context, connectionid string, message []byte) error {
+	s.mu.Lock()
+	conn, found := s.conns[connectionid]
+	s.mu.Unlock()
+
+	if !found {
+		return push.ErrGone
+	} else if err := websocket.Message.Send(conn, string(message)); err != nil {
+		return push.ErrGone
+	}
+	return nil
+}
+
+// local orders belong to every customer
+func anyOwner(context.Context, string, string) (bool, error) {
+	return true, nil
+}
+
+// Connect serves a connection. The customer is the userid query parameter, default localhost.
+func (s *Sockets) Connect(conn *websocket.Conn) {
+	ctx := context.Background()
+	connectionid := uuid.New().String()
+	userid := conn.Request().URL.Query().Get("userid")
+	if userid == "" {
+		userid = "localhost"
+	}
+
+	logger := s.logger.With(zap.String("connectionid", connectionid), zap.String("userid", userid))
+	logger.Info("websocket connected")
+
+	s.mu.Lock()
+	s.conns[connectionid] = conn
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, connectionid)
+		s.mu.Unlock()
+
+		s.Hub.Store.Disconnect(ctx, connectionid)
+		logger.Info("websocket disconnected")
+	}()
+
+	for {
+		var body string
+		if err := websocket.Message.Receive(conn, &body); err != nil {
+			return
+		}
+
+		reply, err := push.Handle(ctx, s.Hub.Store, anyOwner, connectionid, userid, []byte(body), time.Now())
+		if err != nil {
+			logger.Error("websocket action", zap.Error(err))
+			return
+		} else if err := websocket.JSON.Send(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// Publish pushes the json request body to the subscribers of POST /push/{orderid}
+func (s *Sockets) Publish(res http.ResponseWriter, req *http.Request) {
+	orderid := strings.TrimPrefix(req.URL.Path, "/push/")
+	if req.Method != http.MethodPost || orderid == "" {
+		http.Error(res, "requires: POST /push/{orderid}", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil || !json.Valid(body) {
+		http.Error(res, "requires: json body", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.Hub.Publish(req.Context(), orderid, json.RawMessage(body)); err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,100 @@
+module github.com/kscott5/fds/cmd/openapi
+
+go 1.22.1
+
+require (
+	github.com/kscott5/fds/internal/client v0.0.0-00010101000000-000000000000
+	github.com/kscott5/fds/internal/router v0.0.0-00010101000000-000000000000
+	github.com/kscott5/fds/orders v0.0.0-00010101000000-000000000000
+	github.com/kscott5/fds/users v0.0.0-00010101000000-000000000000
+	go.uber.org/zap v1.27.0
+)
+
+require (
+	github.com/aws/aws-sdk-go-v2/service/apigatewaymanagementapi v1.19.5 // indirect
+	github.com/aws/aws-sdk-go-v2/service/eventbridge v1.31.1 // indirect
+	github.com/kscott5/fds/internal/notify v0.0.0-00010101000000-000000000000 // indirect
+)
+
+require (
+	github.com/aws/aws-lambda-go v1.47.0 // indirect
+	github.com/aws/aws-sdk-go-v2 v1.26.2 // indirect
+	github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream v1.6.2 // indirect
+	github.com/aws/aws-sdk-go-v2/config v1.27.13 // indirect
+	github.com/aws/aws-sdk-go-v2/credentials v1.17.13 // indirect
+	github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue v1.13.16 // indirect
+	github.com/aws/aws-sdk-go-v2/feature/ec2/imds v1.16.1 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/configsources v1.3.6 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/endpoints/v2 v2.6.6 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/ini v1.8.0 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/v4a v1.3.6 // indirect
+	github.com/aws/aws-sdk-go-v2/service/dynamodb v1.32.2 // indirect
+	github.com/aws/aws-sdk-go-v2/service/dynamodbstreams v1.20.6 // indirect
+	github.com/aws/aws-sdk-go-v2/service/internal/accept-encoding v1.11.2 // indirect
+	github.com/aws/aws-sdk-go-v2/service/internal/checksum v1.3.7 // indirect
+	github.com/aws/aws-sdk-go-v2/service/internal/endpoint-discovery v1.9.7 // indirect
+	github.com/aws/aws-sdk-go-v2/service/internal/presigned-url v1.11.7 // indirect
+	github.com/aws/aws-sdk-go-v2/service/internal/s3shared v1.17.5 // indirect
+	github.com/aws/aws-sdk-go-v2/service/lambda v1.54.0 // indirect
+	github.com/aws/aws-sdk-go-v2/service/s3 v1.53.1 // indirect
+	github.com/aws/aws-sdk-go-v2/service/ssm v1.50.2 // indirect
+	github.com/aws/aws-sdk-go-v2/service/sso v1.20.6 // indirect
+	github.com/aws/aws-sdk-go-v2/service/ssooidc v1.24.0 // indirect
+	github.com/aws/aws-sdk-go-v2/service/sts v1.28.7 // indirect
+	github.com/aws/smithy-go v1.20.2 // indirect
+	github.com/cenkalti/backoff/v4 v4.3.0 // indirect
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.19.1 // indirect
+	github.com/jmespath/go-jmespath v0.4.0 // indirect
+	github.com/kscott5/fds/internal/config v0.0.0-00010101000000-000000000000 // indirect
+	github.com/kscott5/fds/internal/logging v0.0.0-00010101000000-000000000000 // indirect
+	github.com/kscott5/fds/internal/metrics v0.0.0-00010101000000-000000000000 // indirect
+	github.com/kscott5/fds/internal/outbox v0.0.0-00010101000000-000000000000 // indirect
+	github.com/kscott5/fds/internal/push v0.0.0-00010101000000-000000000000 // indirect
+	github.com/kscott5/fds/internal/schema v0.0.0-00010101000000-000000000000 // indirect
+	github.com/kscott5/fds/internal/tracing v0.0.0-00010101000000-000000000000 // indirect
+	github.com/kscott5/fds/riders v0.0.0-00010101000000-000000000000
+	go.opentelemetry.io/otel v1.26.0 // indirect
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace v1.26.0 // indirect
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp v1.26.0 // indirect
+	go.opentelemetry.io/otel/metric v1.26.0 // indirect
+	go.opentelemetry.io/otel/sdk v1.26.0 // indirect
+	go.opentelemetry.io/otel/trace v1.26.0 // indirect
+	go.opentelemetry.io/proto/otlp v1.2.0 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	golang.org/x/net v0.23.0 // indirect
+	golang.org/x/sys v0.19.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	google.golang.org/genproto/googleapis/api v0.0.0-20240227224415-6ceb2ff114de // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240401170217-c3f982113cda // indirect
+	google.golang.org/grpc v1.63.2 // indirect
+	google.golang.org/protobuf v1.33.0 // indirect
+)
+
+replace github.com/kscott5/fds/internal/client => ../../internal/
+
+replace github.com/kscott5/fds/internal/schema => ../../internal/schema/
+
+replace github.com/kscott5/fds/internal/router => ../../internal/router/
+
+replace github.com/kscott5/fds/users => ../../users/
+
+replace github.com/kscott5/fds/orders => ../../orders/
+
+replace github.com/kscott5/fds/internal/config => ../../internal/config/
+
+replace github.com/kscott5/fds/internal/logging => ../../internal/logging/
+
+replace github.com/kscott5/fds/internal/tracing => ../../internal/tracing/
+
+replace github.com/kscott5/fds/internal/metrics => ../../internal/metrics/
+
+replace github.com/kscott5/fds/riders => ../../riders/
+
+replace github.com/kscott5/fds/internal/push => ../../internal/push/
+
+replace github.com/kscott5/fds/internal/outbox => ../../internal/outbox/
+
+replace github.com/kscott5/fds/internal/notify => ../../internal/notify/
@@ -0,0 +1,37 @@
+// openapi writes the API Gateway OpenAPI document from the service route registrations.
+//
+//	go run . -o ../../../modules/openapi.json.tftpl
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/kscott5/fds/internal/router"
+	orders "github.com/kscott5/fds/orders/services"
+	riders "github.com/kscott5/fds/riders/services"
+	users "github.com/kscott5/fds/users/services"
+
+	"go.uber.org/zap"
+)
+
+func main() {
+	logger, _ := zap.NewDevelopment()
+	output := flag.String("o", "openapi.json.tftpl", "terraform templatefile path")
+	flag.Parse()
+
+	doc := newDocument()
+	for service, register := range map[string]func(*router.Router){"users": users.Register, "orders": orders.Register, "riders": riders.Register} {
+		routes := router.New(service)
+		register(routes)
+		doc.add(routes)
+	}
+
+	if body, err := doc.template(); err != nil {
+		logger.Fatal(err.Error())
+	} else if err := os.WriteFile(*output, body, 0644); err != nil {
+		logger.Fatal(err.Error())
+	} else {
+		logger.Info("openapi document written to " + *output)
+	}
+}
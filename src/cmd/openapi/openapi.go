@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/router"
+)
+
+// template variables are written as @@name@@ and become ${name} after the
+// document is escaped for terraform templatefile
+const (
+	authorizerUriVar = "@@authorizer_uri@@"
+	functionArnVar   = "@@%s_function_arn@@"
+)
+
+var (
+	pathParameter = regexp.MustCompile(`{([^}]+)}`)
+	templateVar   = regexp.MustCompile(`@@([a-z_]+)@@`)
+	escaper       = strings.NewReplacer("${", "$${", "%{", "%%{")
+
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	stringerType  = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+type document struct {
+	schemas map[string]interface{}
+	paths   map[string]map[string]interface{}
+}
+
+func newDocument() *document {
+	d := &document{schemas: map[string]interface{}{}, paths: map[string]map[string]interface{}{}}
+	d.schemaOf(reflect.TypeOf(client.ErrorBody{}))
+	return d
+}
+
+// add documents the service routes. Aliases are not documented.
+func (d *document) add(r *router.Router) {
+	for _, route := range r.Routes() {
+		path, found := d.paths[route.Resource]
+		if !found {
+			path = map[string]interface{}{}
+			d.paths[route.Resource] = path
+		}
+		path[strings.ToLower(route.Method)] = d.operation(r.Service, route)
+	}
+}
+
+func (d *document) operation(service string, route router.Route) map[string]interface{} {
+	errorBody := map[string]interface{}{
+		"description": "error",
+		"content":     jsonContent(map[string]interface{}{"$ref": "#/components/schemas/ErrorBody"}),
+	}
+
+	operation := map[string]interface{}{
+		"summary": route.Summary,
+		"responses": map[string]interface{}{
+			strconv.Itoa(route.Status): map[string]interface{}{
+				"description": "success",
+				"content":     jsonContent(d.schemaOf(reflect.TypeOf(route.Response))),
+			},
+			"4XX": errorBody,
+		},
+		"x-amazon-apigateway-integration": map[string]interface{}{
+			"httpMethod":          "POST",
+			"type":                "aws_proxy",
+			"passthroughBehavior": "WHEN_NO_MATCH",
+			"uri":                 fmt.Sprintf("arn:aws:apigateway:@@region@@:lambda:path/2015-03-31/functions/"+functionArnVar+"/invocations", service),
+		},
+	}
+	if !route.Public {
+		operation["security"] = []interface{}{map[string]interface{}{"lambdaTokenAuthorizer": []string{}}}
+	}
+
+	parameters := []interface{}{}
+	for _, match := range pathParameter.FindAllStringSubmatch(route.Resource, -1) {
+		parameters = append(parameters, map[string]interface{}{
+			"name": match[1], "in": "path", "required": true, "schema": map[string]string{"type": "string"},
+		})
+	}
+	names := make([]string, 0, len(route.Query))
+	for name := range route.Query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parameters = append(parameters, map[string]interface{}{
+			"name": name, "in": "query", "description": route.Query[name], "schema": map[string]string{"type": "string"},
+		})
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+
+	content := map[string]interface{}{}
+	if route.Request != nil {
+		content["application/json"] = map[string]interface{}{"schema": route.Request.OpenAPI()}
+	}
+	for contentType, request := range route.RequestTypes {
+		content[contentType] = map[string]interface{}{"schema": request.OpenAPI()}
+	}
+	if len(content) > 0 {
+		operation["requestBody"] = map[string]interface{}{"required": true, "content": content}
+	}
+
+	return operation
+}
+
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+// schemaOf reflects the json encoding of a response type. Named structs are added to components.
+func (d *document) schemaOf(t reflect.Type) map[string]interface{} {
+	if t == nil {
+		return map[string]interface{}{}
+	} else if t.Implements(marshalerType) && t.Implements(stringerType) {
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return d.schemaOf(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": d.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": d.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if _, found := d.schemas[t.Name()]; !found {
+			d.schemas[t.Name()] = map[string]interface{}{} // recursive types
+			d.schemas[t.Name()] = d.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func (d *document) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		} else if name == "" {
+			name = field.Name
+		}
+		properties[name] = d.schemaOf(field.Type)
+	}
+
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+// template returns the document as a terraform templatefile
+func (d *document) template() ([]byte, error) {
+	body, err := json.MarshalIndent(map[string]interface{}{
+		"openapi": "3.0.1",
+		"info": map[string]string{
+			"title":   "FDS RestAPI (Go)",
+			"version": "1.0",
+		},
+		"components": map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"lambdaTokenAuthorizer": map[string]interface{}{
+					"type":                         "apiKey",
+					"name":                         "Authorization",
+					"in":                           "header",
+					"x-amazon-apigateway-authtype": "custom",
+					"x-amazon-apigateway-authorizer": map[string]interface{}{
+						"authorizerUri":                authorizerUriVar,
+						"authorizerResultTtlInSeconds": 300,
+						"type":                         "token",
+					},
+				},
+			},
+			"schemas": d.schemas,
+		},
+		"x-amazon-apigateway-binary-media-types": []string{"application/zip"},
+		"paths":                                  d.paths,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	escaped := escaper.Replace(string(body))
+	return []byte(templateVar.ReplaceAllString(escaped, "$${$1}") + "\n"), nil
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/internal/metrics"
+	"github.com/kscott5/fds/internal/tracing"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultMaxAttempts = 5
+	DefaultHttpTimeout = 10 * time.Second
+)
+
+// clients are created once per lambda container and reused across invocations
+var (
+	configOnce sync.Once
+	awsConfig  aws.Config
+
+	dynamodbOnce   sync.Once
+	dynamodbClient *dynamodb.Client
+	s3Once         sync.Once
+	s3Client       *s3.Client
+	lambdaOnce     sync.Once
+	lambdaClient   *lambda.Client
+)
+
+// LoadConfig loads the standard SDK config chain: environment, shared config and the lambda
+// execution role. Retries are adaptive with FDS_AWS_MAX_ATTEMPTS attempts, default 5, and
+// every http request times out after FDS_AWS_HTTP_TIMEOUT, default 10s. Operations are traced
+// and DynamoDB latency and throttles are metrics.
+func LoadConfig() aws.Config {
+	configOnce.Do(func() {
+		maxAttempts := DefaultMaxAttempts
+		if n, err := strconv.Atoi(os.Getenv("FDS_AWS_MAX_ATTEMPTS")); err == nil && n > 0 {
+			maxAttempts = n
+		}
+		timeout := DefaultHttpTimeout
+		if d, err := time.ParseDuration(os.Getenv("FDS_AWS_HTTP_TIMEOUT")); err == nil && d > 0 {
+			timeout = d
+		}
+
+		cfg, err := config.LoadDefaultConfig(context.Background(),
+			config.WithHTTPClient(awshttp.NewBuildableClient().WithTimeout(timeout)),
+			config.WithRetryer(func() aws.Retryer {
+				return retry.NewAdaptiveMode(func(options *retry.AdaptiveModeOptions) {
+					options.StandardOptions = append(options.StandardOptions, func(standard *retry.StandardOptions) {
+						standard.MaxAttempts = maxAttempts
+					})
+				})
+			}),
+		)
+		if err != nil {
+			// a malformed shared config or environment is not recoverable at cold start
+			logging.Logger().Fatal("aws config not available", zap.Error(err))
+		}
+		// every sdk operation is a span of the request trace
+		tracing.AppendMiddlewares(&cfg.APIOptions)
+		metrics.AppendMiddlewares(&cfg.APIOptions)
+		awsConfig = cfg
+	})
+	return awsConfig
+}
+
+// NewDynamodb returns the container's dynamodb client. AWS_ENDPOINT_URL_DYNAMODB, or
+// AWS_ENDPOINT_URL, overrides the endpoint for DynamoDB Local.
+func NewDynamodb() *dynamodb.Client {
+	dynamodbOnce.Do(func() {
+		dynamodbClient = dynamodb.NewFromConfig(LoadConfig(), func(options *dynamodb.Options) {
+			if endpoint := endpointFor("AWS_ENDPOINT_URL_DYNAMODB"); endpoint != "" {
+				options.BaseEndpoint = aws.String(endpoint)
+			}
+		})
+	})
+	return dynamodbClient
+}
+
+func NewS3() *s3.Client {
+	s3Once.Do(func() {
+		s3Client = s3.NewFromConfig(LoadConfig(), func(options *s3.Options) {
+			if endpoint := endpointFor("AWS_ENDPOINT_URL_S3"); endpoint != "" {
+				options.BaseEndpoint = aws.String(endpoint)
+				options.UsePathStyle = true
+			}
+		})
+	})
+	return s3Client
+}
+
+func NewLambda() *lambda.Client {
+	lambdaOnce.Do(func() {
+		lambdaClient = lambda.NewFromConfig(LoadConfig(), func(options *lambda.Options) {
+			if endpoint := endpointFor("AWS_ENDPOINT_URL_LAMBDA"); endpoint != "" {
+				options.BaseEndpoint = aws.String(endpoint)
+			}
+		})
+	})
+	return lambdaClient
+}
+
+func endpointFor(name string) string {
+	if endpoint := os.Getenv(name); endpoint != "" {
+		return endpoint
+	}
+	return os.Getenv("AWS_ENDPOINT_URL")
+}
@@ -1,20 +1,65 @@
 package client
 
 import (
-	"context"
+	"encoding/json"
 	"fmt"
-	"os"
-	"time"
+	"regexp"
+	"strings"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/kscott5/fds/internal/metrics"
+
+	"github.com/aws/aws-lambda-go/events"
 )
 
+// TraceIdHeader is the response header with the trace id of the request
+const TraceIdHeader = "x-fds-trace-id"
+
+var arrayIndex = regexp.MustCompile(`\[\d+\]`)
+
 var HttpResponseHeaders map[string]string = map[string]string{
 	"content-type":               "application/json",
 	"access-control-allow-orgin": "*",
 }
 
+// ErrorBody is the api error model returned with 4xx responses
+type ErrorBody struct {
+	StatusCode int         `json:"statuscode"`
+	Message    string      `json:"message"`
+	Violations []Violation `json:"violations,omitempty"`
+	TraceId    string      `json:"traceid,omitempty"`
+}
+
+// Violation is a request body field error. Path is the JSON path of the field, e.g. $.items[0].amount
+type Violation struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// NewErrorResponse returns a client error response. Lambda errors remain server errors.
+func NewErrorResponse(statusCode int, message string) *events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(ErrorBody{StatusCode: statusCode, Message: message})
+	return &events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers:    HttpResponseHeaders,
+		Body:       string(body),
+	}
+}
+
+// NewValidationResponse returns a 400 response listing every request body violation
+func NewValidationResponse(violations []Violation) *events.APIGatewayProxyResponse {
+	for _, violation := range violations {
+		// array indexes are removed to bound the field dimension, e.g. $.items[].amount
+		metrics.Count(metrics.ValidationFailures, metrics.Dim("Field", arrayIndex.ReplaceAllString(violation.Path, "[]")))
+	}
+
+	body, _ := json.Marshal(ErrorBody{StatusCode: 400, Message: "request body validation failed", Violations: violations})
+	return &events.APIGatewayProxyResponse{
+		StatusCode: 400,
+		Headers:    HttpResponseHeaders,
+		Body:       string(body),
+	}
+}
+
 func GetRequestKeyFrom(httpMethod, resource string) (string, error) {
 	if httpMethod != "" && resource != "" {
 		return fmt.Sprintf("%s %s", httpMethod, resource), nil
@@ -23,24 +68,31 @@ func GetRequestKeyFrom(httpMethod, resource string) (string, error) {
 	return "", fmt.Errorf("requires http method and resouce path")
 }
 
-// Local Credentials implements CredentialsProvider.Retrieve method
-type LocalCredentials aws.AnonymousCredentials
+// GetPrincipalIdFrom returns the Cognito sub of the caller from the request context authorizer.
+// Lambda token authorizers provide principalId and Cognito user pool authorizers provide claims.
+func GetPrincipalIdFrom(authorizer map[string]interface{}) (string, error) {
+	if principalId, ok := authorizer["principalId"].(string); ok && principalId != "" {
+		return principalId, nil
+	}
+
+	if claims, ok := authorizer["claims"].(map[string]interface{}); ok {
+		if sub, ok := claims["sub"].(string); ok && sub != "" {
+			return sub, nil
+		}
+	}
 
-func (local LocalCredentials) Retrieve(ctx context.Context) (aws.Credentials, error) {
-	return aws.Credentials{
-		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
-		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
-		Source:          os.Getenv("AWS_REGION"),
-		CanExpire:       false,
-		Expires:         time.Now().Add(time.Hour * 1),
-		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
-	}, nil // error
+	return "", fmt.Errorf("principal id not found with request context")
 }
 
-func NewDynamodb(tableName string) *dynamodb.Client {
-	cfg := aws.NewConfig()
-	return dynamodb.NewFromConfig(*cfg, func(options *dynamodb.Options) {
-		options.Region = os.Getenv("AWS_REGION")
-		options.Credentials = aws.NewCredentialsCache(LocalCredentials{})
-	})
-}
\ No newline at end of file
+// IsMemberOf reports whether the caller belongs to the Cognito group. The lambda token
+// authorizer provides the comma separated groups with the request context.
+func IsMemberOf(authorizer map[string]interface{}, group string) bool {
+	if groups, ok := authorizer["groups"].(string); ok && group != "" {
+		for _, name := range strings.Split(groups, ",") {
+			if name == group {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+// Package config loads the typed configuration of each lambda at cold start.
+//
+// Fields are read from struct tags:
+//
+//	UsersTable string `env:"FDS_APPS_USERS_TABLE" default:"FDSAppsUsers"`
+//	Bucket     string `env:"FDS_APPS_EXPORTS_BUCKET" required:"true"`
+//
+// Values are taken in order of precedence from the environment, the SSM parameters under
+// FDS_SSM_PATH, the json file FDS_CONFIG_FILE and the default tag. Parameter and file names
+// are the env names, e.g. /fds/dev/FDS_APPS_EXPORTS_BUCKET or {"FDS_APPS_EXPORTS_BUCKET": "..."}.
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/logging"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+const (
+	FileVariable    = "FDS_CONFIG_FILE"
+	SSMPathVariable = "FDS_SSM_PATH"
+)
+
+// MissingError lists every required value without a value
+type MissingError struct {
+	Names []string
+}
+
+func (e *MissingError) Error() string {
+	return fmt.Sprintf("config: missing required values %s", strings.Join(e.Names, ", "))
+}
+
+// Load populates the struct pointed to by v. Every missing required value is reported together.
+func Load(ctx context.Context, v interface{}) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Ptr || target.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config: load requires a struct pointer")
+	}
+
+	values := map[string]string{}
+	if file := os.Getenv(FileVariable); file != "" {
+		if err := readFile(file, values); err != nil {
+			return err
+		}
+	}
+	if parameterPath := os.Getenv(SSMPathVariable); parameterPath != "" {
+		if err := readParameters(ctx, parameterPath, values); err != nil {
+			return err
+		}
+	}
+
+	missing := []string{}
+	structType := target.Elem().Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name := field.Tag.Get("env")
+		if name == "" {
+			continue
+		}
+
+		value, found := os.LookupEnv(name)
+		if !found || value == "" {
+			value, found = values[name], values[name] != ""
+		}
+		if !found {
+			value, found = field.Tag.Lookup("default")
+		}
+		if !found || value == "" {
+			if field.Tag.Get("required") == "true" {
+				missing = append(missing, name)
+			}
+			continue
+		}
+
+		if err := set(target.Elem().Field(i), value); err != nil {
+			return fmt.Errorf("config: %s %w", name, err)
+		}
+	}
+
+	if len(missing) > 0 {
+		return &MissingError{Names: missing}
+	}
+	return nil
+}
+
+// MustLoad loads the config or stops the lambda with the error before the first invocation
+func MustLoad(v interface{}) {
+	if err := Load(context.Background(), v); err != nil {
+		logging.Logger().Fatal(err.Error())
+	}
+}
+
+func set(field reflect.Value, value string) error {
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(value)
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("requires true or false")
+		}
+		field.SetBool(b)
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("requires an integer")
+		}
+		field.SetInt(int64(n))
+	case float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("requires a number")
+		}
+		field.SetFloat(f)
+	case time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("requires a duration, e.g. 10s")
+		}
+		field.SetInt(int64(d))
+	case []string:
+		field.Set(reflect.ValueOf(strings.Split(value, ",")))
+	default:
+		return fmt.Errorf("type %s not available", field.Type())
+	}
+	return nil
+}
+
+func readFile(file string, values map[string]string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+
+	fileValues := map[string]string{}
+	if err := json.Unmarshal(data, &fileValues); err != nil {
+		return fmt.Errorf("config: %s requires a json object of string values: %w", file, err)
+	}
+	for name, value := range fileValues {
+		values[name] = value
+	}
+	return nil
+}
+
+func readParameters(ctx context.Context, parameterPath string, values map[string]string) error {
+	ssmClient := ssm.NewFromConfig(client.LoadConfig())
+	paginator := ssm.NewGetParametersByPathPaginator(ssmClient, &ssm.GetParametersByPathInput{
+		Path:           aws.String(parameterPath),
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(true),
+	})
+
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("config: ssm parameters %s: %w", parameterPath, err)
+		}
+		for _, parameter := range output.Parameters {
+			values[path.Base(aws.ToString(parameter.Name))] = aws.ToString(parameter.Value)
+		}
+	}
+	return nil
+}
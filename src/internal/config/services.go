@@ -0,0 +1,127 @@
+package config
+
+// Users is the users api lambda configuration
+type Users struct {
+	UsersTable       string `env:"FDS_APPS_USERS_TABLE" default:"FDSAppsUsers"`
+	OrdersTable      string `env:"FDS_APPS_ORDERS_TABLE" default:"FDSAppsOrders"`
+	AddressTable     string `env:"FDS_APPS_ADDRESS_TABLE" default:"FDSAppsAddress"`
+	FavoriteTable    string `env:"FDS_APPS_FAVORITE_TABLE" default:"FDSAppsFavorite"`
+	AuditTable       string `env:"FDS_APPS_AUDIT_TABLE" default:"FDSAppsAudit"`
+	ExportsTable     string `env:"FDS_APPS_EXPORTS_TABLE" default:"FDSAppsExports"`
+	ExportsBucket    string `env:"FDS_APPS_EXPORTS_BUCKET" required:"true"`
+	ExporterFunction string `env:"FDS_EXPORTER_FUNCTION" required:"true"`
+	EraserFunction   string `env:"FDS_ERASER_FUNCTION" required:"true"`
+	AdminGroupName   string `env:"FDS_ADMIN_GROUP_NAME" required:"true"`
+}
+
+// Exporter is the async user data export lambda configuration
+type Exporter struct {
+	UsersTable    string `env:"FDS_APPS_USERS_TABLE" default:"FDSAppsUsers"`
+	OrdersTable   string `env:"FDS_APPS_ORDERS_TABLE" default:"FDSAppsOrders"`
+	AddressTable  string `env:"FDS_APPS_ADDRESS_TABLE" default:"FDSAppsAddress"`
+	FavoriteTable string `env:"FDS_APPS_FAVORITE_TABLE" default:"FDSAppsFavorite"`
+	ExportsTable  string `env:"FDS_APPS_EXPORTS_TABLE" default:"FDSAppsExports"`
+	ExportsBucket string `env:"FDS_APPS_EXPORTS_BUCKET" required:"true"`
+}
+
+// Eraser is the async user erasure lambda configuration
+type Eraser struct {
+	UsersTable    string `env:"FDS_APPS_USERS_TABLE" default:"FDSAppsUsers"`
+	OrdersTable   string `env:"FDS_APPS_ORDERS_TABLE" default:"FDSAppsOrders"`
+	AddressTable  string `env:"FDS_APPS_ADDRESS_TABLE" default:"FDSAppsAddress"`
+	FavoriteTable string `env:"FDS_APPS_FAVORITE_TABLE" default:"FDSAppsFavorite"`
+	AuditTable    string `env:"FDS_APPS_AUDIT_TABLE" default:"FDSAppsAudit"`
+	ExportsTable  string `env:"FDS_APPS_EXPORTS_TABLE" default:"FDSAppsExports"`
+	ExportsBucket string `env:"FDS_APPS_EXPORTS_BUCKET" required:"true"`
+}
+
+// SignUp is the cognito post confirmation lambda configuration
+type SignUp struct {
+	UsersTable string `env:"FDS_APPS_USERS_TABLE" default:"FDSAppsUsers"`
+}
+
+// Orders is the orders api lambda configuration
+type Orders struct {
+	OrdersTable      string `env:"FDS_APPS_ORDERS_TABLE" default:"FDSAppsOrders"`
+	RestaurantsTable string `env:"FDS_APPS_RESTAURANTS_TABLE" default:"FDSAppsRestaurants"`
+	ZonesTable       string `env:"FDS_APPS_ZONES_TABLE" default:"FDSAppsZones"`
+	AdminGroupName   string `env:"FDS_ADMIN_GROUP_NAME" required:"true"`
+
+	// e-bike speed profile of delivery estimates
+	EtaSpeedKmh     float64 `env:"FDS_ETA_BIKE_SPEED_KMH" default:"15"`
+	EtaDetourFactor float64 `env:"FDS_ETA_DETOUR_FACTOR" default:"1.3"`
+
+	// order updates pushed to WebSocket subscribers. none when the endpoint is empty.
+	ConnectionsTable  string `env:"FDS_APPS_CONNECTIONS_TABLE" default:"FDSAppsConnections"`
+	WebSocketEndpoint string `env:"FDS_WEBSOCKET_ENDPOINT"`
+
+	// OrderPlaced events are written with the order
+	OutboxTable string `env:"FDS_APPS_OUTBOX_TABLE" default:"FDSAppsOutbox"`
+}
+
+// Riders is the riders api lambda configuration
+type Riders struct {
+	RidersTable      string `env:"FDS_APPS_RIDERS_TABLE" default:"FDSAppsRiders"`
+	LocationsTable   string `env:"FDS_APPS_RIDER_LOCATIONS_TABLE" default:"FDSAppsRiderLocations"`
+	OrdersTable      string `env:"FDS_APPS_ORDERS_TABLE" default:"FDSAppsOrders"`
+	RestaurantsTable string `env:"FDS_APPS_RESTAURANTS_TABLE" default:"FDSAppsRestaurants"`
+	OffersTable      string `env:"FDS_APPS_DISPATCH_OFFERS_TABLE" default:"FDSAppsDispatchOffers"`
+	AdminGroupName   string `env:"FDS_ADMIN_GROUP_NAME" required:"true"`
+
+	// e-bike speed profile of the delivery estimates updated with rider locations
+	EtaSpeedKmh     float64 `env:"FDS_ETA_BIKE_SPEED_KMH" default:"15"`
+	EtaDetourFactor float64 `env:"FDS_ETA_DETOUR_FACTOR" default:"1.3"`
+
+	// order updates pushed to WebSocket subscribers. none when the endpoint is empty.
+	ConnectionsTable  string `env:"FDS_APPS_CONNECTIONS_TABLE" default:"FDSAppsConnections"`
+	WebSocketEndpoint string `env:"FDS_WEBSOCKET_ENDPOINT"`
+}
+
+// Dispatcher is the order dispatch lambda configuration
+type Dispatcher struct {
+	OrdersTable      string `env:"FDS_APPS_ORDERS_TABLE" default:"FDSAppsOrders"`
+	RestaurantsTable string `env:"FDS_APPS_RESTAURANTS_TABLE" default:"FDSAppsRestaurants"`
+	RidersTable      string `env:"FDS_APPS_RIDERS_TABLE" default:"FDSAppsRiders"`
+	OffersTable      string `env:"FDS_APPS_DISPATCH_OFFERS_TABLE" default:"FDSAppsDispatchOffers"`
+}
+
+// Tracking is the WebSocket order tracking lambda configuration
+type Tracking struct {
+	OrdersTable      string `env:"FDS_APPS_ORDERS_TABLE" default:"FDSAppsOrders"`
+	ConnectionsTable string `env:"FDS_APPS_CONNECTIONS_TABLE" default:"FDSAppsConnections"`
+}
+
+// Streams is the orders table stream lambda configuration. Events are published to the bus,
+// the topic, or both.
+type Streams struct {
+	EventBusName string `env:"FDS_EVENT_BUS_NAME"`
+	TopicArn     string `env:"FDS_ORDER_EVENTS_TOPIC_ARN"`
+}
+
+// Relay is the outbox relay lambda configuration
+type Relay struct {
+	OutboxTable  string `env:"FDS_APPS_OUTBOX_TABLE" default:"FDSAppsOutbox"`
+	EventBusName string `env:"FDS_EVENT_BUS_NAME" required:"true"`
+}
+
+// Notifications is the order notifications lambda configuration. Provider is aws, stdout or
+// file; file appends the messages to File.
+type Notifications struct {
+	UsersTable       string `env:"FDS_APPS_USERS_TABLE" default:"FDSAppsUsers"`
+	RestaurantsTable string `env:"FDS_APPS_RESTAURANTS_TABLE" default:"FDSAppsRestaurants"`
+	Provider         string `env:"FDS_NOTIFICATIONS_PROVIDER" default:"aws"`
+	File             string `env:"FDS_NOTIFICATIONS_FILE" default:"notifications.jsonl"`
+	EmailFrom        string `env:"FDS_NOTIFICATIONS_EMAIL_FROM"`
+}
+
+// Authorizer is the lambda token authorizer configuration
+type Authorizer struct {
+	UserPoolId     string `env:"FDS_USER_POOL_ID" required:"true"`
+	AppClientId    string `env:"FDS_APPLICATION_CLIENT_ID" required:"true"`
+	AdminGroupName string `env:"FDS_ADMIN_GROUP_NAME" required:"true"`
+	RiderGroupName string `env:"FDS_RIDER_GROUP_NAME" default:"FDSAppsPoolRiders"`
+
+	// local json web key set file or url used instead of the user pool keys, e.g. written by
+	// fdsctl token mint -jwks. never set with deployed stacks.
+	JwksUrl string `env:"FDS_JWKS_URL"`
+}
@@ -0,0 +1,10 @@
+module github.com/kscott5/fds/internal/logging
+
+go 1.22.1
+
+require (
+	github.com/aws/aws-lambda-go v1.47.0
+	go.uber.org/zap v1.27.0
+)
+
+require go.uber.org/multierr v1.10.0 // indirect
@@ -0,0 +1,76 @@
+// Package logging provides the lambda container's json logger and the request scoped child
+// loggers carried by the handler context.
+//
+//	logger := logging.FromContext(ctx)
+//	logger.Info("order placed", zap.String("orderid", orderid))
+//
+// FDS_LOG_LEVEL sets the level: debug, info (default), warn or error.
+package logging
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const LevelVariable = "FDS_LOG_LEVEL"
+
+type contextKey struct{}
+
+var (
+	loggerOnce sync.Once
+	logger     *zap.Logger
+)
+
+// Logger returns the container's logger, created once at cold start
+func Logger() *zap.Logger {
+	loggerOnce.Do(func() {
+		level := zapcore.InfoLevel
+		if value := os.Getenv(LevelVariable); value != "" {
+			if err := level.UnmarshalText([]byte(value)); err != nil {
+				level = zapcore.InfoLevel
+			}
+		}
+
+		config := zap.NewProductionConfig()
+		config.Level = zap.NewAtomicLevelAt(level)
+		config.EncoderConfig.TimeKey = "time"
+		config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+
+		var err error
+		if logger, err = config.Build(); err != nil {
+			logger = zap.NewNop()
+		}
+		if lambdacontext.FunctionName != "" {
+			logger = logger.With(zap.String("function", lambdacontext.FunctionName))
+		}
+	})
+	return logger
+}
+
+// NewContext returns a copy of ctx carrying the logger
+func NewContext(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the request logger of ctx. Contexts without one get the container logger
+// with the lambda request id.
+func FromContext(ctx context.Context) *zap.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*zap.Logger); ok {
+		return logger
+	}
+	return WithLambda(Logger(), ctx)
+}
+
+// WithLambda adds the lambda request id of the invocation
+func WithLambda(logger *zap.Logger, ctx context.Context) *zap.Logger {
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		return logger.With(zap.String("lambdaRequestId", lc.AwsRequestID))
+	}
+	return logger
+}
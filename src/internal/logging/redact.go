@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"encoding/json"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const Redacted = "[REDACTED]"
+
+// SensitiveFields are the lowercase json names replaced with Redacted wherever they appear
+var SensitiveFields = map[string]bool{
+	"authorization":      true,
+	"authorizationtoken": true,
+	"token":              true,
+	"accesstoken":        true,
+	"access_token":       true,
+	"idtoken":            true,
+	"id_token":           true,
+	"refreshtoken":       true,
+	"refresh_token":      true,
+	"password":           true,
+	"fullname":           true,
+	"username":           true,
+	"email":              true,
+	"phone":              true,
+	"address":            true,
+	"addresses":          true,
+	"deliveryaddress":    true,
+	"line1":              true,
+	"line2":              true,
+	"street":             true,
+	"city":               true,
+	"zipcode":            true,
+	"postalcode":         true,
+}
+
+// Redact returns the json body with sensitive values replaced. Bodies that are not json are
+// replaced entirely because their fields can not be told apart.
+func Redact(body string) string {
+	if body == "" {
+		return body
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(body), &value); err != nil {
+		return Redacted
+	}
+
+	data, _ := json.Marshal(redact(value))
+	return string(data)
+}
+
+func redact(value interface{}) interface{} {
+	if object, ok := value.(map[string]interface{}); ok {
+		for name, property := range object {
+			if SensitiveFields[strings.ToLower(name)] {
+				object[name] = Redacted
+			} else {
+				object[name] = redact(property)
+			}
+		}
+	} else if array, ok := value.([]interface{}); ok {
+		for i := range array {
+			array[i] = redact(array[i])
+		}
+	}
+	return value
+}
+
+// Body is the redacted request or response body field
+func Body(body string) zap.Field {
+	return zap.String("body", Redact(body))
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// throttling error codes of the DynamoDB api
+var throttleCodes = map[string]bool{
+	"ProvisionedThroughputExceededException": true,
+	"ThrottlingException":                    true,
+	"RequestLimitExceeded":                   true,
+}
+
+// AppendMiddlewares emits the latency of every DynamoDB operation, retries included, and
+// the throttled attempts
+func AppendMiddlewares(apiOptions *[]func(*middleware.Stack) error) {
+	*apiOptions = append(*apiOptions, func(stack *middleware.Stack) error {
+		if err := stack.Initialize.Add(middleware.InitializeMiddlewareFunc("FDSMetricsLatency", measureOperation), middleware.After); err != nil {
+			return err
+		}
+		// after the retry middleware so every attempt is counted
+		return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("FDSMetricsThrottles", countThrottles), middleware.After)
+	})
+}
+
+func measureOperation(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	if awsmiddleware.GetServiceID(ctx) != "DynamoDB" {
+		return next.HandleInitialize(ctx, in)
+	}
+
+	start := time.Now()
+	ctx = middleware.WithStackValue(ctx, tableKey{}, tableNameOf(in.Parameters))
+	out, metadata, err := next.HandleInitialize(ctx, in)
+
+	Duration(DynamoDBLatency, time.Since(start), dimensionsOf(ctx)...)
+	return out, metadata, err
+}
+
+func countThrottles(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+	out, metadata, err := next.HandleFinalize(ctx, in)
+
+	var apiError smithy.APIError
+	if awsmiddleware.GetServiceID(ctx) == "DynamoDB" && errors.As(err, &apiError) && throttleCodes[apiError.ErrorCode()] {
+		Count(DynamoDBThrottles, dimensionsOf(ctx)...)
+	}
+	return out, metadata, err
+}
+
+type tableKey struct{}
+
+func dimensionsOf(ctx context.Context) []Dimension {
+	table, _ := middleware.GetStackValue(ctx, tableKey{}).(string)
+	return []Dimension{Dim("Operation", awsmiddleware.GetOperationName(ctx)), Dim("TableName", table)}
+}
+
+// tableNameOf returns the TableName input parameter of DynamoDB operations
+func tableNameOf(parameters interface{}) string {
+	value := reflect.ValueOf(parameters)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return ""
+	}
+
+	if field := value.Elem().FieldByName("TableName"); field.IsValid() && field.Kind() == reflect.Ptr && !field.IsNil() {
+		if name, ok := field.Elem().Interface().(string); ok {
+			return name
+		}
+	}
+	return ""
+}
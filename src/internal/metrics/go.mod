@@ -0,0 +1,9 @@
+module github.com/kscott5/fds/internal/metrics
+
+go 1.22.1
+
+require (
+	github.com/aws/aws-lambda-go v1.47.0
+	github.com/aws/aws-sdk-go-v2 v1.26.2
+	github.com/aws/smithy-go v1.20.2
+)
@@ -0,0 +1,132 @@
+// Package metrics emits business metrics as CloudWatch Embedded Metric Format (EMF) json lines.
+// CloudWatch Logs extracts the metrics from the lambda output, no PutMetricData calls are made.
+//
+//	metrics.Count(metrics.OrdersCreated, metrics.Dim("RestaurantId", order.RestaurantId))
+//
+// Lines are written to stdout within a lambda and discarded elsewhere, e.g. fdsctl, unless
+// SetSink provides another sink. FDS_METRICS_NAMESPACE sets the namespace, default FDSApps.
+// https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/CloudWatch_Embedded_Metric_Format_Specification.html
+package metrics
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+const (
+	DefaultNamespace  = "FDSApps"
+	NamespaceVariable = "FDS_METRICS_NAMESPACE"
+)
+
+// metric names
+const (
+	OrdersCreated      = "OrdersCreated"
+	OrdersModified     = "OrdersModified"
+	OrdersCancelled    = "OrdersCancelled"
+	OrdersOutOfZone    = "OrdersOutOfZone"
+	ValidationFailures = "ValidationFailures"
+	AuthorizerDecision = "AuthorizerDecision"
+	DynamoDBLatency    = "DynamoDBLatency"
+	DynamoDBThrottles  = "DynamoDBThrottles"
+	DispatchOffers     = "DispatchOffers"
+	PushMessages       = "PushMessages"
+	OrderEvents        = "OrderEvents"
+	OutboxRecords      = "OutboxRecords"
+	Notifications      = "Notifications"
+)
+
+type Unit string
+
+const (
+	UnitCount        Unit = "Count"
+	UnitMilliseconds Unit = "Milliseconds"
+)
+
+// Dimension is a metric dimension. CloudWatch bills every distinct dimension value as a metric,
+// so values must have a small cardinality: restaurant ids, never user or order ids.
+type Dimension struct {
+	Name  string
+	Value string
+}
+
+func Dim(name, value string) Dimension {
+	return Dimension{Name: name, Value: value}
+}
+
+var (
+	sinkMutex sync.RWMutex
+	sink      Sink = defaultSink()
+)
+
+func defaultSink() Sink {
+	if lambdacontext.FunctionName != "" {
+		return &WriterSink{Writer: os.Stdout}
+	}
+	return DiscardSink{}
+}
+
+// SetSink replaces the sink of every metric and returns the previous sink
+func SetSink(s Sink) Sink {
+	sinkMutex.Lock()
+	defer sinkMutex.Unlock()
+
+	previous := sink
+	sink = s
+	return previous
+}
+
+func namespace() string {
+	if value := os.Getenv(NamespaceVariable); value != "" {
+		return value
+	}
+	return DefaultNamespace
+}
+
+// Put emits one metric value with the dimensions
+func Put(name string, value float64, unit Unit, dimensions ...Dimension) error {
+	names := []string{}
+	line := map[string]interface{}{}
+	for _, dimension := range dimensions {
+		if dimension.Value == "" {
+			dimension.Value = "none"
+		}
+		names = append(names, dimension.Name)
+		line[dimension.Name] = dimension.Value
+	}
+	if lambdacontext.FunctionName != "" {
+		line["function"] = lambdacontext.FunctionName
+	}
+
+	line[name] = value
+	line["_aws"] = map[string]interface{}{
+		"Timestamp": time.Now().UnixMilli(),
+		"CloudWatchMetrics": []map[string]interface{}{{
+			"Namespace":  namespace(),
+			"Dimensions": [][]string{names},
+			"Metrics":    []map[string]string{{"Name": name, "Unit": string(unit)}},
+		}},
+	}
+
+	data, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+
+	sinkMutex.RLock()
+	defer sinkMutex.RUnlock()
+	return sink.Write(data)
+}
+
+// Count emits a count of one
+func Count(name string, dimensions ...Dimension) {
+	Put(name, 1, UnitCount, dimensions...)
+}
+
+// Duration emits the elapsed time in milliseconds
+func Duration(name string, elapsed time.Duration, dimensions ...Dimension) {
+	Put(name, float64(elapsed.Microseconds())/1000, UnitMilliseconds, dimensions...)
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPut(t *testing.T) {
+	tests := []struct {
+		name   string
+		emit   func()
+		metric string
+		values []Value
+	}{
+		{"count", func() { Count(OrdersCreated, Dim("RestaurantId", "r-1")) }, OrdersCreated,
+			[]Value{{Value: 1, Dimensions: map[string]string{"RestaurantId": "r-1"}}}},
+		{"empty dimension", func() { Count(ValidationFailures, Dim("Field", "")) }, ValidationFailures,
+			[]Value{{Value: 1, Dimensions: map[string]string{"Field": "none"}}}},
+		{"duration", func() { Duration(DynamoDBLatency, 1500*time.Microsecond, Dim("Operation", "GetItem")) }, DynamoDBLatency,
+			[]Value{{Value: 1.5, Dimensions: map[string]string{"Operation": "GetItem"}}}},
+		{"no dimensions", func() { Put(PushMessages, 3, UnitCount) }, PushMessages,
+			[]Value{{Value: 3, Dimensions: map[string]string{}}}},
+		{"each value", func() {
+			Count(OrdersCancelled, Dim("RestaurantId", "r-1"))
+			Count(OrdersCancelled, Dim("RestaurantId", "r-2"))
+		}, OrdersCancelled, []Value{
+			{Value: 1, Dimensions: map[string]string{"RestaurantId": "r-1"}},
+			{Value: 1, Dimensions: map[string]string{"RestaurantId": "r-2"}},
+		}},
+		{"other metric", func() { Count(OrdersModified) }, OrdersCreated, []Value{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			memory := &MemorySink{}
+			previous := SetSink(memory)
+			defer SetSink(previous)
+
+			test.emit()
+			if values := memory.Values(test.metric); !reflect.DeepEqual(values, test.values) {
+				t.Errorf("Values(%s) = %v, want %v", test.metric, values, test.values)
+			}
+		})
+	}
+}
+
+func TestPutEmbeddedMetricFormat(t *testing.T) {
+	t.Setenv(NamespaceVariable, "FDSTest")
+
+	memory := &MemorySink{}
+	previous := SetSink(memory)
+	defer SetSink(previous)
+
+	Put(OutboxRecords, 2, UnitCount, Dim("Result", "Published"), Dim("Type", "OrderPlaced"))
+	if len(memory.lines) != 1 {
+		t.Fatalf("lines = %d, want 1", len(memory.lines))
+	}
+
+	line := struct {
+		Result string  `json:"Result"`
+		Type   string  `json:"Type"`
+		Value  float64 `json:"OutboxRecords"`
+		AWS    struct {
+			Timestamp         int64
+			CloudWatchMetrics []struct {
+				Namespace  string
+				Dimensions [][]string
+				Metrics    []map[string]string
+			}
+		} `json:"_aws"`
+	}{}
+	if err := json.Unmarshal(memory.lines[0], &line); err != nil {
+		t.Fatal(err)
+	}
+
+	directives := line.AWS.CloudWatchMetrics
+	if line.Result != "Published" || line.Type != "OrderPlaced" || line.Value != 2 {
+		t.Errorf("line = %s", memory.lines[0])
+	} else if line.AWS.Timestamp == 0 || len(directives) != 1 {
+		t.Fatalf("_aws = %+v", line.AWS)
+	} else if directives[0].Namespace != "FDSTest" {
+		t.Errorf("namespace = %s, want FDSTest", directives[0].Namespace)
+	} else if !reflect.DeepEqual(directives[0].Dimensions, [][]string{{"Result", "Type"}}) {
+		t.Errorf("dimensions = %v", directives[0].Dimensions)
+	} else if !reflect.DeepEqual(directives[0].Metrics, []map[string]string{{"Name": OutboxRecords, "Unit": "Count"}}) {
+		t.Errorf("metrics = %v", directives[0].Metrics)
+	}
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// Sink receives one EMF json line per metric
+type Sink interface {
+	Write(line []byte) error
+}
+
+// WriterSink writes newline terminated lines, e.g. to stdout within a lambda
+type WriterSink struct {
+	Writer io.Writer
+
+	mutex sync.Mutex
+}
+
+func (s *WriterSink) Write(line []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, err := s.Writer.Write(append(line, '\n'))
+	return err
+}
+
+// DiscardSink drops every line
+type DiscardSink struct{}
+
+func (DiscardSink) Write(line []byte) error {
+	return nil
+}
+
+// MemorySink keeps the lines so tests can assert on the emitted metrics without AWS
+type MemorySink struct {
+	mutex sync.Mutex
+	lines [][]byte
+}
+
+func (s *MemorySink) Write(line []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.lines = append(s.lines, append([]byte{}, line...))
+	return nil
+}
+
+// Values returns the emitted values of the metric with the dimensions of each line
+func (s *MemorySink) Values(name string) []Value {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	values := []Value{}
+	for _, line := range s.lines {
+		emf := struct {
+			AWS struct {
+				CloudWatchMetrics []struct {
+					Dimensions [][]string
+				}
+			} `json:"_aws"`
+		}{}
+		fields := map[string]interface{}{}
+		if json.Unmarshal(line, &emf) != nil || json.Unmarshal(line, &fields) != nil {
+			continue
+		}
+
+		value, ok := fields[name].(float64)
+		if !ok || len(emf.AWS.CloudWatchMetrics) == 0 {
+			continue
+		}
+
+		dimensions := map[string]string{}
+		for _, names := range emf.AWS.CloudWatchMetrics[0].Dimensions {
+			for _, dimension := range names {
+				dimensions[dimension], _ = fields[dimension].(string)
+			}
+		}
+		values = append(values, Value{Value: value, Dimensions: dimensions})
+	}
+	return values
+}
+
+// Value is an emitted metric value
+type Value struct {
+	Value      float64
+	Dimensions map[string]string
+}
@@ -0,0 +1,133 @@
+// Package notify holds the notification preferences of users and restaurants and delivers
+// rendered messages with a Provider. Recipients opt in per channel; messages are never sent
+// to channels without an opt in and an address.
+//
+//	provider := notify.Channels{notify.Email: ses, notify.SMS: sms, notify.Push: push}
+//	provider.Send(ctx, notify.Message{Channel: notify.Email, To: prefs.Email, ...})
+//
+// Writer and File are the development providers.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/kscott5/fds/internal/schema"
+)
+
+type Channel string
+
+const (
+	Email Channel = "email"
+	SMS   Channel = "sms"
+	Push  Channel = "push"
+
+	// DefaultLocale is used without a locale or templates of the locale
+	DefaultLocale = "en"
+)
+
+var AllChannels = []Channel{Email, SMS, Push}
+
+// Preferences are stored with the user profile and the restaurant record
+type Preferences struct {
+	Locale   string    `json:"locale,omitempty" dynamodbav:"locale,omitempty"` // BCP 47, e.g. es-MX
+	Channels []Channel `json:"channels" dynamodbav:"channels"`                 // opted in channels
+	Email    string    `json:"email,omitempty" dynamodbav:"email,omitempty"`
+	Phone    string    `json:"phone,omitempty" dynamodbav:"phone,omitempty"` // E.164, e.g. +12065550100
+	// SNS platform endpoint arn of the mobile device
+	PushEndpoint string `json:"pushendpoint,omitempty" dynamodbav:"pushendpoint,omitempty"`
+}
+
+// PreferencesSchema validates notification preferences
+var PreferencesSchema = schema.Object(map[string]*schema.Field{
+	"locale":       schema.String().MaxLength(35).Pattern(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`),
+	"channels":     schema.Array(schema.String().Enum(string(Email), string(SMS), string(Push))).Required().MaxItems(3),
+	"email":        schema.String().Format(schema.Email).MaxLength(254),
+	"phone":        schema.String().Pattern(`^\+[1-9][0-9]{6,14}$`),
+	"pushendpoint": schema.String().MaxLength(256).Pattern(`^arn:aws[a-z-]*:sns:`),
+})
+
+// Address returns the recipient address of the channel, empty without an opt in
+func (p *Preferences) Address(channel Channel) string {
+	if p == nil || !slices.Contains(p.Channels, channel) {
+		return ""
+	}
+
+	switch channel {
+	case Email:
+		return p.Email
+	case SMS:
+		return p.Phone
+	case Push:
+		return p.PushEndpoint
+	default:
+		return ""
+	}
+}
+
+// LocaleOf returns the locale of the preferences or DefaultLocale
+func (p *Preferences) LocaleOf() string {
+	if p == nil || p.Locale == "" {
+		return DefaultLocale
+	}
+	return p.Locale
+}
+
+// Message is a rendered notification. Id is the same for redelivered events so providers
+// with idempotency keys drop duplicates.
+type Message struct {
+	Id      string  `json:"id"`
+	Channel Channel `json:"channel"`
+	To      string  `json:"to"`
+	Locale  string  `json:"locale"`
+	Subject string  `json:"subject,omitempty"` // email subject and push title
+	Body    string  `json:"body"`
+}
+
+// Provider delivers messages
+type Provider interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// Channels sends with the provider of the message channel
+type Channels map[Channel]Provider
+
+func (c Channels) Send(ctx context.Context, message Message) error {
+	provider, found := c[message.Channel]
+	if !found {
+		return fmt.Errorf("notification channel %s not available", message.Channel)
+	}
+	return provider.Send(ctx, message)
+}
+
+// Writer writes messages as json lines, e.g. to stdout
+type Writer struct {
+	W io.Writer
+}
+
+func (w Writer) Send(ctx context.Context, message Message) error {
+	return json.NewEncoder(w.W).Encode(message)
+}
+
+// File appends messages as json lines to the file at Path
+type File struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (f *File) Send(ctx context.Context, message Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return Writer{W: file}.Send(ctx, message)
+}
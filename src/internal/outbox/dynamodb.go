@@ -0,0 +1,70 @@
+package outbox
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Put returns the transaction item of the record. The write fails when the record
+// exists, so a retried request does not publish the event twice.
+func Put(tableName string, record Record) (types.TransactWriteItem, error) {
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		return types.TransactWriteItem{}, err
+	}
+
+	return types.TransactWriteItem{Put: &types.Put{
+		TableName:           aws.String(tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(outboxid)"),
+	}}, nil
+}
+
+// Table is the outbox table of the relay
+type Table struct {
+	DDB       *dynamodb.Client
+	TableName string
+}
+
+// Pending returns the oldest unpublished records
+func (t Table) Pending(ctx context.Context, limit int32) ([]Record, error) {
+	output, err := t.DDB.Query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(t.TableName),
+		IndexName:                 aws.String(PendingIndex),
+		KeyConditionExpression:    aws.String("#pending = :pending"),
+		ExpressionAttributeNames:  map[string]string{"#pending": "pending"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":pending": &types.AttributeValueMemberS{Value: pending}},
+		Limit:                     aws.Int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	records := []Record{}
+	if err := attributevalue.UnmarshalListOfMaps(output.Items, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Published removes the record from the pending index. It expires after PublishedTTL.
+func (t Table) Published(ctx context.Context, outboxid string, now time.Time) error {
+	_, err := t.DDB.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                aws.String(t.TableName),
+		Key:                      map[string]types.AttributeValue{"outboxid": &types.AttributeValueMemberS{Value: outboxid}},
+		UpdateExpression:         aws.String("SET publishedon = :publishedon, expiresat = :expiresat REMOVE #pending"),
+		ConditionExpression:      aws.String("attribute_exists(outboxid)"),
+		ExpressionAttributeNames: map[string]string{"#pending": "pending"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":publishedon": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.UnixMilli(), 10)},
+			":expiresat":   &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Add(PublishedTTL).Unix(), 10)},
+		},
+	})
+	return err
+}
@@ -0,0 +1,46 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+)
+
+// maximum entries of an EventBridge PutEvents request
+const putEventsLimit = 10
+
+// EventBridge publishes to the event bus. The event type is the detail type so rules match
+// with {"source": ["fds.orders"], "detail-type": ["OrderPlaced"]}.
+type EventBridge struct {
+	Client  *eventbridge.Client
+	BusName string
+}
+
+func (eb EventBridge) Publish(ctx context.Context, records ...Record) error {
+	for start := 0; start < len(records); start += putEventsLimit {
+		end := min(start+putEventsLimit, len(records))
+
+		entries := []types.PutEventsRequestEntry{}
+		for _, record := range records[start:end] {
+			entries = append(entries, types.PutEventsRequestEntry{
+				EventBusName: aws.String(eb.BusName),
+				Source:       aws.String(record.Source),
+				DetailType:   aws.String(record.Type),
+				Detail:       aws.String(record.Detail),
+				Time:         aws.Time(time.UnixMilli(record.OccurredOn)),
+			})
+		}
+
+		output, err := eb.Client.PutEvents(ctx, &eventbridge.PutEventsInput{Entries: entries})
+		if err != nil {
+			return err
+		} else if output.FailedEntryCount > 0 {
+			return fmt.Errorf("event bus %s: %d of %d events failed", eb.BusName, output.FailedEntryCount, len(entries))
+		}
+	}
+	return nil
+}
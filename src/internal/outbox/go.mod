@@ -0,0 +1,26 @@
+module github.com/kscott5/fds/internal/outbox
+
+go 1.22.1
+
+require (
+	github.com/aws/aws-sdk-go-v2 v1.26.2
+	github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue v1.13.16
+	github.com/aws/aws-sdk-go-v2/service/dynamodb v1.32.2
+	github.com/aws/aws-sdk-go-v2/service/eventbridge v1.31.1
+	github.com/google/uuid v1.6.0
+	github.com/kscott5/fds/internal/metrics v0.0.0-00010101000000-000000000000
+)
+
+require (
+	github.com/aws/aws-lambda-go v1.47.0 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/configsources v1.3.6 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/endpoints/v2 v2.6.6 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/v4a v1.3.6 // indirect
+	github.com/aws/aws-sdk-go-v2/service/dynamodbstreams v1.20.6 // indirect
+	github.com/aws/aws-sdk-go-v2/service/internal/accept-encoding v1.11.2 // indirect
+	github.com/aws/aws-sdk-go-v2/service/internal/endpoint-discovery v1.9.7 // indirect
+	github.com/aws/smithy-go v1.20.2 // indirect
+	github.com/jmespath/go-jmespath v0.4.0 // indirect
+)
+
+replace github.com/kscott5/fds/internal/metrics => ../metrics/
@@ -0,0 +1,100 @@
+// Package outbox publishes domain events with the write that causes them. The event is
+// written as an outbox record in the same TransactWriteItems as the item, and a Relay
+// drains the pending records to the event bus. Delivery is at least once: a record is
+// published again when the relay stops before marking it, so consumers deduplicate with
+// the event id.
+//
+//	record, _ := outbox.New(event, now)
+//	put, _ := outbox.Put(tableName, record)
+//	ddb.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: []types.TransactWriteItem{order, put}})
+//
+// Records of every service, e.g. orders, users and restaurants, share the outbox table.
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultTable = "FDSAppsOutbox"
+
+	// PendingIndex is the sparse outbox table index of unpublished records, oldest first
+	PendingIndex = "pending-index"
+	pending      = "pending"
+
+	// published records are kept for troubleshooting
+	PublishedTTL = 7 * 24 * time.Hour
+)
+
+// Envelope is the versioned header of every domain event. Id is the deduplication id;
+// the same change always has the same id, see EventId.
+type Envelope struct {
+	Id         string `json:"id"`
+	Type       string `json:"type"`
+	Version    int    `json:"version"`
+	Source     string `json:"source"`
+	OccurredOn int64  `json:"occurredon"` // unix milliseconds
+	Actor      string `json:"actor,omitempty"`
+}
+
+func (e Envelope) Meta() Envelope {
+	return e
+}
+
+// Event is a domain event. Events embed the Envelope.
+type Event interface {
+	Meta() Envelope
+}
+
+// namespace of the event ids
+var eventNamespace = uuid.MustParse("6b0e3c86-6f4e-4d53-9a0e-5b2f62f4c2d1")
+
+// EventId returns the deduplication id of the event type of the subject, e.g. an order id,
+// at the time of the change
+func EventId(source, subject, eventType string, occurredOn int64) string {
+	return uuid.NewSHA1(eventNamespace, []byte(fmt.Sprintf("%s/%s/%s/%d", source, subject, eventType, occurredOn))).String()
+}
+
+// Record is the outbox table item of an event. Pending is removed once published, which
+// removes the record from the pending index.
+type Record struct {
+	OutboxId    string `json:"outboxid" dynamodbav:"outboxid"`
+	Source      string `json:"source" dynamodbav:"source"`
+	Type        string `json:"type" dynamodbav:"type"`
+	Version     int    `json:"version" dynamodbav:"version"`
+	OccurredOn  int64  `json:"occurredon" dynamodbav:"occurredon"`
+	Detail      string `json:"detail" dynamodbav:"detail"` // event json
+	Pending     string `json:"pending,omitempty" dynamodbav:"pending,omitempty"`
+	CreatedOn   int64  `json:"createdon" dynamodbav:"createdon"`
+	PublishedOn int64  `json:"publishedon,omitempty" dynamodbav:"publishedon,omitempty"`
+	ExpiresAt   int64  `json:"-" dynamodbav:"expiresat,omitempty"` // dynamodb ttl in seconds
+}
+
+// New returns the pending record of the event. The record id is the event id.
+func New(event Event, now time.Time) (Record, error) {
+	meta := event.Meta()
+	if meta.Id == "" || meta.Type == "" || meta.Source == "" {
+		requires := map[string]string{"id": "string", "type": "string", "source": "string"}
+		return Record{}, fmt.Errorf("requires: %s", requires)
+	}
+
+	detail, err := json.Marshal(event)
+	if err != nil {
+		return Record{}, err
+	}
+
+	return Record{
+		OutboxId:   meta.Id,
+		Source:     meta.Source,
+		Type:       meta.Type,
+		Version:    meta.Version,
+		OccurredOn: meta.OccurredOn,
+		Detail:     string(detail),
+		Pending:    pending,
+		CreatedOn:  now.UnixMilli(),
+	}, nil
+}
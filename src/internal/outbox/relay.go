@@ -0,0 +1,70 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/kscott5/fds/internal/metrics"
+)
+
+// Store is the outbox of the relay
+type Store interface {
+	Pending(ctx context.Context, limit int32) ([]Record, error)
+	Published(ctx context.Context, outboxid string, now time.Time) error
+}
+
+// Publisher sends records to the consumers, in order
+type Publisher interface {
+	Publish(ctx context.Context, records ...Record) error
+}
+
+// Relay drains the pending records in batches of BatchSize, default 10
+type Relay struct {
+	Store     Store
+	Publisher Publisher
+	BatchSize int32
+}
+
+// Drain publishes the pending records until none remain and returns the published count.
+// A failed batch stops the drain; its records stay pending for the next one. The pending
+// index is eventually consistent, so records published by this drain are skipped and a
+// batch of only those ends it.
+func (r Relay) Drain(ctx context.Context, now func() time.Time) (int, error) {
+	size := r.BatchSize
+	if size <= 0 {
+		size = putEventsLimit
+	}
+
+	published := 0
+	seen := map[string]bool{}
+	for {
+		batch, err := r.Store.Pending(ctx, size)
+		if err != nil {
+			return published, err
+		}
+
+		records := []Record{}
+		for _, record := range batch {
+			if !seen[record.OutboxId] {
+				records = append(records, record)
+			}
+		}
+		if len(records) == 0 {
+			return published, nil
+		}
+
+		if err := r.Publisher.Publish(ctx, records...); err != nil {
+			metrics.Count(metrics.OutboxRecords, metrics.Dim("Result", "Failed"))
+			return published, err
+		}
+
+		for _, record := range records {
+			if err := r.Store.Published(ctx, record.OutboxId, now()); err != nil {
+				return published, err
+			}
+			seen[record.OutboxId] = true
+			published++
+			metrics.Count(metrics.OutboxRecords, metrics.Dim("Result", "Published"), metrics.Dim("Type", record.Type))
+		}
+	}
+}
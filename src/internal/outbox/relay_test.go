@@ -0,0 +1,116 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeStore is an outbox of records, oldest first. A stale pending index still returns
+// published records.
+type fakeStore struct {
+	records   []Record
+	published map[string]bool
+	stale     bool
+}
+
+func newFakeStore(count int, stale bool) *fakeStore {
+	store := fakeStore{published: map[string]bool{}, stale: stale}
+	for i := 1; i <= count; i++ {
+		store.records = append(store.records, Record{OutboxId: fmt.Sprintf("r-%d", i), Type: "order.placed"})
+	}
+	return &store
+}
+
+func (s *fakeStore) Pending(ctx context.Context, limit int32) ([]Record, error) {
+	pending := []Record{}
+	for _, record := range s.records {
+		if int32(len(pending)) < limit && (s.stale || !s.published[record.OutboxId]) {
+			pending = append(pending, record)
+		}
+	}
+	return pending, nil
+}
+
+func (s *fakeStore) Published(ctx context.Context, outboxid string, now time.Time) error {
+	s.published[outboxid] = true
+	return nil
+}
+
+func (s *fakeStore) pendingIds() []string {
+	ids := []string{}
+	for _, record := range s.records {
+		if !s.published[record.OutboxId] {
+			ids = append(ids, record.OutboxId)
+		}
+	}
+	return ids
+}
+
+// fakePublisher fails the publish call failAt, counted from one
+type fakePublisher struct {
+	batches [][]string
+	failAt  int
+	calls   int
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, records ...Record) error {
+	p.calls++
+	if p.calls == p.failAt {
+		return errors.New("event bus unavailable")
+	}
+
+	ids := []string{}
+	for _, record := range records {
+		ids = append(ids, record.OutboxId)
+	}
+	p.batches = append(p.batches, ids)
+	return nil
+}
+
+func TestDrain(t *testing.T) {
+	tests := []struct {
+		name      string
+		records   int
+		batchSize int32
+		stale     bool
+		failAt    int
+		published int
+		batches   [][]string
+		pending   []string
+		failed    bool
+	}{
+		{"batches", 5, 2, false, 0, 5, [][]string{{"r-1", "r-2"}, {"r-3", "r-4"}, {"r-5"}}, []string{}, false},
+		{"default batch size", 12, 0, false, 0, 12, [][]string{
+			{"r-1", "r-2", "r-3", "r-4", "r-5", "r-6", "r-7", "r-8", "r-9", "r-10"}, {"r-11", "r-12"}}, []string{}, false},
+		{"no pending records", 0, 2, false, 0, 0, nil, []string{}, false},
+		{"publish failure", 3, 2, false, 2, 2, [][]string{{"r-1", "r-2"}}, []string{"r-3"}, true},
+		{"first publish failure", 3, 2, false, 1, 0, nil, []string{"r-1", "r-2", "r-3"}, true},
+		{"stale pending index", 2, 2, true, 0, 2, [][]string{{"r-1", "r-2"}}, []string{}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := newFakeStore(test.records, test.stale)
+			publisher := &fakePublisher{failAt: test.failAt}
+			relay := Relay{Store: store, Publisher: publisher, BatchSize: test.batchSize}
+
+			published, err := relay.Drain(context.Background(), time.Now)
+			if (err != nil) != test.failed {
+				t.Errorf("Drain error = %v, want failed %t", err, test.failed)
+			}
+			if published != test.published {
+				t.Errorf("published = %d, want %d", published, test.published)
+			}
+			if !reflect.DeepEqual(publisher.batches, test.batches) {
+				t.Errorf("batches = %v, want %v", publisher.batches, test.batches)
+			}
+			if pending := store.pendingIds(); !reflect.DeepEqual(pending, test.pending) {
+				t.Errorf("pending = %v, want %v", pending, test.pending)
+			}
+		})
+	}
+}
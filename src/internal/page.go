@@ -0,0 +1,69 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// NextTokenHeader carries the opaque token for the next page of a list response.
+// The header is missing on the last page.
+const NextTokenHeader = "x-fds-next-token"
+
+const (
+	DefaultPageLimit = 100
+	MaxPageLimit     = 1000
+)
+
+// GetPageFrom reads the ?limit= and ?next= query string parameters
+func GetPageFrom(query map[string]string) (int32, map[string]types.AttributeValue, error) {
+	limit := DefaultPageLimit
+	if value, found := query["limit"]; found {
+		if n, err := strconv.Atoi(value); err != nil || n < 1 || n > MaxPageLimit {
+			return 0, nil, fmt.Errorf("limit requires a number between 1 and %d", MaxPageLimit)
+		} else {
+			limit = n
+		}
+	}
+
+	token := query["next"]
+	if token == "" {
+		return int32(limit), nil, nil
+	}
+
+	key := map[string]interface{}{}
+	if data, err := base64.RawURLEncoding.DecodeString(token); err != nil {
+		return 0, nil, fmt.Errorf("next token not valid")
+	} else if err := json.Unmarshal(data, &key); err != nil {
+		return 0, nil, fmt.Errorf("next token not valid")
+	} else if startKey, err := attributevalue.MarshalMap(key); err != nil {
+		return 0, nil, err
+	} else {
+		return int32(limit), startKey, nil
+	}
+}
+
+// NewPageHeaders returns the response headers with the next token for the last evaluated key
+func NewPageHeaders(lastEvaluatedKey map[string]types.AttributeValue) (map[string]string, error) {
+	headers := map[string]string{}
+	for name, value := range HttpResponseHeaders {
+		headers[name] = value
+	}
+	if len(lastEvaluatedKey) == 0 {
+		return headers, nil
+	}
+
+	key := map[string]interface{}{}
+	if err := attributevalue.UnmarshalMap(lastEvaluatedKey, &key); err != nil {
+		return nil, err
+	} else if data, err := json.Marshal(key); err != nil {
+		return nil, err
+	} else {
+		headers[NextTokenHeader] = base64.RawURLEncoding.EncodeToString(data)
+		return headers, nil
+	}
+}
@@ -0,0 +1,76 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// client actions. API Gateway selects the route with $request.body.action.
+const (
+	SubscribeAction   = "subscribe"
+	UnsubscribeAction = "unsubscribe"
+)
+
+// Action is a client message
+//
+//	{"action": "subscribe", "orderid": "..."}
+type Action struct {
+	Action  string `json:"action"`
+	OrderId string `json:"orderid"`
+}
+
+// Reply is returned to the client after an action
+type Reply struct {
+	Type    string `json:"type"` // subscribed, unsubscribed or error
+	OrderId string `json:"orderid,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Owns reports whether the order belongs to the customer
+type Owns func(ctx context.Context, userid, orderid string) (bool, error)
+
+func errorReply(format string, args ...interface{}) Reply {
+	return Reply{Type: "error", Message: fmt.Sprintf(format, args...)}
+}
+
+// Handle runs the action of a connection message. Customers subscribe to their own orders.
+// Errors are store or owns failures; invalid actions are error replies.
+func Handle(ctx context.Context, store Store, owns Owns, connectionid, userid string, body []byte, now time.Time) (Reply, error) {
+	action := Action{}
+	requires := map[string]string{"action": "subscribe or unsubscribe", "orderid": "string"}
+	if err := json.Unmarshal(body, &action); err != nil || action.OrderId == "" {
+		return errorReply("requires: %s", requires), nil
+	}
+
+	switch action.Action {
+	case SubscribeAction:
+		if userid == "" {
+			return errorReply("subscriptions require an authorized connection"), nil
+		} else if owned, err := owns(ctx, userid, action.OrderId); err != nil {
+			return Reply{}, err
+		} else if !owned {
+			return errorReply("order %s not found", action.OrderId), nil
+		}
+
+		subscription := Subscription{
+			OrderId:      action.OrderId,
+			ConnectionId: connectionid,
+			UserId:       userid,
+			SubscribedOn: now.UnixMilli(),
+			ExpiresAt:    now.Add(SubscriptionTTL).Unix(),
+		}
+		if err := store.Subscribe(ctx, subscription); err != nil {
+			return Reply{}, err
+		}
+		return Reply{Type: "subscribed", OrderId: action.OrderId}, nil
+	case UnsubscribeAction:
+		if err := store.Unsubscribe(ctx, connectionid, action.OrderId); err != nil {
+			return Reply{}, err
+		}
+		return Reply{Type: "unsubscribed", OrderId: action.OrderId}, nil
+	default:
+		return errorReply("action %s not available. available: %s, %s", action.Action, SubscribeAction, UnsubscribeAction), nil
+	}
+}
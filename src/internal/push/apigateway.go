@@ -0,0 +1,45 @@
+package push
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigatewaymanagementapi"
+	"github.com/aws/aws-sdk-go-v2/service/apigatewaymanagementapi/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// Management sends with the API Gateway management api of the WebSocket stage
+type Management struct {
+	Client *apigatewaymanagementapi.Client
+}
+
+// NewManagement returns the sender of the stage endpoint, https://{api}.execute-api.{region}.amazonaws.com/{stage}
+func NewManagement(cfg aws.Config, endpoint string) Management {
+	return Management{Client: apigatewaymanagementapi.NewFromConfig(cfg, func(options *apigatewaymanagementapi.Options) {
+		options.BaseEndpoint = aws.String(endpoint)
+	})}
+}
+
+func (m Management) Send(ctx context.Context, connectionid string, message []byte) error {
+	_, err := m.Client.PostToConnection(ctx, &apigatewaymanagementapi.PostToConnectionInput{
+		ConnectionId: aws.String(connectionid),
+		Data:         message,
+	})
+
+	var gone *types.GoneException
+	if errors.As(err, &gone) {
+		return ErrGone
+	}
+	return err
+}
+
+// New returns the lambda publisher of the WebSocket stage endpoint. Updates are discarded
+// when the endpoint is empty, e.g. without the WebSocket api.
+func New(cfg aws.Config, ddb *dynamodb.Client, connectionsTable, endpoint string) Publisher {
+	if endpoint == "" {
+		return Discard
+	}
+	return Hub{Store: Table{DDB: ddb, TableName: connectionsTable}, Sender: NewManagement(cfg, endpoint)}
+}
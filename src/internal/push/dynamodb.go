@@ -0,0 +1,95 @@
+package push
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ConnectionIndex is the connections table index of the subscriptions of a connection
+const ConnectionIndex = "connectionid-index"
+
+// Table is the store of the WebSocket lambdas. Items are keyed by orderid and connectionid
+// and expire with the connection.
+type Table struct {
+	DDB       *dynamodb.Client
+	TableName string
+}
+
+func subscriptionKey(orderid, connectionid string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"orderid":      &types.AttributeValueMemberS{Value: orderid},
+		"connectionid": &types.AttributeValueMemberS{Value: connectionid},
+	}
+}
+
+func (t Table) Subscribe(ctx context.Context, subscription Subscription) error {
+	item, err := attributevalue.MarshalMap(subscription)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.DDB.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(t.TableName), Item: item})
+	return err
+}
+
+func (t Table) Unsubscribe(ctx context.Context, connectionid, orderid string) error {
+	_, err := t.DDB.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(t.TableName),
+		Key:       subscriptionKey(orderid, connectionid),
+	})
+	return err
+}
+
+func (t Table) Disconnect(ctx context.Context, connectionid string) error {
+	paginator := dynamodb.NewQueryPaginator(t.DDB, &dynamodb.QueryInput{
+		TableName:                 aws.String(t.TableName),
+		IndexName:                 aws.String(ConnectionIndex),
+		KeyConditionExpression:    aws.String("connectionid = :connectionid"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":connectionid": &types.AttributeValueMemberS{Value: connectionid}},
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+
+		page := []Subscription{}
+		if err := attributevalue.UnmarshalListOfMaps(output.Items, &page); err != nil {
+			return err
+		}
+		for _, subscription := range page {
+			if err := t.Unsubscribe(ctx, connectionid, subscription.OrderId); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (t Table) Connections(ctx context.Context, orderid string) ([]string, error) {
+	connections := []string{}
+	paginator := dynamodb.NewQueryPaginator(t.DDB, &dynamodb.QueryInput{
+		TableName:                 aws.String(t.TableName),
+		KeyConditionExpression:    aws.String("orderid = :orderid"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":orderid": &types.AttributeValueMemberS{Value: orderid}},
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		page := []Subscription{}
+		if err := attributevalue.UnmarshalListOfMaps(output.Items, &page); err != nil {
+			return nil, err
+		}
+		for _, subscription := range page {
+			connections = append(connections, subscription.ConnectionId)
+		}
+	}
+	return connections, nil
+}
@@ -0,0 +1,64 @@
+package push
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Memory is the in-process store of the local dev server and tests
+type Memory struct {
+	mu     sync.Mutex
+	orders map[string]map[string]Subscription // orderid, connectionid
+}
+
+func NewMemory() *Memory {
+	return &Memory{orders: map[string]map[string]Subscription{}}
+}
+
+func (m *Memory) Subscribe(ctx context.Context, subscription Subscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.orders[subscription.OrderId] == nil {
+		m.orders[subscription.OrderId] = map[string]Subscription{}
+	}
+	m.orders[subscription.OrderId][subscription.ConnectionId] = subscription
+	return nil
+}
+
+func (m *Memory) Unsubscribe(ctx context.Context, connectionid, orderid string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.orders[orderid], connectionid)
+	if len(m.orders[orderid]) == 0 {
+		delete(m.orders, orderid)
+	}
+	return nil
+}
+
+func (m *Memory) Disconnect(ctx context.Context, connectionid string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for orderid, connections := range m.orders {
+		delete(connections, connectionid)
+		if len(connections) == 0 {
+			delete(m.orders, orderid)
+		}
+	}
+	return nil
+}
+
+func (m *Memory) Connections(ctx context.Context, orderid string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	connections := []string{}
+	for connectionid := range m.orders[orderid] {
+		connections = append(connections, connectionid)
+	}
+	sort.Strings(connections)
+	return connections, nil
+}
@@ -0,0 +1,97 @@
+// Package push fans order updates out to the WebSocket connections subscribed to the order.
+// A Hub reads the subscriptions of a Store and writes with a Sender: the API Gateway
+// management api in the lambdas, or the in-process connections of the local dev server.
+//
+//	hub := push.Hub{Store: push.NewMemory(), Sender: sender}
+//	hub.Publish(ctx, orderid, update)
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kscott5/fds/internal/metrics"
+)
+
+const (
+	DefaultConnectionsTable = "FDSAppsConnections"
+
+	// API Gateway closes WebSocket connections after two hours
+	SubscriptionTTL = 2 * time.Hour
+)
+
+// ErrGone is returned by a Sender when the connection is closed
+var ErrGone = errors.New("websocket connection gone")
+
+// Sender writes a message to a connection
+type Sender interface {
+	Send(ctx context.Context, connectionid string, message []byte) error
+}
+
+// Subscription is a connection receiving the updates of a customer's order
+type Subscription struct {
+	OrderId      string `json:"orderid" dynamodbav:"orderid"`
+	ConnectionId string `json:"connectionid" dynamodbav:"connectionid"`
+	UserId       string `json:"userid" dynamodbav:"userid"`
+	SubscribedOn int64  `json:"subscribedon" dynamodbav:"subscribedon"`
+	ExpiresAt    int64  `json:"-" dynamodbav:"expiresat"` // dynamodb ttl in seconds
+}
+
+// Store keeps the subscriptions of the connections
+type Store interface {
+	Subscribe(ctx context.Context, subscription Subscription) error
+	Unsubscribe(ctx context.Context, connectionid, orderid string) error
+	// Disconnect removes every subscription of the connection
+	Disconnect(ctx context.Context, connectionid string) error
+	Connections(ctx context.Context, orderid string) ([]string, error)
+}
+
+// Publisher pushes an update to the subscribers of the order
+type Publisher interface {
+	Publish(ctx context.Context, orderid string, update interface{}) error
+}
+
+type discard struct{}
+
+func (discard) Publish(context.Context, string, interface{}) error { return nil }
+
+// Discard is the publisher without a WebSocket api
+var Discard Publisher = discard{}
+
+// Hub publishes json updates to the connections of the store. Closed connections are
+// disconnected.
+type Hub struct {
+	Store  Store
+	Sender Sender
+}
+
+func (h Hub) Publish(ctx context.Context, orderid string, update interface{}) error {
+	message, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+
+	connections, err := h.Store.Connections(ctx, orderid)
+	if err != nil {
+		return err
+	}
+
+	errs := []error{}
+	for _, connectionid := range connections {
+		result := "sent"
+		if err := h.Sender.Send(ctx, connectionid, message); errors.Is(err, ErrGone) {
+			result = "gone"
+			if err := h.Store.Disconnect(ctx, connectionid); err != nil {
+				errs = append(errs, err)
+			}
+		} else if err != nil {
+			result = "failed"
+			errs = append(errs, fmt.Errorf("connection %s: %w", connectionid, err))
+		}
+		metrics.Count(metrics.PushMessages, metrics.Dim("Result", result))
+	}
+	return errors.Join(errs...)
+}
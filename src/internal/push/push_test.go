@@ -0,0 +1,131 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// recorder is a Sender keeping the messages of each connection. Connections in errs fail.
+type recorder struct {
+	sent map[string][]string
+	errs map[string]error
+}
+
+func (r *recorder) Send(ctx context.Context, connectionid string, message []byte) error {
+	if err := r.errs[connectionid]; err != nil {
+		return err
+	}
+	r.sent[connectionid] = append(r.sent[connectionid], string(message))
+	return nil
+}
+
+func subscribe(t *testing.T, store Store, orderid string, connections ...string) {
+	for _, connectionid := range connections {
+		if err := store.Subscribe(context.Background(), Subscription{OrderId: orderid, ConnectionId: connectionid}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestHubPublish(t *testing.T) {
+	failed := errors.New("throttled")
+	update := map[string]string{"orderid": "o-1", "status": "acknowledged"}
+	message := `{"orderid":"o-1","status":"acknowledged"}`
+
+	tests := []struct {
+		name      string
+		errs      map[string]error
+		sent      map[string][]string
+		err       error
+		remaining []string
+	}{
+		{"every subscriber", nil, map[string][]string{"c-1": {message}, "c-2": {message}}, nil, []string{"c-1", "c-2"}},
+		{"gone connection", map[string]error{"c-1": ErrGone}, map[string][]string{"c-2": {message}}, nil, []string{"c-2"}},
+		{"failed connection", map[string]error{"c-2": failed}, map[string][]string{"c-1": {message}}, failed, []string{"c-1", "c-2"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := NewMemory()
+			subscribe(t, store, "o-1", "c-1", "c-2")
+			// another order's subscriber and a connection of both orders
+			subscribe(t, store, "o-2", "c-3", "c-1")
+
+			sender := &recorder{sent: map[string][]string{}, errs: test.errs}
+			hub := Hub{Store: store, Sender: sender}
+
+			if err := hub.Publish(ctx, "o-1", update); !errors.Is(err, test.err) {
+				t.Fatalf("Publish = %v, want %v", err, test.err)
+			} else if !reflect.DeepEqual(sender.sent, test.sent) {
+				t.Errorf("sent %v, want %v", sender.sent, test.sent)
+			}
+
+			if remaining, _ := store.Connections(ctx, "o-1"); !reflect.DeepEqual(remaining, test.remaining) {
+				t.Errorf("o-1 connections %v, want %v", remaining, test.remaining)
+			}
+
+			// gone connections are removed from every order
+			want := []string{"c-1", "c-3"}
+			if test.errs["c-1"] == ErrGone {
+				want = []string{"c-3"}
+			}
+			if remaining, _ := store.Connections(ctx, "o-2"); !reflect.DeepEqual(remaining, want) {
+				t.Errorf("o-2 connections %v, want %v", remaining, want)
+			}
+		})
+	}
+}
+
+func TestHandle(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+	owns := func(ctx context.Context, userid, orderid string) (bool, error) {
+		return userid == "u-1" && orderid == "o-1", nil
+	}
+
+	tests := []struct {
+		name          string
+		userid        string
+		body          string
+		reply         Reply
+		subscriptions []string
+		// the connection is subscribed before the action
+		subscribed bool
+	}{
+		{"subscribe", "u-1", `{"action": "subscribe", "orderid": "o-1"}`, Reply{Type: "subscribed", OrderId: "o-1"}, []string{"c-1", "c-9"}, false},
+		{"unsubscribe", "u-1", `{"action": "unsubscribe", "orderid": "o-1"}`, Reply{Type: "unsubscribed", OrderId: "o-1"}, []string{"c-9"}, true},
+		{"another customer's order", "u-2", `{"action": "subscribe", "orderid": "o-1"}`, Reply{Type: "error", Message: "order o-1 not found"}, []string{"c-9"}, false},
+		{"unauthorized connection", "", `{"action": "subscribe", "orderid": "o-1"}`, Reply{Type: "error", Message: "subscriptions require an authorized connection"}, []string{"c-9"}, false},
+		{"unknown action", "u-1", `{"action": "publish", "orderid": "o-1"}`, Reply{Type: "error", Message: "action publish not available. available: subscribe, unsubscribe"}, []string{"c-9"}, false},
+		{"missing order", "u-1", `{"action": "subscribe"}`, Reply{Type: "error", Message: "requires: map[action:subscribe or unsubscribe orderid:string]"}, []string{"c-9"}, false},
+		{"not json", "u-1", `subscribe`, Reply{Type: "error", Message: "requires: map[action:subscribe or unsubscribe orderid:string]"}, []string{"c-9"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := NewMemory()
+			subscribe(t, store, "o-1", "c-9")
+			if test.subscribed {
+				subscribe(t, store, "o-1", "c-1")
+			}
+
+			reply, err := Handle(ctx, store, owns, "c-1", test.userid, []byte(test.body), now)
+			if err != nil {
+				t.Fatal(err)
+			} else if reply != test.reply {
+				t.Errorf("Handle = %+v, want %+v", reply, test.reply)
+			}
+
+			connections, _ := store.Connections(ctx, "o-1")
+			sort.Strings(connections)
+			if !reflect.DeepEqual(connections, test.subscriptions) {
+				t.Errorf("o-1 connections %v, want %v", connections, test.subscriptions)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+// Package router registers the API Gateway routes of a service. The registrations are
+// dispatched by the lambda handler and read by the OpenAPI generator in cmd/openapi.
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/internal/schema"
+	"github.com/kscott5/fds/internal/tracing"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+type HandlerFunc func(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error)
+
+// Route is an API Gateway method and resource path handled by a service
+type Route struct {
+	Method   string
+	Resource string
+	Handler  HandlerFunc
+	Summary  string
+
+	// legacy resource paths handled by the same handler, e.g. /order/{id}. Not documented.
+	Aliases []string
+
+	// application/json request body schema, or the schema by content type when
+	// the route accepts other media types
+	Request      *schema.Field
+	RequestTypes map[string]*schema.Field
+
+	// query string parameters and their descriptions
+	Query map[string]string
+
+	// success status code and a value of the response body type. 200 when Status is zero.
+	Status   int
+	Response interface{}
+
+	// routes are protected by the lambda token authorizer unless public
+	Public bool
+}
+
+// Router dispatches API Gateway proxy requests by "METHOD /resource"
+type Router struct {
+	Service string
+
+	routes   []Route
+	handlers map[string]HandlerFunc
+}
+
+func New(service string) *Router {
+	return &Router{Service: service, handlers: map[string]HandlerFunc{}}
+}
+
+func (r *Router) Handle(route Route) {
+	if route.Status == 0 {
+		route.Status = 200
+	}
+
+	r.routes = append(r.routes, route)
+	for _, resource := range append([]string{route.Resource}, route.Aliases...) {
+		key, _ := client.GetRequestKeyFrom(route.Method, resource)
+		r.handlers[key] = route.Handler
+	}
+}
+
+// Routes returns the registered routes in registration order
+func (r *Router) Routes() []Route {
+	return append([]Route{}, r.routes...)
+}
+
+// Dispatch calls the route handler within the request span and with the request logger in ctx
+func (r *Router) Dispatch(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	key, _ := client.GetRequestKeyFrom(request.HTTPMethod, request.Resource)
+
+	ctx, span := tracing.StartInvocation(ctx, key,
+		attribute.String("faas.trigger", "http"),
+		attribute.String("http.request.method", request.HTTPMethod),
+		attribute.String("http.route", request.Resource),
+	)
+
+	logger := RequestLogger(ctx, r.Service, key, request)
+	ctx = logging.NewContext(ctx, logger)
+
+	response, err := r.dispatch(ctx, key, request)
+	if err != nil {
+		logger.Error("request failed", zap.Error(err))
+	} else if response != nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", response.StatusCode))
+		response = withTraceId(response, tracing.TraceId(ctx))
+	}
+
+	tracing.EndInvocation(ctx, span, err)
+	return response, err
+}
+
+func (r *Router) dispatch(ctx context.Context, key string, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	if handler, ok := r.handlers[key]; ok {
+		logging.FromContext(ctx).Info("request started")
+		return handler(ctx, request)
+	}
+
+	logging.FromContext(ctx).Warn("route not found")
+	return nil, fmt.Errorf("(%s) not valid. valid request requires httpmethod and resource", key)
+}
+
+// withTraceId adds the trace id header, and the trace id to error response bodies
+func withTraceId(response *events.APIGatewayProxyResponse, traceId string) *events.APIGatewayProxyResponse {
+	if traceId == "" {
+		return response
+	}
+
+	// handlers share client.HttpResponseHeaders
+	headers := map[string]string{client.TraceIdHeader: traceId}
+	for name, value := range response.Headers {
+		headers[name] = value
+	}
+	response.Headers = headers
+
+	errorBody := client.ErrorBody{}
+	if response.StatusCode >= 400 && !response.IsBase64Encoded && json.Unmarshal([]byte(response.Body), &errorBody) == nil {
+		errorBody.TraceId = traceId
+		if body, err := json.Marshal(errorBody); err == nil {
+			response.Body = string(body)
+		}
+	}
+	return response
+}
+
+// RequestLogger returns the logger with the correlation ids of the request
+func RequestLogger(ctx context.Context, service, route string, request *events.APIGatewayProxyRequest) *zap.Logger {
+	logger := logging.FromContext(ctx).With(
+		zap.String("service", service),
+		zap.String("route", route),
+		zap.String("apiRequestId", request.RequestContext.RequestID),
+	)
+	if principalId, err := client.GetPrincipalIdFrom(request.RequestContext.Authorizer); err == nil {
+		logger = logger.With(zap.String("principalId", principalId))
+	}
+	return logger
+}
@@ -0,0 +1,72 @@
+package schema
+
+import (
+	"sort"
+)
+
+// OpenAPI returns the field as an OpenAPI 3.0 schema object
+func (f *Field) OpenAPI() map[string]interface{} {
+	out := map[string]interface{}{}
+	if f.kind != AnyKind {
+		out["type"] = f.kind.String()
+	}
+	if f.nullable {
+		out["nullable"] = true
+	}
+
+	if f.min != nil {
+		out["minimum"] = *f.min
+	}
+	if f.max != nil {
+		out["maximum"] = *f.max
+	}
+	if f.minLength > 0 {
+		out["minLength"] = f.minLength
+	}
+	if f.maxLength > 0 {
+		out["maxLength"] = f.maxLength
+	}
+	if f.format != "" {
+		out["format"] = f.format
+	}
+	if f.pattern != nil {
+		out["pattern"] = f.pattern.String()
+	}
+	if len(f.enum) > 0 {
+		out["enum"] = f.enum
+	}
+
+	if f.kind == ArrayKind {
+		if f.minItems > 0 {
+			out["minItems"] = f.minItems
+		}
+		if f.maxItems > 0 {
+			out["maxItems"] = f.maxItems
+		}
+		if f.items != nil {
+			out["items"] = f.items.OpenAPI()
+		} else {
+			out["items"] = map[string]interface{}{}
+		}
+	}
+
+	if f.kind == ObjectKind {
+		properties := map[string]interface{}{}
+		required := []string{}
+		for name, property := range f.properties {
+			properties[name] = property.OpenAPI()
+			if property.required {
+				required = append(required, name)
+			}
+		}
+		sort.Strings(required)
+
+		out["properties"] = properties
+		if len(required) > 0 {
+			out["required"] = required
+		}
+		out["additionalProperties"] = f.allowUnknown
+	}
+
+	return out
+}
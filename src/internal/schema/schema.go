@@ -0,0 +1,277 @@
+// Package schema declares request body schemas and reports every violation with its JSON path.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kscott5/fds/internal/client"
+)
+
+type Kind uint8
+
+const (
+	AnyKind Kind = iota
+	StringKind
+	NumberKind
+	IntegerKind
+	BooleanKind
+	ObjectKind
+	ArrayKind
+)
+
+func (k Kind) String() string {
+	switch k {
+	case StringKind:
+		return "string"
+	case NumberKind:
+		return "number"
+	case IntegerKind:
+		return "integer"
+	case BooleanKind:
+		return "boolean"
+	case ObjectKind:
+		return "object"
+	case ArrayKind:
+		return "array"
+	default:
+		return "any"
+	}
+}
+
+// string formats
+const (
+	UUID     = "uuid"
+	Email    = "email"
+	DateTime = "date-time"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{12}$`)
+
+// Field describes one JSON value. Build fields with String, Number, Integer, Boolean, Object, Array and AnyValue.
+type Field struct {
+	kind     Kind
+	required bool
+	nullable bool
+
+	min, max             *float64
+	minLength, maxLength int
+	format               string
+	pattern              *regexp.Regexp
+	enum                 []string
+
+	minItems, maxItems int
+	items              *Field
+
+	properties   map[string]*Field
+	allowUnknown bool
+}
+
+func String() *Field   { return &Field{kind: StringKind} }
+func Number() *Field   { return &Field{kind: NumberKind} }
+func Integer() *Field  { return &Field{kind: IntegerKind} }
+func Boolean() *Field  { return &Field{kind: BooleanKind} }
+func AnyValue() *Field { return &Field{kind: AnyKind} }
+
+// Object fields reject unknown properties unless AllowUnknown is set
+func Object(properties map[string]*Field) *Field {
+	return &Field{kind: ObjectKind, properties: properties}
+}
+
+func Array(items *Field) *Field {
+	return &Field{kind: ArrayKind, items: items}
+}
+
+func (f *Field) Required() *Field             { f.required = true; return f }
+func (f *Field) Nullable() *Field             { f.nullable = true; return f }
+func (f *Field) Min(min float64) *Field       { f.min = &min; return f }
+func (f *Field) Max(max float64) *Field       { f.max = &max; return f }
+func (f *Field) MinLength(n int) *Field       { f.minLength = n; return f }
+func (f *Field) MaxLength(n int) *Field       { f.maxLength = n; return f }
+func (f *Field) Format(format string) *Field  { f.format = format; return f }
+func (f *Field) Enum(values ...string) *Field { f.enum = values; return f }
+func (f *Field) MinItems(n int) *Field        { f.minItems = n; return f }
+func (f *Field) MaxItems(n int) *Field        { f.maxItems = n; return f }
+func (f *Field) AllowUnknown() *Field         { f.allowUnknown = true; return f }
+
+func (f *Field) Pattern(pattern string) *Field {
+	f.pattern = regexp.MustCompile(pattern)
+	return f
+}
+
+// Validate decodes the body and returns every violation. An empty result means the body is valid.
+func (f *Field) Validate(body []byte) []client.Violation {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return []client.Violation{{Path: "$", Message: fmt.Sprintf("invalid json: %s", err)}}
+	} else if decoder.More() {
+		return []client.Violation{{Path: "$", Message: "invalid json: unexpected data after top-level value"}}
+	}
+
+	violations := []client.Violation{}
+	f.validate("$", value, &violations)
+	return violations
+}
+
+func (f *Field) validate(path string, value interface{}, violations *[]client.Violation) {
+	add := func(format string, args ...interface{}) {
+		*violations = append(*violations, client.Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if value == nil {
+		if !f.nullable {
+			add("must not be null")
+		}
+		return
+	}
+
+	switch f.kind {
+	case StringKind:
+		s, ok := value.(string)
+		if !ok {
+			add("must be a string")
+			return
+		}
+		f.validateString(s, add)
+	case NumberKind, IntegerKind:
+		n, ok := value.(json.Number)
+		if !ok {
+			add("must be a %s", f.kind)
+			return
+		}
+		f.validateNumber(n, add)
+	case BooleanKind:
+		if _, ok := value.(bool); !ok {
+			add("must be a boolean")
+		}
+	case ArrayKind:
+		list, ok := value.([]interface{})
+		if !ok {
+			add("must be an array")
+			return
+		}
+		if len(list) < f.minItems {
+			add("must contain at least %d items", f.minItems)
+		}
+		if f.maxItems > 0 && len(list) > f.maxItems {
+			add("must contain at most %d items", f.maxItems)
+		}
+		if f.items != nil {
+			for i, item := range list {
+				f.items.validate(fmt.Sprintf("%s[%d]", path, i), item, violations)
+			}
+		}
+	case ObjectKind:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			add("must be an object")
+			return
+		}
+		f.validateObject(path, object, violations)
+	}
+}
+
+func (f *Field) validateString(s string, add func(string, ...interface{})) {
+	length := len([]rune(s))
+	if f.required && strings.TrimSpace(s) == "" {
+		add("must not be empty")
+	}
+	if length < f.minLength {
+		add("must be at least %d characters", f.minLength)
+	}
+	if f.maxLength > 0 && length > f.maxLength {
+		add("must be at most %d characters", f.maxLength)
+	}
+	if f.pattern != nil && s != "" && !f.pattern.MatchString(s) {
+		add("must match %s", f.pattern)
+	}
+	if len(f.enum) > 0 {
+		found := false
+		for _, v := range f.enum {
+			found = found || v == s
+		}
+		if !found {
+			add("must be one of: %s", strings.Join(f.enum, ", "))
+		}
+	}
+
+	switch f.format {
+	case UUID:
+		if !uuidPattern.MatchString(s) {
+			add("must be a uuid")
+		}
+	case Email:
+		if _, err := mail.ParseAddress(s); err != nil {
+			add("must be an email address")
+		}
+	case DateTime:
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			add("must be an RFC 3339 date-time")
+		}
+	}
+}
+
+func (f *Field) validateNumber(n json.Number, add func(string, ...interface{})) {
+	if f.kind == IntegerKind {
+		if _, err := n.Int64(); err != nil {
+			add("must be an integer")
+			return
+		}
+	}
+
+	v, err := n.Float64()
+	if err != nil {
+		add("must be a number")
+		return
+	}
+	if f.min != nil && v < *f.min {
+		add("must be >= %v", *f.min)
+	}
+	if f.max != nil && v > *f.max {
+		add("must be <= %v", *f.max)
+	}
+}
+
+func (f *Field) validateObject(path string, object map[string]interface{}, violations *[]client.Violation) {
+	names := make([]string, 0, len(f.properties))
+	for name := range f.properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property := f.properties[name]
+		value, found := object[name]
+		if !found {
+			if property.required {
+				*violations = append(*violations, client.Violation{Path: path + "." + name, Message: "is required"})
+			}
+			continue
+		}
+		property.validate(path+"."+name, value, violations)
+	}
+
+	if f.allowUnknown {
+		return
+	}
+
+	unknown := []string{}
+	for name := range object {
+		if _, found := f.properties[name]; !found {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		*violations = append(*violations, client.Violation{Path: path + "." + name, Message: "unknown field"})
+	}
+}
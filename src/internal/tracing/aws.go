@@ -0,0 +1,63 @@
+package tracing
+
+import (
+	"context"
+	"reflect"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AppendMiddlewares adds a client span to every AWS SDK operation, e.g. DynamoDB.GetItem
+func AppendMiddlewares(apiOptions *[]func(*middleware.Stack) error) {
+	*apiOptions = append(*apiOptions, func(stack *middleware.Stack) error {
+		// after the service metadata is registered with the context
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("FDSTracing", startOperation), middleware.After)
+	})
+}
+
+func startOperation(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	service, operation := awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx)
+	attributes := []attribute.KeyValue{
+		semconv.RPCSystemKey.String("aws-api"),
+		semconv.RPCService(service),
+		semconv.RPCMethod(operation),
+		semconv.CloudRegion(awsmiddleware.GetRegion(ctx)),
+	}
+	if table := tableNameOf(in.Parameters); table != "" {
+		attributes = append(attributes, semconv.AWSDynamoDBTableNames(table))
+	}
+
+	ctx, span := Start(ctx, service+"."+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+	out, metadata, err := next.HandleInitialize(ctx, in)
+
+	if requestId, ok := awsmiddleware.GetRequestIDMetadata(metadata); ok {
+		span.SetAttributes(semconv.AWSRequestID(requestId))
+	}
+	if response, ok := awsmiddleware.GetRawResponse(metadata).(*smithyhttp.Response); ok {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(response.StatusCode))
+	}
+
+	End(span, err)
+	return out, metadata, err
+}
+
+// tableNameOf returns the TableName input parameter of DynamoDB operations
+func tableNameOf(parameters interface{}) string {
+	value := reflect.ValueOf(parameters)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return ""
+	}
+
+	if field := value.Elem().FieldByName("TableName"); field.IsValid() && field.Kind() == reflect.Ptr && !field.IsNil() {
+		if name, ok := field.Elem().Interface().(string); ok {
+			return name
+		}
+	}
+	return ""
+}
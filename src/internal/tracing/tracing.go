@@ -0,0 +1,128 @@
+// Package tracing provides the lambda container's OpenTelemetry tracer provider and the
+// invocation spans started by the router and the lambda handlers.
+//
+//	ctx, span := tracing.Start(ctx, "fetch jwks keys")
+//	defer span.End()
+//
+// Spans are exported with OTLP over http when OTEL_EXPORTER_OTLP_ENDPOINT or
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is set, e.g. http://localhost:4318 for a local collector.
+// Without an endpoint spans are still created so trace ids are available to logs and responses.
+package tracing
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/kscott5/fds/internal/logging"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"go.uber.org/zap"
+)
+
+const TracerName = "github.com/kscott5/fds"
+
+var (
+	providerOnce sync.Once
+	provider     *sdktrace.TracerProvider
+)
+
+// Provider returns the container's tracer provider, created once at cold start
+func Provider() *sdktrace.TracerProvider {
+	providerOnce.Do(func() {
+		logger := logging.Logger()
+		otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+			logger.Warn("tracing error", zap.Error(err))
+		}))
+
+		options := []sdktrace.TracerProviderOption{
+			sdktrace.WithResource(resource.NewSchemaless(
+				semconv.ServiceName(serviceName()),
+				semconv.CloudProviderAWS,
+				semconv.CloudRegion(os.Getenv("AWS_REGION")),
+			)),
+			// x-ray accepts trace ids that begin with the epoch seconds
+			sdktrace.WithIDGenerator(xrayIDGenerator{}),
+		}
+
+		if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "" {
+			if exporter, err := otlptracehttp.New(context.Background()); err != nil {
+				logger.Warn("otlp trace exporter not available", zap.Error(err))
+			} else {
+				options = append(options, sdktrace.WithBatcher(exporter))
+			}
+		}
+
+		provider = sdktrace.NewTracerProvider(options...)
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	return provider
+}
+
+func serviceName() string {
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		return name
+	} else if lambdacontext.FunctionName != "" {
+		return lambdacontext.FunctionName
+	}
+	return "fds"
+}
+
+// Start returns a child span of the span in ctx
+func Start(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return Provider().Tracer(TracerName).Start(ctx, name, options...)
+}
+
+// End records the error, if any, and ends the span
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// StartInvocation starts the server span of a lambda invocation. The span continues the
+// x-ray trace of the invocation and ctx carries a logger with the trace id.
+func StartInvocation(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		if parent, ok := spanContextFromXRay(xrayHeaderFrom(ctx)); ok {
+			ctx = trace.ContextWithRemoteSpanContext(ctx, parent)
+		}
+	}
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		attributes = append(attributes, semconv.FaaSInvocationID(lc.AwsRequestID))
+	}
+
+	ctx, span := Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attributes...))
+	ctx = logging.NewContext(ctx, logging.FromContext(ctx).With(zap.String("traceId", TraceId(ctx))))
+	return ctx, span
+}
+
+// EndInvocation ends the invocation span and exports the spans before the container is frozen
+func EndInvocation(ctx context.Context, span trace.Span, err error) {
+	End(span, err)
+	if err := Provider().ForceFlush(ctx); err != nil {
+		logging.FromContext(ctx).Warn("tracing flush failed", zap.Error(err))
+	}
+}
+
+// TraceId returns the trace id of the span in ctx, or empty without a span
+func TraceId(ctx context.Context) string {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		return spanContext.TraceID().String()
+	}
+	return ""
+}
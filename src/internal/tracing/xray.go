@@ -0,0 +1,88 @@
+package tracing
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// xrayIDGenerator creates trace ids with the epoch seconds in the first 4 bytes
+type xrayIDGenerator struct{}
+
+var (
+	randomMutex sync.Mutex
+	random      = rand.New(rand.NewSource(seed()))
+)
+
+func seed() int64 {
+	var b [8]byte
+	crand.Read(b[:])
+	return int64(binary.LittleEndian.Uint64(b[:]))
+}
+
+func (xrayIDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	randomMutex.Lock()
+	defer randomMutex.Unlock()
+
+	traceId := trace.TraceID{}
+	binary.BigEndian.PutUint32(traceId[:4], uint32(time.Now().Unix()))
+	random.Read(traceId[4:])
+
+	spanId := trace.SpanID{}
+	random.Read(spanId[:])
+	return traceId, spanId
+}
+
+func (xrayIDGenerator) NewSpanID(ctx context.Context, traceId trace.TraceID) trace.SpanID {
+	randomMutex.Lock()
+	defer randomMutex.Unlock()
+
+	spanId := trace.SpanID{}
+	random.Read(spanId[:])
+	return spanId
+}
+
+// xrayHeaderFrom returns the trace header the lambda runtime provides with each invocation
+func xrayHeaderFrom(ctx context.Context) string {
+	if header, ok := ctx.Value("x-amzn-trace-id").(string); ok && header != "" {
+		return header
+	}
+	return os.Getenv("_X_AMZN_TRACE_ID")
+}
+
+// spanContextFromXRay parses Root=1-{epoch}-{random};Parent={span id};Sampled={0|1}
+func spanContextFromXRay(header string) (trace.SpanContext, bool) {
+	config := trace.SpanContextConfig{Remote: true}
+	for _, part := range strings.Split(header, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name == "Root" {
+			root := strings.Split(value, "-")
+			if len(root) != 3 || root[0] != "1" {
+				return trace.SpanContext{}, false
+			} else if b, err := hex.DecodeString(root[1] + root[2]); err != nil || len(b) != 16 {
+				return trace.SpanContext{}, false
+			} else {
+				copy(config.TraceID[:], b)
+			}
+		} else if name == "Parent" {
+			if b, err := hex.DecodeString(value); err != nil || len(b) != 8 {
+				return trace.SpanContext{}, false
+			} else {
+				copy(config.SpanID[:], b)
+			}
+		} else if name == "Sampled" && value == "1" {
+			config.TraceFlags = trace.FlagsSampled
+		}
+	}
+
+	spanContext := trace.NewSpanContext(config)
+	return spanContext, spanContext.IsValid()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/config"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/internal/notify"
+	"github.com/kscott5/fds/internal/tracing"
+	"github.com/kscott5/fds/notifications/notifier"
+	"github.com/kscott5/fds/notifications/providers"
+	"github.com/kscott5/fds/notifications/templates"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	_ "github.com/aws/aws-lambda-go/lambdacontext" // IMPORTANT: package level init() in use.
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+var (
+	settings config.Notifications
+	service  notifier.Notifier
+)
+
+// notifyOrder sends the notifications of an order event of the event bus. Errors are
+// returned so EventBridge retries the event; messages already sent may be sent again.
+func notifyOrder(ctx context.Context, event events.EventBridgeEvent) (err error) {
+	ctx, span := tracing.StartInvocation(ctx, "notify order", attribute.String("fds.event.type", event.DetailType))
+	defer func() { tracing.EndInvocation(ctx, span, err) }()
+
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: order notifications", zap.String("type", event.DetailType))
+
+	detail := notifier.OrderEvent{}
+	if err := json.Unmarshal(event.Detail, &detail); err != nil {
+		logger.Error("order event detail skipped", zap.String("id", event.ID), zap.Error(err))
+		return nil
+	}
+
+	sent, err := service.Notify(ctx, detail)
+	logger.Info("order notifications sent", zap.Int("sent", sent), zap.Error(err))
+	return err
+}
+
+// provider returns the provider of the configuration. stdout and file are for development.
+func provider() (notify.Provider, error) {
+	switch settings.Provider {
+	case "aws":
+		return providers.New(client.LoadConfig(), settings.EmailFrom), nil
+	case "stdout":
+		return notify.Writer{W: os.Stdout}, nil
+	case "file":
+		return &notify.File{Path: settings.File}, nil
+	default:
+		return nil, fmt.Errorf("notifications provider %s not available. requires aws, stdout or file", settings.Provider)
+	}
+}
+
+func main() {
+	config.MustLoad(&settings)
+
+	sender, err := provider()
+	if err != nil {
+		logging.Logger().Fatal(err.Error())
+	}
+	service = notifier.Notifier{
+		Directory: notifier.Tables{DDB: client.NewDynamodb(), UsersTable: settings.UsersTable, RestaurantsTable: settings.RestaurantsTable},
+		Templates: templates.MustLoad(),
+		Provider:  sender,
+	}
+	lambda.Start(notifyOrder)
+}
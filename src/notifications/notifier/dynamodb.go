@@ -0,0 +1,54 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/kscott5/fds/internal/notify"
+	"github.com/kscott5/fds/orders/restaurants"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Tables reads the recipients from the users and restaurants tables
+type Tables struct {
+	DDB              *dynamodb.Client
+	UsersTable       string
+	RestaurantsTable string
+}
+
+// user profile attributes of notifications, see users/profiles
+type profile struct {
+	FullName      string              `dynamodbav:"FullName"`
+	Notifications *notify.Preferences `dynamodbav:"notifications"`
+	DeletedAt     int64               `dynamodbav:"deletedAt"`
+}
+
+func (t Tables) get(ctx context.Context, tableName, name, value string, out interface{}) (bool, error) {
+	output, err := t.DDB.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key:       map[string]types.AttributeValue{name: &types.AttributeValueMemberS{Value: value}},
+	})
+	if err != nil || output.Item == nil {
+		return false, err
+	}
+	return true, attributevalue.UnmarshalMap(output.Item, out)
+}
+
+func (t Tables) Customer(ctx context.Context, userid string) (Recipient, bool, error) {
+	user := profile{}
+	if found, err := t.get(ctx, t.UsersTable, "userid", userid, &user); err != nil || !found || user.DeletedAt != 0 {
+		return Recipient{}, false, err
+	}
+	return Recipient{Name: user.FullName, Preferences: user.Notifications}, true, nil
+}
+
+func (t Tables) Restaurant(ctx context.Context, restaurantid string) (Recipient, bool, error) {
+	restaurant := restaurants.Restaurant{}
+	if found, err := t.get(ctx, t.RestaurantsTable, "restaurantid", restaurantid, &restaurant); err != nil || !found {
+		return Recipient{}, false, err
+	}
+	return Recipient{Name: restaurant.Name, Preferences: restaurant.Notifications}, true, nil
+}
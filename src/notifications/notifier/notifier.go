@@ -0,0 +1,130 @@
+// Package notifier notifies the customer and the restaurant of order events. Each recipient
+// receives the message of every opted in channel in the recipient's locale.
+//
+//	OrderPlaced         customer, restaurant
+//	OrderAcknowledged   customer
+//	OrderCancelled      customer, restaurant
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/internal/metrics"
+	"github.com/kscott5/fds/internal/notify"
+	"github.com/kscott5/fds/notifications/templates"
+	"github.com/kscott5/fds/orders/services"
+
+	"go.uber.org/zap"
+)
+
+const (
+	Customer   = "customer"
+	Restaurant = "restaurant"
+)
+
+var audiences = map[string][]string{
+	services.OrderPlacedEvent:       {Customer, Restaurant},
+	services.OrderAcknowledgedEvent: {Customer},
+	services.OrderCancelledEvent:    {Customer, Restaurant},
+}
+
+// OrderEvent is the detail of the order events of the event bus
+type OrderEvent struct {
+	services.OrderEnvelope
+	Order  services.Order `json:"order"`
+	Reason string         `json:"reason,omitempty"`
+}
+
+// Recipient is a customer or restaurant. Preferences are nil without an opt in.
+type Recipient struct {
+	Name        string
+	Preferences *notify.Preferences
+}
+
+// Directory returns the recipients of an order. found is false for unknown and deleted
+// recipients.
+type Directory interface {
+	Customer(ctx context.Context, userid string) (recipient Recipient, found bool, err error)
+	Restaurant(ctx context.Context, restaurantid string) (recipient Recipient, found bool, err error)
+}
+
+// Data is the template data of messages
+type Data struct {
+	Type       string
+	Name       string // recipient name
+	Restaurant string // restaurant name
+	Order      services.Order
+	Reason     string
+}
+
+type Notifier struct {
+	Directory Directory
+	Templates *templates.Templates
+	Provider  notify.Provider
+}
+
+// Notify sends the messages of the event and returns the sent count. Failed messages do not
+// stop the others; their errors are returned together.
+func (n Notifier) Notify(ctx context.Context, event OrderEvent) (int, error) {
+	logger := logging.FromContext(ctx).With(zap.String("type", event.Type), zap.String("id", event.Id))
+
+	recipients := audiences[event.Type]
+	if len(recipients) == 0 {
+		logger.Debug("order event without notifications")
+		return 0, nil
+	}
+
+	restaurant, _, err := n.Directory.Restaurant(ctx, event.Order.RestaurantId)
+	if err != nil {
+		return 0, err
+	}
+	data := Data{Type: event.Type, Restaurant: restaurant.Name, Order: event.Order, Reason: event.Reason}
+	if data.Restaurant == "" {
+		data.Restaurant = event.Order.RestaurantId
+	}
+
+	sent, errs := 0, []error{}
+	for _, audience := range recipients {
+		recipient, found := restaurant, restaurant.Preferences != nil
+		if audience == Customer {
+			if recipient, found, err = n.Directory.Customer(ctx, event.UserId); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		if !found {
+			continue
+		}
+
+		data.Name = recipient.Name
+		for _, channel := range notify.AllChannels {
+			to := recipient.Preferences.Address(channel)
+			if to == "" {
+				continue
+			}
+
+			message, found, err := n.Templates.Render(recipient.Preferences.LocaleOf(), event.Type, audience, channel, data)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			} else if !found {
+				logger.Warn("notification template not found", zap.String("audience", audience), zap.String("channel", string(channel)))
+				continue
+			}
+			message.Id, message.To = fmt.Sprintf("%s/%s/%s", event.Id, audience, channel), to
+
+			if err := n.Provider.Send(ctx, message); err != nil {
+				logger.Error("notification not sent", zap.String("audience", audience), zap.String("channel", string(channel)), zap.Error(err))
+				metrics.Count(metrics.Notifications, metrics.Dim("Channel", string(channel)), metrics.Dim("Result", "Failed"))
+				errs = append(errs, err)
+				continue
+			}
+			sent++
+			metrics.Count(metrics.Notifications, metrics.Dim("Channel", string(channel)), metrics.Dim("Result", "Sent"))
+		}
+	}
+	return sent, errors.Join(errs...)
+}
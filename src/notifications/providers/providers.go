@@ -0,0 +1,94 @@
+// Package providers delivers notifications with aws: SES email, SNS text messages and SNS
+// mobile push to the platform endpoint of the device.
+package providers
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/kscott5/fds/internal/notify"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	sestypes "github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+// New returns the aws provider of every channel. Email is not available without the
+// sender identity.
+func New(cfg aws.Config, emailFrom string) notify.Channels {
+	client := sns.NewFromConfig(cfg)
+	channels := notify.Channels{
+		notify.SMS:  SMS{Client: client},
+		notify.Push: MobilePush{Client: client},
+	}
+	if emailFrom != "" {
+		channels[notify.Email] = SES{Client: sesv2.NewFromConfig(cfg), From: emailFrom}
+	}
+	return channels
+}
+
+// SES sends text email from the verified identity From
+type SES struct {
+	Client *sesv2.Client
+	From   string
+}
+
+func (s SES) Send(ctx context.Context, message notify.Message) error {
+	_, err := s.Client.SendEmail(ctx, &sesv2.SendEmailInput{
+		FromEmailAddress: aws.String(s.From),
+		Destination:      &sestypes.Destination{ToAddresses: []string{message.To}},
+		Content: &sestypes.EmailContent{Simple: &sestypes.Message{
+			Subject: &sestypes.Content{Data: aws.String(message.Subject), Charset: aws.String("UTF-8")},
+			Body:    &sestypes.Body{Text: &sestypes.Content{Data: aws.String(message.Body), Charset: aws.String("UTF-8")}},
+		}},
+	})
+	return err
+}
+
+// SMS sends transactional text messages
+type SMS struct {
+	Client *sns.Client
+}
+
+func (s SMS) Send(ctx context.Context, message notify.Message) error {
+	_, err := s.Client.Publish(ctx, &sns.PublishInput{
+		PhoneNumber: aws.String(message.To),
+		Message:     aws.String(message.Body),
+		MessageAttributes: map[string]snstypes.MessageAttributeValue{
+			"AWS.SNS.SMS.SMSType": {DataType: aws.String("String"), StringValue: aws.String("Transactional")},
+		},
+	})
+	return err
+}
+
+// MobilePush publishes to the platform endpoint arn with the APNS and FCM payloads
+type MobilePush struct {
+	Client *sns.Client
+}
+
+func (p MobilePush) Send(ctx context.Context, message notify.Message) error {
+	apns, _ := json.Marshal(map[string]interface{}{"aps": map[string]interface{}{
+		"alert": map[string]string{"title": message.Subject, "body": message.Body},
+	}})
+	fcm, _ := json.Marshal(map[string]interface{}{
+		"notification": map[string]string{"title": message.Subject, "body": message.Body},
+	})
+	payload, err := json.Marshal(map[string]string{
+		"default":      message.Body,
+		"APNS":         string(apns),
+		"APNS_SANDBOX": string(apns),
+		"GCM":          string(fcm),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = p.Client.Publish(ctx, &sns.PublishInput{
+		TargetArn:        aws.String(message.To),
+		Message:          aws.String(string(payload)),
+		MessageStructure: aws.String("json"),
+	})
+	return err
+}
@@ -0,0 +1,107 @@
+// Package templates renders the notification messages of order events. Templates are
+// text/template files per locale, audience and event, locales/{locale}/{event}.{audience}.tmpl,
+// that define the blocks of each channel:
+//
+//	{{define "email.subject"}} {{define "email.body"}}
+//	{{define "sms.body"}}
+//	{{define "push.subject"}} {{define "push.body"}}
+//
+// A locale without the template falls back to its language, es-MX to es, and then to
+// notify.DefaultLocale.
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/kscott5/fds/internal/notify"
+)
+
+//go:embed locales
+var files embed.FS
+
+var funcs = template.FuncMap{
+	"money": func(amount float64) string { return fmt.Sprintf("%.2f", amount) },
+	// short order number of messages
+	"short": func(id string) string { return strings.ToUpper(id[:min(8, len(id))]) },
+}
+
+// Templates are keyed by {locale}/{event}.{audience}
+type Templates struct {
+	sets map[string]*template.Template
+}
+
+// Load parses the embedded templates
+func Load() (*Templates, error) {
+	t := &Templates{sets: map[string]*template.Template{}}
+	err := fs.WalkDir(files, "locales", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || path.Ext(name) != ".tmpl" {
+			return err
+		}
+
+		set, err := template.New(path.Base(name)).Funcs(funcs).ParseFS(files, name)
+		if err != nil {
+			return err
+		}
+		locale := path.Base(path.Dir(name))
+		t.sets[locale+"/"+strings.TrimSuffix(path.Base(name), ".tmpl")] = set
+		return nil
+	})
+	return t, err
+}
+
+// MustLoad is Load for package variables
+func MustLoad() *Templates {
+	t, err := Load()
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// candidates returns the locale, its language and the default locale
+func candidates(locale string) []string {
+	locale = strings.ToLower(locale)
+	names := []string{locale}
+	if language, _, found := strings.Cut(locale, "-"); found {
+		names = append(names, language)
+	}
+	return append(names, notify.DefaultLocale)
+}
+
+// Render returns the subject and body of the channel. found is false without a template
+// of the event and audience, e.g. no restaurant message of acknowledged orders.
+func (t *Templates) Render(locale, event, audience string, channel notify.Channel, data interface{}) (message notify.Message, found bool, err error) {
+	name := event + "." + audience
+	for _, candidate := range candidates(locale) {
+		set := t.sets[candidate+"/"+name]
+		if set == nil || set.Lookup(string(channel)+".body") == nil {
+			continue
+		}
+
+		message = notify.Message{Channel: channel, Locale: candidate}
+		if message.Body, err = execute(set, string(channel)+".body", data); err != nil {
+			return message, true, err
+		}
+		if set.Lookup(string(channel)+".subject") != nil {
+			if message.Subject, err = execute(set, string(channel)+".subject", data); err != nil {
+				return message, true, err
+			}
+		}
+		return message, true, nil
+	}
+	return message, false, nil
+}
+
+func execute(set *template.Template, name string, data interface{}) (string, error) {
+	var out bytes.Buffer
+	if err := set.ExecuteTemplate(&out, name, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.String()), nil
+}
@@ -0,0 +1,95 @@
+// Package changes converts the orders table stream into versioned domain events for other
+// systems. Records are converted from the NEW_IMAGE and OLD_IMAGE of the order item:
+//
+//	MODIFY status to acknowledged   OrderAcknowledged
+//	MODIFY status to cancelled      OrderCancelled
+//	MODIFY customer attributes      OrderModified
+//
+// OrderPlaced is written to the outbox with the order and published by the outbox relay.
+// Estimate, rider and other status changes are not published. Events are published with a
+// Publisher: EventBridge, SNS, or a Writer to replay recorded stream records.
+package changes
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/kscott5/fds/internal/outbox"
+	"github.com/kscott5/fds/orders/services"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Event is a domain event of an order change, see the services order events
+type Event = outbox.Event
+
+// customer attributes of OrderModified
+var modifiable = []struct {
+	name  string
+	value func(order services.Order) interface{}
+}{
+	{"restaurantid", func(order services.Order) interface{} { return order.RestaurantId }},
+	{"items", func(order services.Order) interface{} { return order.Items }},
+	{"totalamount", func(order services.Order) interface{} { return order.TotalAmount }},
+	{"tip", func(order services.Order) interface{} { return order.Tip }},
+	{"notes", func(order services.Order) interface{} { return order.Notes }},
+	{"deliveryaddress", func(order services.Order) interface{} { return order.DeliveryAddress }},
+}
+
+func changed(previous, current services.Order) []string {
+	names := []string{}
+	for _, attribute := range modifiable {
+		if !reflect.DeepEqual(attribute.value(previous), attribute.value(current)) {
+			names = append(names, attribute.name)
+		}
+	}
+	return names
+}
+
+// envelope returns the header of the change with the last actor of the order timeline
+func envelope(record events.DynamoDBEventRecord, eventType string, item services.OrderItem) services.OrderEnvelope {
+	order := item.Data
+	if order.ModifiedOn == 0 {
+		order.ModifiedOn = services.UnixMilliTime(record.Change.ApproximateCreationDateTime.UnixMilli())
+	}
+
+	actor := ""
+	if len(item.Events) > 0 {
+		actor = item.Events[len(item.Events)-1].Actor
+	}
+	return services.NewOrderEnvelope(eventType, order, actor)
+}
+
+// Convert returns the domain event of the stream record. nil is returned for changes
+// without an event, e.g. inserted or removed orders and estimate updates.
+func Convert(record events.DynamoDBEventRecord) (Event, error) {
+	if events.DynamoDBOperationType(record.EventName) != events.DynamoDBOperationTypeModify {
+		return nil, nil
+	} else if record.Change.OldImage == nil {
+		return nil, fmt.Errorf("record %s requires the NEW_AND_OLD_IMAGES stream view", record.EventID)
+	}
+
+	current, previous := services.OrderItem{}, services.OrderItem{}
+	if err := unmarshalImage(record.Change.NewImage, &current); err != nil {
+		return nil, fmt.Errorf("record %s new image: %w", record.EventID, err)
+	} else if err := unmarshalImage(record.Change.OldImage, &previous); err != nil {
+		return nil, fmt.Errorf("record %s old image: %w", record.EventID, err)
+	}
+
+	order := current.Data
+	if order.Status != previous.Data.Status {
+		switch order.Status {
+		case services.Cancelled:
+			return services.OrderCancelled{OrderEnvelope: envelope(record, services.OrderCancelledEvent, current), Order: order, Reason: order.CancelReason}, nil
+		case services.Acknowledged:
+			return services.OrderAcknowledged{OrderEnvelope: envelope(record, services.OrderAcknowledgedEvent, current), Order: order}, nil
+		default:
+			return nil, nil
+		}
+	}
+
+	if names := changed(previous.Data, order); len(names) > 0 {
+		return services.OrderModified{OrderEnvelope: envelope(record, services.OrderModifiedEvent, current), Order: order, Changed: names}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,138 @@
+package changes
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/kscott5/fds/orders/services"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const (
+	customer = "12dcc213-5c9d-47b1-be3e-926b77e96d60"
+	first    = "7f3c2a1e-0b5d-4c8e-9a61-2d4f8e6b1c01"
+	second   = "7f3c2a1e-0b5d-4c8e-9a61-2d4f8e6b1c02"
+)
+
+// recorded stream records of two orders: placed, modified, acknowledged and estimated;
+// then placed, cancelled and removed
+func recorded(t *testing.T) events.DynamoDBEvent {
+	data, err := os.ReadFile("../../../data/streams/orders.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	batch := events.DynamoDBEvent{}
+	if err := json.Unmarshal(data, &batch); err != nil {
+		t.Fatal(err)
+	}
+	return batch
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		eventid    string
+		eventType  string
+		orderid    string
+		actor      string
+		occurredOn int64
+		changed    []string
+		reason     string
+	}{
+		{"00000000000000000000000000000001", "", "", "", 0, nil, ""},
+		{"00000000000000000000000000000002", services.OrderModifiedEvent, first, customer, 1791900060000, []string{"notes"}, ""},
+		{"00000000000000000000000000000003", services.OrderAcknowledgedEvent, first, services.OperatorActor, 1791900120000, nil, ""},
+		{"00000000000000000000000000000004", "", "", "", 0, nil, ""},
+		{"00000000000000000000000000000005", "", "", "", 0, nil, ""},
+		{"00000000000000000000000000000006", services.OrderCancelledEvent, second, customer, 1791900230000, nil, "ordered twice"},
+		{"00000000000000000000000000000007", "", "", "", 0, nil, ""},
+	}
+
+	records := map[string]events.DynamoDBEventRecord{}
+	for _, record := range recorded(t).Records {
+		records[record.EventID] = record
+	}
+
+	for _, test := range tests {
+		t.Run(test.eventid, func(t *testing.T) {
+			record, ok := records[test.eventid]
+			if !ok {
+				t.Fatalf("record %s not recorded", test.eventid)
+			}
+
+			event, err := Convert(record)
+			if err != nil {
+				t.Fatal(err)
+			} else if test.eventType == "" {
+				if event != nil {
+					t.Errorf("Convert = %s, want no event", event.Meta().Type)
+				}
+				return
+			} else if event == nil {
+				t.Fatalf("Convert = nil, want %s", test.eventType)
+			}
+
+			meta := event.Meta()
+			if meta.Type != test.eventType || meta.Source != services.EventsSource || meta.Version != services.EventsVersion {
+				t.Errorf("event %s %s v%d, want %s %s v%d", meta.Type, meta.Source, meta.Version, test.eventType, services.EventsSource, services.EventsVersion)
+			} else if meta.Actor != test.actor || meta.OccurredOn != test.occurredOn {
+				t.Errorf("event actor %s on %d, want %s on %d", meta.Actor, meta.OccurredOn, test.actor, test.occurredOn)
+			} else if meta.Id != services.NewOrderEnvelope(test.eventType, services.Order{OrderId: test.orderid, ModifiedOn: services.UnixMilliTime(test.occurredOn)}, "").Id {
+				t.Errorf("event id %s is not the order change id", meta.Id)
+			}
+
+			switch e := event.(type) {
+			case services.OrderModified:
+				if !reflect.DeepEqual(e.Changed, test.changed) {
+					t.Errorf("changed = %v, want %v", e.Changed, test.changed)
+				}
+			case services.OrderCancelled:
+				if e.Reason != test.reason {
+					t.Errorf("reason = %q, want %q", e.Reason, test.reason)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertRequiresOldImage(t *testing.T) {
+	record := events.DynamoDBEventRecord{EventID: "1", EventName: string(events.DynamoDBOperationTypeModify)}
+	if _, err := Convert(record); err == nil {
+		t.Errorf("Convert without the old image = nil, want an error")
+	}
+}
+
+func TestProcessReplay(t *testing.T) {
+	recorder := &Recorder{}
+	response := Process(context.Background(), recorder, recorded(t))
+	if len(response.BatchItemFailures) > 0 {
+		t.Fatalf("batch item failures %v", response.BatchItemFailures)
+	}
+
+	types := []string{}
+	ids := map[string]bool{}
+	for _, event := range recorder.Events {
+		types = append(types, event.Meta().Type)
+		ids[event.Meta().Id] = true
+	}
+
+	want := []string{services.OrderModifiedEvent, services.OrderAcknowledgedEvent, services.OrderCancelledEvent}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("replayed %v, want %v", types, want)
+	} else if len(ids) != len(want) {
+		t.Errorf("replayed %d distinct event ids, want %d", len(ids), len(want))
+	}
+
+	// replaying the same records publishes the same event ids, consumers deduplicate with them
+	again := &Recorder{}
+	Process(context.Background(), again, recorded(t))
+	for _, event := range again.Events {
+		if !ids[event.Meta().Id] {
+			t.Errorf("replayed event id %s changed", event.Meta().Id)
+		}
+	}
+}
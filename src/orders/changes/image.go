@@ -0,0 +1,50 @@
+package changes
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// attributeOf converts a stream attribute to the sdk attribute value
+func attributeOf(av events.DynamoDBAttributeValue) types.AttributeValue {
+	switch av.DataType() {
+	case events.DataTypeBinary:
+		return &types.AttributeValueMemberB{Value: av.Binary()}
+	case events.DataTypeBoolean:
+		return &types.AttributeValueMemberBOOL{Value: av.Boolean()}
+	case events.DataTypeBinarySet:
+		return &types.AttributeValueMemberBS{Value: av.BinarySet()}
+	case events.DataTypeList:
+		list := []types.AttributeValue{}
+		for _, v := range av.List() {
+			list = append(list, attributeOf(v))
+		}
+		return &types.AttributeValueMemberL{Value: list}
+	case events.DataTypeMap:
+		return &types.AttributeValueMemberM{Value: imageOf(av.Map())}
+	case events.DataTypeNumber:
+		return &types.AttributeValueMemberN{Value: av.Number()}
+	case events.DataTypeNumberSet:
+		return &types.AttributeValueMemberNS{Value: av.NumberSet()}
+	case events.DataTypeString:
+		return &types.AttributeValueMemberS{Value: av.String()}
+	case events.DataTypeStringSet:
+		return &types.AttributeValueMemberSS{Value: av.StringSet()}
+	default:
+		return &types.AttributeValueMemberNULL{Value: true}
+	}
+}
+
+func imageOf(image map[string]events.DynamoDBAttributeValue) map[string]types.AttributeValue {
+	item := map[string]types.AttributeValue{}
+	for name, av := range image {
+		item[name] = attributeOf(av)
+	}
+	return item
+}
+
+// unmarshalImage decodes a NEW_IMAGE or OLD_IMAGE with the dynamodbav tags of out
+func unmarshalImage(image map[string]events.DynamoDBAttributeValue, out interface{}) error {
+	return attributevalue.UnmarshalMap(imageOf(image), out)
+}
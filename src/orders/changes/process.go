@@ -0,0 +1,42 @@
+package changes
+
+import (
+	"context"
+
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/internal/metrics"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"go.uber.org/zap"
+)
+
+// Process publishes the events of the stream batch in record order. Records that cannot be
+// converted are logged and skipped, retries would not change them. The first record that
+// fails to publish is returned as the batch item failure so the stream retries from it.
+func Process(ctx context.Context, publisher Publisher, batch events.DynamoDBEvent) events.DynamoDBEventResponse {
+	logger := logging.FromContext(ctx)
+	response := events.DynamoDBEventResponse{BatchItemFailures: []events.DynamoDBBatchItemFailure{}}
+
+	for _, record := range batch.Records {
+		event, err := Convert(record)
+		if err != nil {
+			logger.Error("order stream record skipped", zap.String("eventid", record.EventID), zap.Error(err))
+			metrics.Count(metrics.OrderEvents, metrics.Dim("Type", "Invalid"))
+			continue
+		} else if event == nil {
+			logger.Debug("order stream record without event", zap.String("eventid", record.EventID), zap.String("eventname", record.EventName))
+			continue
+		}
+
+		meta := event.Meta()
+		if err := publisher.Publish(ctx, event); err != nil {
+			logger.Error("order event not published", zap.String("eventid", record.EventID), zap.String("type", meta.Type), zap.Error(err))
+			response.BatchItemFailures = append(response.BatchItemFailures, events.DynamoDBBatchItemFailure{ItemIdentifier: record.Change.SequenceNumber})
+			return response
+		}
+		logger.Info("order event published", zap.String("type", meta.Type), zap.String("id", meta.Id))
+		metrics.Count(metrics.OrderEvents, metrics.Dim("Type", meta.Type))
+	}
+	return response
+}
@@ -0,0 +1,120 @@
+package changes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kscott5/fds/internal/outbox"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+// Publisher sends order events to consumers, in order
+type Publisher interface {
+	Publish(ctx context.Context, events ...Event) error
+}
+
+// EventBridge publishes to the event bus with the outbox record format, see outbox.EventBridge
+type EventBridge struct {
+	Client  *eventbridge.Client
+	BusName string
+}
+
+func (eb EventBridge) Publish(ctx context.Context, events ...Event) error {
+	records := []outbox.Record{}
+	for _, event := range events {
+		record, err := outbox.New(event, time.Now())
+		if err != nil {
+			return err
+		}
+		records = append(records, record)
+	}
+	return outbox.EventBridge{Client: eb.Client, BusName: eb.BusName}.Publish(ctx, records...)
+}
+
+// SNS publishes to the topic. The type and version message attributes allow subscription
+// filter policies.
+type SNS struct {
+	Client   *sns.Client
+	TopicArn string
+}
+
+func (s SNS) Publish(ctx context.Context, events ...Event) error {
+	for _, event := range events {
+		message, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		meta := event.Meta()
+		_, err = s.Client.Publish(ctx, &sns.PublishInput{
+			TopicArn: aws.String(s.TopicArn),
+			Message:  aws.String(string(message)),
+			MessageAttributes: map[string]snstypes.MessageAttributeValue{
+				"type":    {DataType: aws.String("String"), StringValue: aws.String(meta.Type)},
+				"version": {DataType: aws.String("Number"), StringValue: aws.String(fmt.Sprint(meta.Version))},
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Publishers publishes with every publisher
+type Publishers []Publisher
+
+func (ps Publishers) Publish(ctx context.Context, events ...Event) error {
+	var errs []error
+	for _, p := range ps {
+		if err := p.Publish(ctx, events...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Writer writes events as json lines, e.g. to replay recorded stream records
+type Writer struct {
+	W io.Writer
+}
+
+func (w Writer) Publish(ctx context.Context, events ...Event) error {
+	encoder := json.NewEncoder(w.W)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Recorder keeps the published events
+type Recorder struct {
+	Events []Event
+}
+
+func (r *Recorder) Publish(ctx context.Context, events ...Event) error {
+	r.Events = append(r.Events, events...)
+	return nil
+}
+
+// New returns the publisher of the configured event bus and topic, either may be empty
+func New(cfg aws.Config, busName, topicArn string) Publisher {
+	publishers := Publishers{}
+	if busName != "" {
+		publishers = append(publishers, EventBridge{Client: eventbridge.NewFromConfig(cfg), BusName: busName})
+	}
+	if topicArn != "" {
+		publishers = append(publishers, SNS{Client: sns.NewFromConfig(cfg), TopicArn: topicArn})
+	}
+	return publishers
+}
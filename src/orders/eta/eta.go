@@ -0,0 +1,110 @@
+// Package eta estimates when an order is ready, picked up and delivered from the restaurant
+// prep time, the e-bike travel time of a speed profile and the assigned rider's location.
+package eta
+
+import (
+	"time"
+
+	"github.com/kscott5/fds/orders/zones"
+)
+
+// Profile is the e-bike speed profile. Travel distances are the great circle distance times
+// the detour factor of the street grid.
+type Profile struct {
+	SpeedKmh     float64
+	DetourFactor float64
+
+	PrepTime     time.Duration // restaurants without a prep time estimate
+	DispatchTime time.Duration // until a rider without a known location reaches the restaurant
+	HandoffTime  time.Duration // at the restaurant and at the door
+}
+
+var DefaultProfile = Profile{
+	SpeedKmh:     15,
+	DetourFactor: 1.3,
+	PrepTime:     15 * time.Minute,
+	DispatchTime: 8 * time.Minute,
+	HandoffTime:  2 * time.Minute,
+}
+
+// TravelKm is the street distance between the points
+func (p Profile) TravelKm(from, to zones.Point) float64 {
+	return zones.DistanceKm(from, to) * p.DetourFactor
+}
+
+func (p Profile) TravelTime(km float64) time.Duration {
+	if p.SpeedKmh <= 0 {
+		return 0
+	}
+	return time.Duration(km / p.SpeedKmh * float64(time.Hour))
+}
+
+// Basis is the rider information the estimate was made with
+type Basis string
+
+const (
+	// no rider assigned. the profile dispatch time is expected.
+	Dispatching Basis = "dispatching"
+	// rider assigned without a known location
+	Assigned Basis = "assigned"
+	// assigned rider's last location
+	RiderLocation Basis = "riderlocation"
+)
+
+// Estimate times are unix milliseconds
+type Estimate struct {
+	ReadyOn    int64   `json:"readyon" dynamodbav:"readyon"`
+	PickupOn   int64   `json:"pickupon" dynamodbav:"pickupon"`
+	DeliverOn  int64   `json:"deliveron" dynamodbav:"deliveron"`
+	TravelKm   float64 `json:"travelkm" dynamodbav:"travelkm"`
+	Basis      Basis   `json:"basis" dynamodbav:"basis"`
+	ComputedOn int64   `json:"computedon" dynamodbav:"computedon"`
+}
+
+// Input is the order state the estimate is made with. Prep is the restaurant's prep time;
+// zero uses the profile PrepTime. Rider is the assigned rider's last location when known.
+type Input struct {
+	PlacedOn   time.Time
+	Prep       time.Duration
+	Restaurant zones.Point
+	Delivery   zones.Point
+	Assigned   bool
+	Rider      *zones.Point
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// Estimate returns the ready, pickup and delivery times. The food is ready a prep time after
+// placement; the rider picks it up when both the food and the rider are at the restaurant.
+func (p Profile) Estimate(in Input, now time.Time) Estimate {
+	prep := in.Prep
+	if prep <= 0 {
+		prep = p.PrepTime
+	}
+	ready := later(now, in.PlacedOn.Add(prep))
+
+	basis, riderArrives := Dispatching, now.Add(p.DispatchTime)
+	if in.Assigned && in.Rider != nil {
+		basis, riderArrives = RiderLocation, now.Add(p.TravelTime(p.TravelKm(*in.Rider, in.Restaurant)))
+	} else if in.Assigned {
+		basis = Assigned
+	}
+
+	travelKm := p.TravelKm(in.Restaurant, in.Delivery)
+	pickup := later(ready, riderArrives).Add(p.HandoffTime)
+	deliver := pickup.Add(p.TravelTime(travelKm)).Add(p.HandoffTime)
+
+	return Estimate{
+		ReadyOn:    ready.UnixMilli(),
+		PickupOn:   pickup.UnixMilli(),
+		DeliverOn:  deliver.UnixMilli(),
+		TravelKm:   float64(int(travelKm*10+0.5)) / 10,
+		Basis:      basis,
+		ComputedOn: now.UnixMilli(),
+	}
+}
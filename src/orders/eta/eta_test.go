@@ -0,0 +1,97 @@
+package eta
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kscott5/fds/orders/zones"
+)
+
+func TestEstimate(t *testing.T) {
+	// a km a minute along the great circle
+	profile := Profile{SpeedKmh: 60, DetourFactor: 1, PrepTime: 10 * time.Minute, DispatchTime: 5 * time.Minute, HandoffTime: time.Minute}
+
+	placed := time.UnixMilli(1_700_000_000_000)
+	restaurant := zones.Point{Latitude: 47.6097, Longitude: -122.3422}
+	// 0.1 degrees of latitude north, 11.12 km
+	north := zones.Point{Latitude: 47.7097, Longitude: -122.3422}
+	kmMinutes := 11.1195 * float64(time.Minute)
+
+	tests := []struct {
+		name     string
+		in       Input
+		now      time.Duration // after placed
+		basis    Basis
+		ready    time.Duration // after placed
+		pickup   time.Duration
+		deliver  time.Duration
+		travelKm float64
+	}{
+		{"profile prep time", Input{Restaurant: restaurant, Delivery: restaurant}, 2 * time.Minute,
+			Dispatching, 10 * time.Minute, 11 * time.Minute, 12 * time.Minute, 0},
+		{"restaurant prep time", Input{Prep: 20 * time.Minute, Restaurant: restaurant, Delivery: restaurant}, 2 * time.Minute,
+			Dispatching, 20 * time.Minute, 21 * time.Minute, 22 * time.Minute, 0},
+		{"prep time elapsed", Input{Restaurant: restaurant, Delivery: restaurant}, 30 * time.Minute,
+			Dispatching, 30 * time.Minute, 36 * time.Minute, 37 * time.Minute, 0},
+		{"dispatch slower than prep", Input{Prep: time.Minute, Restaurant: restaurant, Delivery: restaurant}, 0,
+			Dispatching, time.Minute, 6 * time.Minute, 7 * time.Minute, 0},
+		{"assigned without location", Input{Restaurant: restaurant, Delivery: restaurant, Assigned: true}, 2 * time.Minute,
+			Assigned, 10 * time.Minute, 11 * time.Minute, 12 * time.Minute, 0},
+		{"rider at the restaurant", Input{Prep: time.Minute, Restaurant: restaurant, Delivery: restaurant, Assigned: true, Rider: &restaurant}, 0,
+			RiderLocation, time.Minute, 2 * time.Minute, 3 * time.Minute, 0},
+		{"rider away", Input{Restaurant: restaurant, Delivery: restaurant, Assigned: true, Rider: &north}, 2 * time.Minute,
+			RiderLocation, 10 * time.Minute, 2*time.Minute + time.Duration(kmMinutes) + time.Minute, 2*time.Minute + time.Duration(kmMinutes) + 2*time.Minute, 0},
+		{"rider location unused before assignment", Input{Restaurant: restaurant, Delivery: restaurant, Rider: &north}, 2 * time.Minute,
+			Dispatching, 10 * time.Minute, 11 * time.Minute, 12 * time.Minute, 0},
+		{"delivery distance", Input{Restaurant: restaurant, Delivery: north}, 2 * time.Minute,
+			Dispatching, 10 * time.Minute, 11 * time.Minute, 12*time.Minute + time.Duration(kmMinutes), 11.1},
+	}
+
+	within := func(got int64, want time.Duration) bool {
+		d := time.UnixMilli(got).Sub(placed.Add(want))
+		return d > -time.Second && d < time.Second
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.in.PlacedOn = placed
+			now := placed.Add(test.now)
+
+			estimate := profile.Estimate(test.in, now)
+			if estimate.Basis != test.basis {
+				t.Errorf("basis = %s, want %s", estimate.Basis, test.basis)
+			} else if !within(estimate.ReadyOn, test.ready) {
+				t.Errorf("ready %v after placed, want %v", time.UnixMilli(estimate.ReadyOn).Sub(placed), test.ready)
+			} else if !within(estimate.PickupOn, test.pickup) {
+				t.Errorf("pickup %v after placed, want %v", time.UnixMilli(estimate.PickupOn).Sub(placed), test.pickup)
+			} else if !within(estimate.DeliverOn, test.deliver) {
+				t.Errorf("deliver %v after placed, want %v", time.UnixMilli(estimate.DeliverOn).Sub(placed), test.deliver)
+			} else if estimate.TravelKm != test.travelKm {
+				t.Errorf("travel = %.1f km, want %.1f", estimate.TravelKm, test.travelKm)
+			} else if estimate.ComputedOn != now.UnixMilli() {
+				t.Errorf("computed on %d, want %d", estimate.ComputedOn, now.UnixMilli())
+			}
+		})
+	}
+}
+
+func TestTravelTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile Profile
+		km      float64
+		want    time.Duration
+	}{
+		{"default profile", DefaultProfile, 5, 20 * time.Minute},
+		{"no distance", DefaultProfile, 0, 0},
+		{"no speed", Profile{}, 5, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.profile.TravelTime(test.km); got != test.want {
+				t.Errorf("TravelTime(%.1f) = %v, want %v", test.km, got, test.want)
+			}
+		})
+	}
+}
@@ -2,36 +2,30 @@ package main
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/config"
+	"github.com/kscott5/fds/internal/push"
+	"github.com/kscott5/fds/internal/router"
 	"github.com/kscott5/fds/orders/services"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	_ "github.com/aws/aws-lambda-go/lambdacontext" // IMPORTANT: package level init() in use.
-
-	"go.uber.org/zap"
 )
 
 func main() {
+	config.MustLoad(&services.Config)
+	services.EtaProfile.SpeedKmh = services.Config.EtaSpeedKmh
+	services.EtaProfile.DetourFactor = services.Config.EtaDetourFactor
+	services.Push = push.New(client.LoadConfig(), client.NewDynamodb(), services.Config.ConnectionsTable, services.Config.WebSocketEndpoint)
+
+	routes := router.New("orders")
+	services.Register(routes)
+
 	// AWS SDK lambda function handler
 	lambdaHandler := lambda.NewHandler(func(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
-		logger, _ := zap.NewDevelopment()
-		logger.Info("FDS lambda.Start orders")
-
-		switch key, _ := client.GetRequestKeyFrom(request.HTTPMethod, request.Resource); key {
-		case "PUT /orders", "PUT /order":
-			return services.CreateOrder(ctx, request)
-		case "PUT /orders/{id}", "PUT /order/{id}":
-			return services.ModifyOrder(ctx, request)
-		case "GET /orders":
-			return services.ListOrders(ctx, request)
-		case "GET /orders/{id}", "GET /order/{id}":
-			return services.GetOrder(ctx, request)
-		default:
-			return nil, fmt.Errorf("(%s) not valid. valid request requires httpmethod and resource", key)
-		}
+		return routes.Dispatch(ctx, request)
 	})
 
 	lambda.Start(lambdaHandler)
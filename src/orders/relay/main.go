@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/config"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/internal/outbox"
+	"github.com/kscott5/fds/internal/tracing"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	_ "github.com/aws/aws-lambda-go/lambdacontext" // IMPORTANT: package level init() in use.
+
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"go.uber.org/zap"
+)
+
+var settings config.Relay
+
+// drain publishes the pending outbox records to the event bus. It is invoked by the outbox
+// table stream when records are written and every minute for records of failed drains;
+// the invocation payload is not used.
+func drain(ctx context.Context, _ json.RawMessage) (err error) {
+	ctx, span := tracing.StartInvocation(ctx, "drain outbox")
+	defer func() { tracing.EndInvocation(ctx, span, err) }()
+
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: outbox relay")
+
+	relay := outbox.Relay{
+		Store:     outbox.Table{DDB: client.NewDynamodb(), TableName: settings.OutboxTable},
+		Publisher: outbox.EventBridge{Client: eventbridge.NewFromConfig(client.LoadConfig()), BusName: settings.EventBusName},
+	}
+
+	published, err := relay.Drain(ctx, time.Now)
+	logger.Info("outbox drained", zap.Int("published", published), zap.Error(err))
+	return err
+}
+
+func main() {
+	config.MustLoad(&settings)
+	lambda.Start(drain)
+}
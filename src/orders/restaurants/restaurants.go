@@ -0,0 +1,38 @@
+// Package restaurants is the restaurant record referenced by order restaurant ids.
+package restaurants
+
+import (
+	"github.com/kscott5/fds/internal/notify"
+	"github.com/kscott5/fds/internal/schema"
+	"github.com/kscott5/fds/orders/services"
+)
+
+const DefaultRestaurantsTable = "FDSAppsRestaurants"
+
+type Restaurant struct {
+	RestaurantId string           `json:"restaurantid" dynamodbav:"restaurantid"`
+	Name         string           `json:"name" dynamodbav:"name"`
+	Cuisine      string           `json:"cuisine,omitempty" dynamodbav:"cuisine,omitempty"`
+	Address      services.Address `json:"address" dynamodbav:"address"`
+	PrepMinutes  int              `json:"prepminutes,omitempty" dynamodbav:"prepminutes,omitempty"`
+
+	// new and cancelled order notifications of the kitchen
+	Notifications *notify.Preferences `json:"notifications,omitempty" dynamodbav:"notifications,omitempty"`
+}
+
+// RestaurantSchema validates restaurant records
+var RestaurantSchema = schema.Object(map[string]*schema.Field{
+	"restaurantid": schema.String().Required().MaxLength(64),
+	"name":         schema.String().Required().MaxLength(128),
+	"cuisine":      schema.String().MaxLength(64),
+	"prepminutes":  schema.Integer().Min(1).Max(180),
+	"address": schema.Object(map[string]*schema.Field{
+		"street":     schema.String().Required().MaxLength(128),
+		"city":       schema.String().Required().MaxLength(64),
+		"state":      schema.String().MaxLength(64),
+		"postalcode": schema.String().MaxLength(16).Pattern(`^[0-9A-Za-z -]+$`),
+		"latitude":   schema.Number().Required().Min(-90).Max(90),
+		"longitude":  schema.Number().Required().Min(-180).Max(180),
+	}).Required(),
+	"notifications": notify.PreferencesSchema,
+})
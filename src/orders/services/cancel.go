@@ -3,88 +3,100 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/internal/metrics"
 
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 
 	"github.com/aws/aws-lambda-go/events"
 	_ "github.com/aws/aws-lambda-go/lambdacontext" // IMPORTANT: package level init() in use.
-
-	"go.uber.org/zap"
 )
 
 func CancelOrder(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
-	logger, _ := zap.NewDevelopment()
-	logger.Info("lambda function: dynamodb modify order")
-	logger.Debug(fmt.Sprintf("%v", request.Body))
-
-	userid, _ := GetUserFromRequestContext(request.RequestContext.Authorizer)
-
-	// extract previous order and validate request.PathParameters.
-	po := Order{}
-	if response, err := GetOrder(ctx, request); err != nil {
-		return nil, err
-	} else if err := json.Unmarshal([]byte(response.Body), &po); err != nil {
-		return nil, err
-	} else if po.UserId != userid || po.Status != Placed {
-		return nil, fmt.Errorf("unable to cancel order after ten minutes")
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb cancel order")
+	logger.Debug("request body", logging.Body(request.Body))
+
+	// the cancel reason is optional
+	body := request.Body
+	if strings.TrimSpace(body) == "" {
+		body = "{}"
+	}
+	if violations := CancelSchema.Validate([]byte(body)); len(violations) > 0 {
+		return client.NewValidationResponse(violations), nil
 	}
 
-	logger.Info("lambda function: processing order updates")
-	logger.Debug(fmt.Sprintf("lambda function: order status %s", po.Status))
-
-	tableName := os.Getenv("FDS_APPS_ORDERS_TABLE")
-	if tableName == "" {
-		tableName = DefaultOrderTable
+	orderid := request.PathParameters["id"]
+	requires := map[string]string{"id": "string"}
+	if orderid == "" {
+		return nil, fmt.Errorf("requires: %s", requires)
 	}
 
-	// extract and validate request body
-	data := Order{}
-	if err := json.Unmarshal([]byte(request.Body), &data); err != nil {
+	cancel := struct {
+		Reason string `json:"reason"`
+	}{}
+	if err := json.Unmarshal([]byte(body), &cancel); err != nil {
 		return nil, err
 	}
 
-	requires := map[string]string{"restaurantid": "string", "totalamount": "decimal", "items": "map"}
-	if data.RestaurantId == "" || len(data.Items) == 0 || data.TotalAmount <= 0 {
-		return nil, fmt.Errorf("requires: %s", requires)
-	}
+	tableName := Config.OrdersTable
 
-	data.Status = Placed
-	data.ModifiedOn = UnixMilliTime(time.Now().UnixMilli())
+	userid, _ := GetUserFromRequestContext(request.RequestContext.Authorizer)
 
-	// current order
-	co := map[string]interface{}{
-		"orderid": data.OrderId,
-		"userid":  data.UserId,
-		"data":    data.Items,
+	now := time.Now().UnixMilli()
+	ub := newUpdateBuilder()
+	if err := ub.set(ub.path("status"), Cancelled); err != nil {
+		return nil, err
+	} else if err := ub.set(ub.path("modifiedon"), now); err != nil {
+		return nil, err
+	} else if err := ub.set(ub.path("cancelreason"), cancel.Reason); err != nil {
+		return nil, err
+	} else if err := ub.appendEvent(OrderEvent{Type: EventCancelled, Status: Cancelled, Actor: userid, OccurredOn: UnixMilliTime(now), Reason: cancel.Reason}); err != nil {
+		return nil, err
+	}
+	ub.remove(ub.path("eta"))
+
+	// orders can only be cancelled while placed within ten minutes
+	condition := ub.placedWithin(now)
+
+	logger.Debug("new dynamodb client session")
+	ddb := client.NewDynamodb()
+	params := dynamodb.UpdateItemInput{
+		TableName:                 aws.String(tableName),
+		Key:                       orderKey(userid, orderid),
+		UpdateExpression:          aws.String(ub.expression()),
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeNames:  ub.names,
+		ExpressionAttributeValues: ub.values,
+		ReturnValues:              types.ReturnValueAllNew,
 	}
 
-	if input, err := attributevalue.MarshalMap(co); err != nil {
+	order := Order{}
+	var conditionFailed *types.ConditionalCheckFailedException
+	if output, err := ddb.UpdateItem(ctx, &params); errors.As(err, &conditionFailed) {
+		return nil, fmt.Errorf("unable to cancel order after ten minutes")
+	} else if err != nil {
+		return nil, err
+	} else if err := attributevalue.Unmarshal(output.Attributes[OrderDataAttribute], &order); err != nil {
 		return nil, err
 	} else {
-		logger.Debug("new dynamodb client session")
-		ddb := client.NewDynamodb(tableName)
-		params := dynamodb.PutItemInput{
-			TableName: aws.String(tableName),
-			Item:      input,
+		metrics.Count(metrics.OrdersCancelled, metrics.Dim("RestaurantId", order.RestaurantId))
+		publishOrder(ctx, order, nil)
+		response := events.APIGatewayProxyResponse{
+			StatusCode: 200,
+			Headers:    client.HttpResponseHeaders,
+			Body:       fmt.Sprintf("{\"orderid\": \"%s\", \"status\": \"%s\"}", orderid, Cancelled),
 		}
 
-		if _, err := ddb.PutItem(ctx, &params); err != nil {
-			return nil, err
-		} else {
-			response := events.APIGatewayProxyResponse{
-				StatusCode: 200,
-				Headers:    client.HttpResponseHeaders,
-				Body:       fmt.Sprintf("{\"orderid\": \"%s\", \"description\": \"updates are complete\"}", co["orderId"]),
-			}
-
-			return &response, nil
-		}
+		return &response, nil
 	}
 }
@@ -4,21 +4,23 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/google/uuid"
 	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/internal/metrics"
+	"github.com/kscott5/fds/internal/outbox"
+	"github.com/kscott5/fds/orders/eta"
 
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 
 	"github.com/aws/aws-lambda-go/events"
 	_ "github.com/aws/aws-lambda-go/lambdacontext" // IMPORTANT: package level init() in use.
-
-	"go.uber.org/zap"
 )
 
 const (
@@ -39,17 +41,13 @@ const (
 
 func (os OrderStatus) MarshalJSON() ([]byte, error) {
 	switch os {
-	case Placed:
-		return []byte("placed"), nil
-	case Acknowledged:
-		return []byte("acknowledged"), nil
-	case Cancelled:
-		return []byte("cancelled"), nil
+	case Placed, Acknowledged, Cancelled, Paused:
+		return json.Marshal(os.String())
 	default:
-		return []byte("invalid"), fmt.Errorf("invalid order status marshal json not available")
+		return nil, fmt.Errorf("invalid order status marshal json not available")
 	}
 }
-func (os *OrderStatus) Unmarshaler(data []byte) error {
+func (os *OrderStatus) UnmarshalJSON(data []byte) error {
 	var v string
 	if err := json.Unmarshal(data, &v); err != nil {
 		return err
@@ -62,6 +60,8 @@ func (os *OrderStatus) Unmarshaler(data []byte) error {
 		*os = Acknowledged
 	case "cancelled":
 		*os = Cancelled
+	case "paused":
+		*os = Paused
 	default:
 		*os = Invalid
 		return fmt.Errorf("order status unmarshaler not available")
@@ -76,16 +76,27 @@ func (os OrderStatus) String() string {
 		return "acknowledged"
 	case Cancelled:
 		return "cancelled"
+	case Paused:
+		return "paused"
 	default:
 		return "invalid"
 	}
 }
 
 type Items struct {
-	ItemId      string  `json:"itemid"`
-	Description string  `json:"description"`
-	Quanity     int     `json:"quanity"`
-	Amount      float64 `json:"amount"`
+	ItemId      string  `json:"itemid" dynamodbav:"itemid"`
+	Description string  `json:"description" dynamodbav:"description"`
+	Quanity     int     `json:"quanity" dynamodbav:"quantity"` // stored as quantity since migration 2
+	Amount      float64 `json:"amount" dynamodbav:"amount"`
+}
+
+type Address struct {
+	Street     string  `json:"street" dynamodbav:"street"`
+	City       string  `json:"city" dynamodbav:"city"`
+	State      string  `json:"state" dynamodbav:"state"`
+	PostalCode string  `json:"postalcode" dynamodbav:"postalcode"`
+	Latitude   float64 `json:"latitude" dynamodbav:"latitude"`
+	Longitude  float64 `json:"longitude" dynamodbav:"longitude"`
 }
 
 type UnixMilliTime int64
@@ -94,82 +105,120 @@ func (ut UnixMilliTime) String() string {
 }
 
 type Order struct {
-	RestaurantId string        `json:"restaurantid"`
-	TotalAmount  float64       `json:"totalamount"`
-	Items        []Items       `json:"items"`
-	OrderId      string        `json:"orderid"`
-	UserId       string        `json:"userid"`
-	Status       OrderStatus   `json:"status"`
-	PlacedOn     UnixMilliTime `json:"placedon"`
-	ModifiedOn   UnixMilliTime `json:"modifiedon"`
+	RestaurantId    string        `json:"restaurantid" dynamodbav:"restaurantid"`
+	TotalAmount     float64       `json:"totalamount" dynamodbav:"totalamount"`
+	Items           []Items       `json:"items" dynamodbav:"items"`
+	Tip             float64       `json:"tip" dynamodbav:"tip"`
+	Notes           string        `json:"notes" dynamodbav:"notes"`
+	DeliveryAddress Address       `json:"deliveryaddress" dynamodbav:"deliveryaddress"`
+	OrderId         string        `json:"orderid" dynamodbav:"orderid"`
+	UserId          string        `json:"userid" dynamodbav:"userid"`
+	Status          OrderStatus   `json:"status,omitempty" dynamodbav:"status"`
+	PlacedOn        UnixMilliTime `json:"placedon" dynamodbav:"placedon"`
+	ModifiedOn      UnixMilliTime `json:"modifiedon" dynamodbav:"modifiedon"`
+	CancelReason    string        `json:"cancelreason,omitempty" dynamodbav:"cancelreason,omitempty"`
+	RiderId         string        `json:"riderid,omitempty" dynamodbav:"riderid,omitempty"`
+	AssignedOn      UnixMilliTime `json:"assignedon,omitempty" dynamodbav:"assignedon,omitempty"`
+	Eta             *eta.Estimate `json:"eta,omitempty" dynamodbav:"eta,omitempty"`
 }
 
-func GetUserFromRequestContext(authorizer map[string]interface{}) (string, error) {
-	userId := NonContextualUserId
+// withServerAttributes returns the order with the cancel and dispatch attributes of the stored
+// order. they are set by the cancel path and dispatch only, a new order has no stored order.
+func (o Order) withServerAttributes(stored Order) Order {
+	o.CancelReason = stored.CancelReason
+	o.RiderId = stored.RiderId
+	o.AssignedOn = stored.AssignedOn
+	return o
+}
 
-	// Cognitio user pool authentication and authorization
-	if cmapper := authorizer["claims"]; cmapper != nil {
-		claims := cmapper.(map[string]string)
-		if found := claims["sub"]; found != "" {
-			userId = claims["sub"]
-		}
+// Dispatchable reports whether dispatch may offer the order to riders
+func (o Order) Dispatchable() bool {
+	return o.Status == Acknowledged && o.RiderId == ""
+}
 
+// OrderItem is the orders table item returned with GET /orders and GET /orders/{id}
+type OrderItem struct {
+	OrderId string       `json:"orderid" dynamodbav:"orderid"`
+	UserId  string       `json:"userid" dynamodbav:"userid"`
+	Data    Order        `json:"data" dynamodbav:"data"`
+	Events  []OrderEvent `json:"events,omitempty" dynamodbav:"events,omitempty"`
+}
+
+func GetUserFromRequestContext(authorizer map[string]interface{}) (string, error) {
+	// Cognito sub of the caller with lambda token or user pool authorizers
+	if userId, err := client.GetPrincipalIdFrom(authorizer); err != nil {
+		return NonContextualUserId, err
+	} else {
 		return userId, nil
 	}
-
-	return userId, fmt.Errorf("user id not found with request context")
 }
 
 func CreateOrder(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
-	logger, _ := zap.NewDevelopment()
+	logger := logging.FromContext(ctx)
 	logger.Info("lambda function: dynamodb create new order")
-	logger.Debug(fmt.Sprintf("%v", request.Body))
+	logger.Debug("request body", logging.Body(request.Body))
 
-	tableName := os.Getenv("FDS_APPS_ORDERS_TABLE")
-	if tableName == "" {
-		tableName = DefaultOrderTable
+	tableName := Config.OrdersTable
+
+	// validate and extract request body
+	if violations := OrderSchema.Validate([]byte(request.Body)); len(violations) > 0 {
+		return client.NewValidationResponse(violations), nil
 	}
 
-	// extract and validate request body
 	data := Order{}
 	if err := json.Unmarshal([]byte(request.Body), &data); err != nil {
 		return nil, err
 	}
 
-	requires := map[string]string{"restaurantid": "string", "totalamount": "decimal", "items": "map"}
-	if data.RestaurantId == "" || len(data.Items) == 0 || data.TotalAmount <= 0 {
-		return nil, fmt.Errorf("requires: %s", requires)
+	logger.Debug("new dynamodb client session")
+	ddb := client.NewDynamodb()
+	restaurant, rejected, err := checkDeliveryZone(ctx, ddb, data.RestaurantId, data.DeliveryAddress)
+	if err != nil || rejected != nil {
+		return rejected, err
 	}
 
+	now := time.Now()
+	data = data.withServerAttributes(Order{})
 	data.UserId, _ = GetUserFromRequestContext(request.RequestContext.Authorizer)
 	data.OrderId = uuid.New().String()
 	data.Status = Placed
-	data.PlacedOn = UnixMilliTime(time.Now().UnixMilli())
+	data.PlacedOn = UnixMilliTime(now.UnixMilli())
 	data.ModifiedOn = data.PlacedOn
+	data.Eta = estimateOf(data, *restaurant, nil, now)
 
-	order := map[string]interface{}{
-		"orderid": data.OrderId,
-		"userid":  data.UserId,
-		"data":    data,
+	order := OrderItem{
+		OrderId: data.OrderId,
+		UserId:  data.UserId,
+		Data:    data,
+		Events:  []OrderEvent{{Type: EventPlaced, Status: Placed, Actor: data.UserId, OccurredOn: data.PlacedOn}},
+	}
+
+	// the OrderPlaced event is committed with the order, the outbox relay publishes it
+	record, err := outbox.New(OrderPlaced{OrderEnvelope: NewOrderEnvelope(OrderPlacedEvent, data, data.UserId), Order: data.WithoutPersonalData()}, now)
+	if err != nil {
+		return nil, err
 	}
 
 	if input, err := attributevalue.MarshalMap(order); err != nil {
 		return nil, err
+	} else if placed, err := outbox.Put(Config.OutboxTable, record); err != nil {
+		return nil, err
 	} else {
-		logger.Debug("new dynamodb client session")
-		ddb := client.NewDynamodb(tableName)
-		params := dynamodb.PutItemInput{
-			TableName: aws.String(tableName),
-			Item:      input,
+		params := dynamodb.TransactWriteItemsInput{
+			TransactItems: []types.TransactWriteItem{
+				{Put: &types.Put{TableName: aws.String(tableName), Item: input}},
+				placed,
+			},
 		}
 
-		if _, err := ddb.PutItem(ctx, &params); err != nil {
+		if _, err := ddb.TransactWriteItems(ctx, &params); err != nil {
 			return nil, err
 		} else {
+			metrics.Count(metrics.OrdersCreated, metrics.Dim("RestaurantId", data.RestaurantId))
 			response := events.APIGatewayProxyResponse{
 				StatusCode: 200,
 				Headers:    client.HttpResponseHeaders,
-				Body:       fmt.Sprintf("{\"orderid\": \"%s\"}", order["orderId"]),
+				Body:       fmt.Sprintf("{\"orderid\": \"%s\"}", data.OrderId),
 			}
 
 			return &response, nil
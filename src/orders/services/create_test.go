@@ -0,0 +1,60 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kscott5/fds/orders/eta"
+)
+
+func TestServerAttributes(t *testing.T) {
+	const order = `"restaurantid": "r-1", "totalamount": 12, "items": [{"itemid": "1", "quanity": 1, "amount": 12}]`
+
+	tests := []struct {
+		name         string
+		body         string
+		stored       Order
+		violation    string
+		dispatchable bool
+		basis        eta.Basis
+	}{
+		{"new order", `{` + order + `}`, Order{}, "", true, eta.Dispatching},
+		{"client rider", `{` + order + `, "riderid": "rider-1"}`, Order{}, "$.riderid", true, eta.Dispatching},
+		{"client assignment", `{` + order + `, "riderid": "rider-1", "assignedon": 1700000000000}`, Order{}, "$.assignedon", true, eta.Dispatching},
+		{"client cancel reason", `{` + order + `, "cancelreason": "ordered twice"}`, Order{}, "$.cancelreason", true, eta.Dispatching},
+		{"stored rider", `{` + order + `, "riderid": "rider-2"}`, Order{RiderId: "rider-1", AssignedOn: 1700000000000}, "$.riderid", false, eta.Assigned},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			violation := ""
+			for _, v := range OrderSchema.Validate([]byte(test.body)) {
+				if v.Path == test.violation {
+					violation = v.Path
+				}
+			}
+			if violation != test.violation {
+				t.Errorf("violations of %s = %v, want %q", test.body, OrderSchema.Validate([]byte(test.body)), test.violation)
+			}
+
+			// the body is applied even when validation is bypassed
+			data := Order{}
+			if err := json.Unmarshal([]byte(test.body), &data); err != nil {
+				t.Fatal(err)
+			}
+			data = data.withServerAttributes(test.stored)
+			data.Status = Acknowledged
+
+			if data.RiderId != test.stored.RiderId || data.AssignedOn != test.stored.AssignedOn {
+				t.Errorf("rider %s assigned on %d, want %s on %d", data.RiderId, data.AssignedOn, test.stored.RiderId, test.stored.AssignedOn)
+			} else if data.CancelReason != test.stored.CancelReason {
+				t.Errorf("cancel reason = %q, want %q", data.CancelReason, test.stored.CancelReason)
+			} else if data.Dispatchable() != test.dispatchable {
+				t.Errorf("Dispatchable = %t, want %t", data.Dispatchable(), test.dispatchable)
+			} else if estimate := estimateOf(data, restaurantSite{}, nil, time.Now()); estimate.Basis != test.basis {
+				t.Errorf("estimate basis = %s, want %s", estimate.Basis, test.basis)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/kscott5/fds/orders/eta"
+	"github.com/kscott5/fds/orders/zones"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// EtaProfile is the e-bike speed profile of order estimates. Lambda mains set it from config.
+var EtaProfile = eta.DefaultProfile
+
+// restaurantSite is the restaurant address and prep time. restaurants.Restaurant imports this package.
+type restaurantSite struct {
+	Address     Address `dynamodbav:"address"`
+	PrepMinutes int     `dynamodbav:"prepminutes"`
+}
+
+// getRestaurantSite returns nil when the restaurant does not exist
+func getRestaurantSite(ctx context.Context, ddb *dynamodb.Client, tableName, restaurantid string) (*restaurantSite, error) {
+	output, err := ddb.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key:       map[string]types.AttributeValue{"restaurantid": &types.AttributeValueMemberS{Value: restaurantid}},
+	})
+	if err != nil || output.Item == nil {
+		return nil, err
+	}
+
+	site := restaurantSite{}
+	if err := attributevalue.UnmarshalMap(output.Item, &site); err != nil {
+		return nil, err
+	}
+	return &site, nil
+}
+
+// estimateOf returns nil for cancelled orders. rider is the assigned rider's last location when known.
+func estimateOf(order Order, site restaurantSite, rider *zones.Point, now time.Time) *eta.Estimate {
+	if order.Status == Cancelled || order.Status == Invalid {
+		return nil
+	}
+
+	estimate := EtaProfile.Estimate(eta.Input{
+		PlacedOn:   time.UnixMilli(int64(order.PlacedOn)),
+		Prep:       time.Duration(site.PrepMinutes) * time.Minute,
+		Restaurant: zones.Point{Latitude: site.Address.Latitude, Longitude: site.Address.Longitude},
+		Delivery:   zones.Point{Latitude: order.DeliveryAddress.Latitude, Longitude: order.DeliveryAddress.Longitude},
+		Assigned:   order.RiderId != "",
+		Rider:      rider,
+	}, now)
+	return &estimate
+}
+
+// EtaTables are the tables read and written by RefreshEta
+type EtaTables struct {
+	Orders      string
+	Restaurants string
+}
+
+// RefreshEta stores a new estimate of the order with its current status and rider and pushes
+// the order to its subscribers. Estimates computed within maxAge are kept, e.g. between rider
+// location pings, and only the rider location is pushed. nil is returned when the order does
+// not exist or the estimate was kept.
+func RefreshEta(ctx context.Context, ddb *dynamodb.Client, tables EtaTables, userid, orderid string, rider *zones.Point, maxAge time.Duration) (*Order, error) {
+	output, err := ddb.GetItem(ctx, &dynamodb.GetItemInput{TableName: aws.String(tables.Orders), Key: orderKey(userid, orderid)})
+	if err != nil || output.Item == nil {
+		return nil, err
+	}
+
+	item := OrderItem{}
+	if err := attributevalue.UnmarshalMap(output.Item, &item); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	order := item.Data
+	if order.Eta != nil && maxAge > 0 && now.Sub(time.UnixMilli(order.Eta.ComputedOn)) < maxAge {
+		if rider != nil {
+			publishOrder(ctx, order, rider)
+		}
+		return nil, nil
+	}
+
+	site, err := getRestaurantSite(ctx, ddb, tables.Restaurants, order.RestaurantId)
+	if err != nil {
+		return nil, err
+	} else if site == nil {
+		site = &restaurantSite{}
+	}
+
+	ub := newUpdateBuilder()
+	if order.Eta = estimateOf(order, *site, rider, now); order.Eta == nil {
+		ub.remove(ub.path("eta"))
+	} else if err := ub.set(ub.path("eta"), order.Eta); err != nil {
+		return nil, err
+	}
+
+	params := dynamodb.UpdateItemInput{
+		TableName:                 aws.String(tables.Orders),
+		Key:                       orderKey(userid, orderid),
+		UpdateExpression:          aws.String(ub.expression()),
+		ConditionExpression:       aws.String("attribute_exists(orderid)"),
+		ExpressionAttributeNames:  ub.names,
+		ExpressionAttributeValues: ub.values,
+	}
+	if len(ub.values) == 0 {
+		params.ExpressionAttributeValues = nil
+	}
+
+	var conditionFailed *types.ConditionalCheckFailedException
+	if _, err := ddb.UpdateItem(ctx, &params); errors.As(err, &conditionFailed) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	publishOrder(ctx, order, rider)
+	return &order, nil
+}
@@ -4,14 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 	
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/logging"
 
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 
 	"github.com/aws/aws-lambda-go/events"
 	_ "github.com/aws/aws-lambda-go/lambdacontext" // IMPORTANT: package level init() in use.
@@ -20,14 +19,11 @@ import (
 )
 
 func GetOrder(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
-	logger, _ := zap.NewDevelopment()
+	logger := logging.FromContext(ctx)
 	logger.Info("lambda function: dynamodb get order")
-	logger.Debug(fmt.Sprint(request.PathParameters))
+	logger.Debug("path parameters", zap.Any("parameters", request.PathParameters))
 
-	tableName := os.Getenv("FDS_APPS_ORDERS_TABLE")
-	if tableName == "" {
-		tableName = DefaultOrderTable
-	}
+	tableName := Config.OrdersTable
 
 	orderid := request.PathParameters["id"]
 	requires := map[string]string{"id": "string"}
@@ -35,32 +31,32 @@ func GetOrder(ctx context.Context, request *events.APIGatewayProxyRequest) (*eve
 		return nil, fmt.Errorf("requires: %s", requires)
 	}
 	
-	userid, _ := attributevalue.Marshal("PLACEHOLDER")
-	attr, _ := attributevalue.Marshal(orderid)
-	key := map[string]types.AttributeValue{
-		"orderid": attr,
-		"userid": userid,
+	// orders are keyed by the caller's Cognito sub
+	userid, err := GetUserFromRequestContext(request.RequestContext.Authorizer)
+	if err != nil {
+		return client.NewErrorResponse(403, err.Error()), nil
 	}
 
-	ddb := client.NewDynamodb(tableName)
+	ddb := client.NewDynamodb()
 	params := dynamodb.GetItemInput{
 		TableName: aws.String(tableName),
-		Key:       key,
+		Key:       orderKey(userid, orderid),
 	}
 
-	out := map[string]interface{}{}
+	item := OrderItem{}
 	if output, err := ddb.GetItem(ctx, &params); err != nil {
 		return nil, err
-	} else if err := attributevalue.UnmarshalMap(output.Item, &out); err != nil {
+	} else if output.Item == nil {
+		return client.NewErrorResponse(404, fmt.Sprintf("order %s not found", orderid)), nil
+	} else if err := attributevalue.UnmarshalMap(output.Item, &item); err != nil {
 		return nil, err
-	} else if body, err := json.Marshal(out); err != nil {
+	} else if body, err := json.Marshal(item.Data); err != nil {
 		return nil, err
 	} else {
 		response := events.APIGatewayProxyResponse{
-			StatusCode:      200,
-			Headers:         client.HttpResponseHeaders,
-			Body:            string(body),
-			IsBase64Encoded: true,
+			StatusCode: 200,
+			Headers:    client.HttpResponseHeaders,
+			Body:       string(body),
 		}
 
 		return &response, nil
@@ -68,31 +64,37 @@ func GetOrder(ctx context.Context, request *events.APIGatewayProxyRequest) (*eve
 }
 
 func ListOrders(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
-	logger, _ := zap.NewDevelopment()
+	logger := logging.FromContext(ctx)
 	logger.Info("lambda function: dynamodb list orders")
-	logger.Debug(fmt.Sprintf("%v", request.Body))
+	logger.Debug("request body", logging.Body(request.Body))
 
-	tableName := os.Getenv("FDS_APPS_ORDERS_TABLE")
-	if tableName == "" {
-		tableName = "FDSAppsOrders"
+	tableName := Config.OrdersTable
+
+	limit, startKey, err := client.GetPageFrom(request.QueryStringParameters)
+	if err != nil {
+		return client.NewErrorResponse(400, err.Error()), nil
 	}
 
-	ddb := client.NewDynamodb(tableName)
+	ddb := client.NewDynamodb()
 	params := dynamodb.ScanInput{
-		TableName: aws.String(tableName),
+		TableName:         aws.String(tableName),
+		Limit:             aws.Int32(limit),
+		ExclusiveStartKey: startKey,
 	}
 
-	var out interface{}
+	out := []interface{}{}
 	if output, err := ddb.Scan(ctx, &params); err != nil {
 		return nil, err
 	} else if err := attributevalue.UnmarshalListOfMaps(output.Items, &out); err != nil {
 		return nil, err
 	} else if body, err := json.Marshal(out); err != nil{
 		return nil, err
+	} else if headers, err := client.NewPageHeaders(output.LastEvaluatedKey); err != nil {
+		return nil, err
 	} else {
 		response := events.APIGatewayProxyResponse{
 			StatusCode: 200,
-			Headers:    client.HttpResponseHeaders,
+			Headers:    headers,
 			Body:       string(body),
 		}
 
@@ -3,15 +3,18 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/internal/metrics"
 
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 
 	"github.com/aws/aws-lambda-go/events"
 	_ "github.com/aws/aws-lambda-go/lambdacontext" // IMPORTANT: package level init() in use.
@@ -23,71 +26,95 @@ const (
 )
 
 func ModifyOrder(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
-	logger, _ := zap.NewDevelopment()
+	logger := logging.FromContext(ctx)
 	logger.Info("lambda function: dynamodb modify order")
-	logger.Debug(fmt.Sprintf("%v", request.Body))
+	logger.Debug("request body", logging.Body(request.Body))
+
+	if violations := OrderSchema.Validate([]byte(request.Body)); len(violations) > 0 {
+		return client.NewValidationResponse(violations), nil
+	}
 
 	userid, _ := GetUserFromRequestContext(request.RequestContext.Authorizer)
 
-	// extract previous order and validate request.PathParameters with PUT /orders/{orderid} or PUT /order/{orderid}
-	po := Order{}
-	if response, err := GetOrder(ctx, request); err != nil {
+	orderid := request.PathParameters["id"]
+	requires := map[string]string{"id": "string"}
+	if orderid == "" {
+		return nil, fmt.Errorf("requires: %s", requires)
+	}
+
+	tableName := Config.OrdersTable
+	ddb := client.NewDynamodb()
+
+	// extract previous order of the caller with PUT /orders/{orderid} or PUT /order/{orderid}
+	previous := OrderItem{}
+	if output, err := ddb.GetItem(ctx, &dynamodb.GetItemInput{TableName: aws.String(tableName), Key: orderKey(userid, orderid)}); err != nil {
 		return nil, err
-	} else if err := json.Unmarshal([]byte(response.Body), &po); err != nil {
+	} else if output.Item == nil {
+		return client.NewErrorResponse(404, fmt.Sprintf("order %s not found", orderid)), nil
+	} else if err := attributevalue.UnmarshalMap(output.Item, &previous); err != nil {
 		return nil, err
-	} else if po.UserId != userid || po.Status == Acknowledged || (time.Now().UnixMilli() - int64(po.PlacedOn)) > MaxElapseTimeMilliSecs {
+	}
+
+	po := previous.Data
+	if po.Status != Placed || (time.Now().UnixMilli() - int64(po.PlacedOn)) > MaxElapseTimeMilliSecs {
 		return nil, fmt.Errorf("order updates not acceptable. previous order was acknowledged")
 	}
 
 	logger.Info("lambda function: processing order updates")
-	logger.Debug(fmt.Sprintf("lambda function: order status %s", po.Status))
-
-	tableName := os.Getenv("FDS_APPS_ORDERS_TABLE")
-	if tableName == "" {
-		tableName = DefaultOrderTable
-	}
+	logger.Debug("lambda function: order status", zap.Stringer("status", po.Status))
 
-	// extract and validate request body
+	// extract request body
 	data := Order{}
 	if err :=json.Unmarshal([]byte(request.Body), &data); err != nil {
 		return nil, err
 	}
 
-	requires := map[string]string{"restaurantid": "string", "totalamount": "decimal", "items": "map"}
-	if data.RestaurantId == "" || len(data.Items) == 0  || data.TotalAmount <= 0  {
-		return nil, fmt.Errorf("requires: %s", requires)
+	restaurant, rejected, err := checkDeliveryZone(ctx, ddb, data.RestaurantId, data.DeliveryAddress)
+	if err != nil || rejected != nil {
+		return rejected, err
 	}
 
+	// the order keys and placement are not replaced with the body
+	now := time.Now()
+	data = data.withServerAttributes(po)
+	data.OrderId = previous.OrderId
+	data.UserId = previous.UserId
 	data.Status = Placed
-	data.ModifiedOn = UnixMilliTime(time.Now().UnixMilli())
+	data.PlacedOn = po.PlacedOn
+	data.ModifiedOn = UnixMilliTime(now.UnixMilli())
+	data.Eta = estimateOf(data, *restaurant, nil, now)
 
-	// current order
-	co := map[string]interface{}{
-		"orderid": data.OrderId,
-		"userid": data.UserId,
-		"data": data.Items,
+	// the order data is replaced. the timeline outside the data is kept.
+	ub := newUpdateBuilder()
+	if err := ub.set(ub.name(OrderDataAttribute), data); err != nil {
+		return nil, err
+	} else if err := ub.appendEvent(OrderEvent{Type: EventModified, Status: Placed, Actor: userid, OccurredOn: data.ModifiedOn}); err != nil {
+		return nil, err
 	}
 
-	if input, err := attributevalue.MarshalMap(co); err != nil {
+	params := dynamodb.UpdateItemInput{
+		TableName:                 aws.String(tableName),
+		Key:                       orderKey(data.UserId, data.OrderId),
+		UpdateExpression:          aws.String(ub.expression()),
+		ConditionExpression:       aws.String(ub.placedWithin(now.UnixMilli())),
+		ExpressionAttributeNames:  ub.names,
+		ExpressionAttributeValues: ub.values,
+	}
+
+	var conditionFailed *types.ConditionalCheckFailedException
+	if _, err := ddb.UpdateItem(ctx, &params); errors.As(err, &conditionFailed) {
+		return nil, fmt.Errorf("order updates not acceptable. previous order was acknowledged")
+	} else if err != nil {
 		return nil, err
 	} else {
-		logger.Debug("new dynamodb client session")
-		ddb := client.NewDynamodb(tableName)
-		params := dynamodb.PutItemInput{
-			TableName: aws.String(tableName),
-			Item:      input,
+		metrics.Count(metrics.OrdersModified, metrics.Dim("RestaurantId", po.RestaurantId))
+		publishOrder(ctx, data, nil)
+		response := events.APIGatewayProxyResponse{
+			StatusCode: 200,
+			Headers:    client.HttpResponseHeaders,
+			Body:       fmt.Sprintf("{\"orderid\": \"%s\", \"description\": \"updates are complete\"}", orderid),
 		}
 
-		if _, err := ddb.PutItem(ctx, &params); err != nil {
-			return nil, err
-		} else {
-			response := events.APIGatewayProxyResponse{
-				StatusCode: 200,
-				Headers:    client.HttpResponseHeaders,
-				Body:       fmt.Sprintf("{\"orderid\": \"%s\", \"description\": \"updates are complete\"}", co["orderId"]),
-			}
-
-			return &response, nil
-		}
+		return &response, nil
 	}
 }
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"github.com/kscott5/fds/internal/outbox"
+)
+
+// Domain events of order changes, published to other systems. OrderPlaced is written to the
+// outbox with the order; the others are converted from the orders table stream.
+const (
+	EventsSource = "fds.orders"
+
+	// EventsVersion is the event schema version. Changes consumers must handle increment it.
+	EventsVersion = 1
+
+	OrderPlacedEvent       = "OrderPlaced"
+	OrderModifiedEvent     = "OrderModified"
+	OrderCancelledEvent    = "OrderCancelled"
+	OrderAcknowledgedEvent = "OrderAcknowledged"
+)
+
+// OrderEnvelope is the event header with the order keys
+type OrderEnvelope struct {
+	outbox.Envelope
+	OrderId string `json:"orderid"`
+	UserId  string `json:"userid"`
+}
+
+// NewOrderEnvelope returns the header of the order change at order.ModifiedOn
+func NewOrderEnvelope(eventType string, order Order, actor string) OrderEnvelope {
+	return OrderEnvelope{
+		Envelope: outbox.Envelope{
+			Id:         outbox.EventId(EventsSource, order.OrderId, eventType, int64(order.ModifiedOn)),
+			Type:       eventType,
+			Version:    EventsVersion,
+			Source:     EventsSource,
+			OccurredOn: int64(order.ModifiedOn),
+			Actor:      actor,
+		},
+		OrderId: order.OrderId,
+		UserId:  order.UserId,
+	}
+}
+
+// WithoutPersonalData returns the order without the delivery address and notes. Outbox
+// records are retained after the customer's erasure, so OrderPlaced carries the order ids,
+// items and amounts; consumers read the rest from the order.
+func (order Order) WithoutPersonalData() Order {
+	order.DeliveryAddress = Address{}
+	order.Notes = ""
+	return order
+}
+
+type OrderPlaced struct {
+	OrderEnvelope
+	Order Order `json:"order"`
+}
+
+// OrderModified lists the changed order attributes
+type OrderModified struct {
+	OrderEnvelope
+	Order   Order    `json:"order"`
+	Changed []string `json:"changed"`
+}
+
+type OrderCancelled struct {
+	OrderEnvelope
+	Order  Order  `json:"order"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type OrderAcknowledged struct {
+	OrderEnvelope
+	Order Order `json:"order"`
+}
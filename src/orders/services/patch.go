@@ -0,0 +1,723 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/internal/metrics"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/aws/aws-lambda-go/events"
+	_ "github.com/aws/aws-lambda-go/lambdacontext" // IMPORTANT: package level init() in use.
+
+	"go.uber.org/zap"
+)
+
+const (
+	// RFC 7396 JSON Merge Patch
+	MergePatchContentType = "application/merge-patch+json"
+	// RFC 6902 JSON Patch
+	JsonPatchContentType = "application/json-patch+json"
+
+	// order attributes are stored in the data map of the dynamodb item
+	OrderDataAttribute = "data"
+)
+
+// address attributes and their json types available with patch requests
+var patchableAddress = map[string]string{
+	"street":     "string",
+	"city":       "string",
+	"state":      "string",
+	"postalcode": "string",
+	"latitude":   "number",
+	"longitude":  "number",
+}
+
+// item attributes and their json types available with patch requests
+var patchableItem = map[string]string{
+	"itemid":      "string",
+	"description": "string",
+	"quanity":     "number",
+	"amount":      "number",
+}
+
+// item attributes stored with a name other than the json name
+var storedItemAttribute = map[string]string{
+	"quanity": "quantity",
+}
+
+// JsonPatchOperation is a single RFC 6902 operation
+type JsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// updateBuilder translates patch documents into a dynamodb update expression.
+// Every document path is relative to the order data attribute.
+type updateBuilder struct {
+	sets    []string
+	removes []string
+	names   map[string]string
+	values  map[string]types.AttributeValue
+}
+
+func newUpdateBuilder() *updateBuilder {
+	return &updateBuilder{
+		names:  map[string]string{},
+		values: map[string]types.AttributeValue{},
+	}
+}
+
+func (ub *updateBuilder) name(attribute string) string {
+	placeholder := fmt.Sprintf("#%s", attribute)
+	ub.names[placeholder] = attribute
+	return placeholder
+}
+
+// path builds a document path. string segments are attribute names and int segments are list indexes.
+func (ub *updateBuilder) path(segments ...interface{}) string {
+	builder := strings.Builder{}
+	builder.WriteString(ub.name(OrderDataAttribute))
+	for _, segment := range segments {
+		switch v := segment.(type) {
+		case int:
+			fmt.Fprintf(&builder, "[%d]", v)
+		case string:
+			fmt.Fprintf(&builder, ".%s", ub.name(v))
+		}
+	}
+	return builder.String()
+}
+
+func (ub *updateBuilder) value(v interface{}) (string, error) {
+	attr, err := attributevalue.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	placeholder := fmt.Sprintf(":v%d", len(ub.values))
+	ub.values[placeholder] = attr
+	return placeholder, nil
+}
+
+func (ub *updateBuilder) set(path string, v interface{}) error {
+	placeholder, err := ub.value(v)
+	if err != nil {
+		return err
+	}
+
+	ub.sets = append(ub.sets, fmt.Sprintf("%s = %s", path, placeholder))
+	return nil
+}
+
+// appendList appends the elements of the list
+func (ub *updateBuilder) appendList(path string, list interface{}) error {
+	placeholder, err := ub.value(list)
+	if err != nil {
+		return err
+	}
+
+	ub.sets = append(ub.sets, fmt.Sprintf("%s = list_append(%s, %s)", path, path, placeholder))
+	return nil
+}
+
+func (ub *updateBuilder) remove(path string) {
+	ub.removes = append(ub.removes, path)
+}
+
+func (ub *updateBuilder) expression() string {
+	expression := []string{}
+	if len(ub.sets) > 0 {
+		expression = append(expression, "SET "+strings.Join(ub.sets, ", "))
+	}
+	if len(ub.removes) > 0 {
+		expression = append(expression, "REMOVE "+strings.Join(ub.removes, ", "))
+	}
+	return strings.Join(expression, " ")
+}
+
+// decodeTyped decodes a json value with the json type of a patchable attribute
+func decodeTyped(kind string, raw json.RawMessage) (interface{}, error) {
+	switch kind {
+	case "string":
+		var v string
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	default:
+		var v float64
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	}
+}
+
+func decodeItems(raw json.RawMessage) ([]Items, error) {
+	items := []Items{}
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("requires: at least one item")
+	}
+	for _, item := range items {
+		if err := validateItem(item); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func decodeItem(raw json.RawMessage) (Items, error) {
+	item := Items{}
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return item, err
+	}
+	return item, validateItem(item)
+}
+
+func validateItem(item Items) error {
+	requires := map[string]string{"itemid": "string", "quanity": "int > 0", "amount": "decimal >= 0"}
+	if item.ItemId == "" || item.Quanity <= 0 || item.Amount < 0 {
+		return fmt.Errorf("requires: %s", requires)
+	}
+	return nil
+}
+
+func decodeTip(raw json.RawMessage) (float64, error) {
+	var tip float64
+	if err := json.Unmarshal(raw, &tip); err != nil {
+		return 0, err
+	}
+	if tip < 0 {
+		return 0, fmt.Errorf("requires: tip >= 0")
+	}
+	return tip, nil
+}
+
+// patchTarget is the stored order a patch document is applied to. item changes are applied
+// to a copy of the stored items to recompute the order total.
+type patchTarget struct {
+	items []Items
+	// the items were patched
+	changed bool
+	// the stored order has a delivery address, removed addresses have no fields to patch
+	hasAddress bool
+}
+
+func newPatchTarget(order Order, hasAddress bool) *patchTarget {
+	return &patchTarget{items: append([]Items{}, order.Items...), hasAddress: hasAddress}
+}
+
+// hasDataMap reports whether the stored order item has the map attribute in its data
+func hasDataMap(item map[string]types.AttributeValue, attribute string) bool {
+	if data, ok := item[OrderDataAttribute].(*types.AttributeValueMemberM); ok {
+		_, found := data.Value[attribute].(*types.AttributeValueMemberM)
+		return found
+	}
+	return false
+}
+
+func (pt *patchTarget) setItems(items []Items) {
+	pt.items, pt.changed = items, true
+}
+
+func (pt *patchTarget) appendItem(item Items) {
+	pt.items, pt.changed = append(pt.items, item), true
+}
+
+// item returns the stored item of the index. indexes past the last item are not found.
+func (pt *patchTarget) item(path string, index int) (*Items, error) {
+	if index >= len(pt.items) {
+		return nil, fmt.Errorf("%s not found. the order has %d items", path, len(pt.items))
+	}
+	pt.changed = true
+	return &pt.items[index], nil
+}
+
+func (pt *patchTarget) removeItem(path string, index int) error {
+	if _, err := pt.item(path, index); err != nil {
+		return err
+	}
+	pt.items = append(pt.items[:index], pt.items[index+1:]...)
+	return nil
+}
+
+func (pt *patchTarget) setItemField(path string, index int, field string, v interface{}) error {
+	item, err := pt.item(path, index)
+	if err != nil {
+		return err
+	}
+
+	switch field {
+	case "itemid":
+		item.ItemId = v.(string)
+	case "description":
+		item.Description = v.(string)
+	case "quanity":
+		if quantity := v.(float64); quantity != math.Trunc(quantity) {
+			return fmt.Errorf("requires: %s integer", path)
+		} else {
+			item.Quanity = int(quantity)
+		}
+	case "amount":
+		item.Amount = v.(float64)
+	}
+	return validateItem(*item)
+}
+
+// setTotal sets the order total of patched items, the sum of the item amounts by quantity
+func (pt *patchTarget) setTotal(ub *updateBuilder) error {
+	if !pt.changed {
+		return nil
+	}
+
+	total := 0.0
+	for _, item := range pt.items {
+		total += item.Amount * float64(item.Quanity)
+	}
+	total = math.Round(total*100) / 100
+
+	if len(pt.items) == 0 {
+		return fmt.Errorf("items can not be removed from an order")
+	} else if len(pt.items) > MaxOrderItems {
+		return fmt.Errorf("requires: at most %d items", MaxOrderItems)
+	} else if total < 0.01 || total > MaxOrderAmount {
+		return fmt.Errorf("requires: totalamount between 0.01 and %d, patched items total %.2f", MaxOrderAmount, total)
+	}
+	return ub.set(ub.path("totalamount"), total)
+}
+
+func isNull(raw json.RawMessage) bool {
+	return len(raw) == 0 || strings.TrimSpace(string(raw)) == "null"
+}
+
+// applyMergePatch translates a RFC 7396 merge patch document. null values remove the attribute.
+// patched items replace the order total. address fields of a removed address add a new address.
+func applyMergePatch(ub *updateBuilder, body []byte, target *patchTarget) error {
+	doc := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return err
+	}
+
+	for attribute, raw := range doc {
+		switch attribute {
+		case "items":
+			if isNull(raw) {
+				return fmt.Errorf("items can not be removed from an order")
+			} else if items, err := decodeItems(raw); err != nil {
+				return err
+			} else if err := ub.set(ub.path("items"), items); err != nil {
+				return err
+			} else {
+				target.setItems(items)
+			}
+		case "tip":
+			if isNull(raw) {
+				ub.remove(ub.path("tip"))
+			} else if tip, err := decodeTip(raw); err != nil {
+				return err
+			} else if err := ub.set(ub.path("tip"), tip); err != nil {
+				return err
+			}
+		case "notes":
+			var notes string
+			if isNull(raw) {
+				ub.remove(ub.path("notes"))
+			} else if err := json.Unmarshal(raw, &notes); err != nil {
+				return err
+			} else if err := ub.set(ub.path("notes"), notes); err != nil {
+				return err
+			}
+		case "deliveryaddress":
+			if isNull(raw) {
+				ub.remove(ub.path("deliveryaddress"))
+				continue
+			}
+
+			fields := map[string]json.RawMessage{}
+			if err := json.Unmarshal(raw, &fields); err != nil {
+				return err
+			}
+
+			address := map[string]interface{}{}
+			for field, fieldRaw := range fields {
+				kind, ok := patchableAddress[field]
+				if !ok {
+					return fmt.Errorf("deliveryaddress/%s not patchable", field)
+				} else if !target.hasAddress {
+					if v, err := decodeTyped(kind, fieldRaw); err != nil {
+						return err
+					} else if !isNull(fieldRaw) {
+						address[field] = v
+					}
+				} else if isNull(fieldRaw) {
+					ub.remove(ub.path("deliveryaddress", field))
+				} else if v, err := decodeTyped(kind, fieldRaw); err != nil {
+					return err
+				} else if err := ub.set(ub.path("deliveryaddress", field), v); err != nil {
+					return err
+				}
+			}
+
+			// the address map is added, nested paths of a missing map are not valid
+			if len(address) > 0 {
+				if err := ub.set(ub.path("deliveryaddress"), address); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("%s not patchable. patchable: items, tip, notes, deliveryaddress", attribute)
+		}
+	}
+
+	return target.setTotal(ub)
+}
+
+// patchPaths checks that the json patch operations have the same result applied together
+// in one update expression as applied in order, as RFC 6902 requires
+type patchPaths struct {
+	touched []string
+	// an item was added with /items/-
+	appended bool
+	// an item was removed, the following item indexes changed
+	shifted bool
+}
+
+func (pp *patchPaths) check(operation JsonPatchOperation, segments []string) error {
+	path := "/" + strings.Join(segments, "/")
+	items := segments[0] == "items"
+
+	if items && len(segments) == 2 && segments[1] == "-" {
+		for _, touched := range pp.touched {
+			if touched == "/items" || strings.HasPrefix(touched, "/items/") {
+				return fmt.Errorf("json patch %s %s overlaps %s. send item changes in another request", operation.Op, operation.Path, touched)
+			}
+		}
+		pp.appended = true
+		return nil
+	} else if items && pp.appended {
+		return fmt.Errorf("json patch %s %s overlaps /items/-. send item changes in another request", operation.Op, operation.Path)
+	} else if items && pp.shifted && len(segments) > 1 {
+		return fmt.Errorf("json patch %s %s follows an item remove that changes the item indexes", operation.Op, operation.Path)
+	}
+
+	for _, touched := range pp.touched {
+		if path == touched || strings.HasPrefix(path, touched+"/") || strings.HasPrefix(touched, path+"/") {
+			return fmt.Errorf("json patch %s %s overlaps %s", operation.Op, operation.Path, touched)
+		}
+	}
+
+	pp.touched = append(pp.touched, path)
+	if items && len(segments) == 2 && operation.Op == "remove" {
+		pp.shifted = true
+	}
+	return nil
+}
+
+// applyJsonPatch translates RFC 6902 add, replace and remove operations. Items
+// can only be added at the end of the list with the "-" index. Operations are applied
+// together, so operations on overlapping paths or on item indexes changed by an earlier
+// operation are rejected. Item indexes and address fields must be stored; patched items
+// replace the order total.
+func applyJsonPatch(ub *updateBuilder, body []byte, target *patchTarget) error {
+	operations := []JsonPatchOperation{}
+	if err := json.Unmarshal(body, &operations); err != nil {
+		return err
+	}
+
+	paths := patchPaths{}
+	added := []Items{}
+	for _, operation := range operations {
+		if operation.Op != "add" && operation.Op != "replace" && operation.Op != "remove" {
+			return fmt.Errorf("json patch op %s not supported. supported: add, replace, remove", operation.Op)
+		}
+
+		segments := strings.Split(strings.TrimPrefix(operation.Path, "/"), "/")
+		for i := range segments {
+			segments[i] = strings.ReplaceAll(strings.ReplaceAll(segments[i], "~1", "/"), "~0", "~")
+		}
+
+		remove := operation.Op == "remove"
+		if !remove && isNull(operation.Value) {
+			return fmt.Errorf("json patch %s %s requires value", operation.Op, operation.Path)
+		} else if err := paths.check(operation, segments); err != nil {
+			return err
+		}
+
+		switch {
+		case len(segments) == 1 && segments[0] == "items":
+			if remove {
+				return fmt.Errorf("items can not be removed from an order")
+			} else if items, err := decodeItems(operation.Value); err != nil {
+				return err
+			} else if err := ub.set(ub.path("items"), items); err != nil {
+				return err
+			} else {
+				target.setItems(items)
+			}
+		case len(segments) == 1 && segments[0] == "tip":
+			if remove {
+				ub.remove(ub.path("tip"))
+			} else if tip, err := decodeTip(operation.Value); err != nil {
+				return err
+			} else if err := ub.set(ub.path("tip"), tip); err != nil {
+				return err
+			}
+		case len(segments) == 1 && segments[0] == "notes":
+			var notes string
+			if remove {
+				ub.remove(ub.path("notes"))
+			} else if err := json.Unmarshal(operation.Value, &notes); err != nil {
+				return err
+			} else if err := ub.set(ub.path("notes"), notes); err != nil {
+				return err
+			}
+		case len(segments) == 1 && segments[0] == "deliveryaddress":
+			address := Address{}
+			if remove {
+				ub.remove(ub.path("deliveryaddress"))
+			} else if err := json.Unmarshal(operation.Value, &address); err != nil {
+				return err
+			} else if err := ub.set(ub.path("deliveryaddress"), address); err != nil {
+				return err
+			}
+		case len(segments) == 2 && segments[0] == "deliveryaddress":
+			kind, ok := patchableAddress[segments[1]]
+			if !ok {
+				return fmt.Errorf("%s not patchable", operation.Path)
+			} else if !target.hasAddress {
+				return fmt.Errorf("%s not found. the order has no delivery address, add /deliveryaddress", operation.Path)
+			} else if remove {
+				ub.remove(ub.path("deliveryaddress", segments[1]))
+			} else if v, err := decodeTyped(kind, operation.Value); err != nil {
+				return err
+			} else if err := ub.set(ub.path("deliveryaddress", segments[1]), v); err != nil {
+				return err
+			}
+		case len(segments) == 2 && segments[0] == "items" && segments[1] == "-":
+			if operation.Op != "add" {
+				return fmt.Errorf("json patch %s %s not supported", operation.Op, operation.Path)
+			} else if item, err := decodeItem(operation.Value); err != nil {
+				return err
+			} else {
+				added = append(added, item)
+				target.appendItem(item)
+			}
+		case len(segments) >= 2 && segments[0] == "items":
+			index, err := strconv.Atoi(segments[1])
+			if err != nil || index < 0 {
+				return fmt.Errorf("%s requires a list index", operation.Path)
+			}
+
+			if len(segments) == 2 {
+				if remove {
+					if err := target.removeItem(operation.Path, index); err != nil {
+						return err
+					}
+					ub.remove(ub.path("items", index))
+				} else if operation.Op == "add" {
+					return fmt.Errorf("json patch add %s not supported. use /items/- to add an item", operation.Path)
+				} else if item, err := decodeItem(operation.Value); err != nil {
+					return err
+				} else if stored, err := target.item(operation.Path, index); err != nil {
+					return err
+				} else if err := ub.set(ub.path("items", index), item); err != nil {
+					return err
+				} else {
+					*stored = item
+				}
+			} else if kind, ok := patchableItem[segments[2]]; !ok || len(segments) > 3 || remove {
+				return fmt.Errorf("json patch %s %s not supported", operation.Op, operation.Path)
+			} else if v, err := decodeTyped(kind, operation.Value); err != nil {
+				return err
+			} else if err := target.setItemField(operation.Path, index, segments[2], v); err != nil {
+				return err
+			} else if err := ub.set(ub.path("items", index, storedName(segments[2])), v); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s not patchable. patchable: /items, /tip, /notes, /deliveryaddress", operation.Path)
+		}
+	}
+
+	// added items are appended in the operation order
+	if len(added) > 0 {
+		if err := ub.appendList(ub.path("items"), added); err != nil {
+			return err
+		}
+	}
+	return target.setTotal(ub)
+}
+
+func storedName(attribute string) string {
+	if name, ok := storedItemAttribute[attribute]; ok {
+		return name
+	}
+	return attribute
+}
+
+func orderKey(userid, orderid string) map[string]types.AttributeValue {
+	useridAttr, _ := attributevalue.Marshal(userid)
+	orderidAttr, _ := attributevalue.Marshal(orderid)
+	return map[string]types.AttributeValue{
+		"userid":  useridAttr,
+		"orderid": orderidAttr,
+	}
+}
+
+// placedWithin returns the condition expression of orders still placed and not older than ten minutes
+func (ub *updateBuilder) placedWithin(now int64) string {
+	status, _ := ub.value(Placed)
+	cutoff, _ := ub.value(now - MaxElapseTimeMilliSecs)
+	return fmt.Sprintf("attribute_exists(orderid) AND %s = %s AND %s >= %s",
+		ub.path("status"), status, ub.path("placedon"), cutoff)
+}
+
+// unmodifiedSince returns the condition expression of orders not modified after modifiedOn
+func (ub *updateBuilder) unmodifiedSince(modifiedOn UnixMilliTime) string {
+	read, _ := ub.value(modifiedOn)
+	return fmt.Sprintf("%s = %s", ub.path("modifiedon"), read)
+}
+
+// patchable reports whether the order is placed and not older than ten minutes
+func patchable(order Order, now int64) bool {
+	return order.Status == Placed && now-int64(order.PlacedOn) <= MaxElapseTimeMilliSecs
+}
+
+func getHeader(headers map[string]string, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+func PatchOrder(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb patch order")
+	logger.Debug("request body", logging.Body(request.Body))
+
+	tableName := Config.OrdersTable
+
+	orderid := request.PathParameters["id"]
+	requires := map[string]string{"id": "string"}
+	if orderid == "" {
+		return nil, fmt.Errorf("requires: %s", requires)
+	}
+
+	userid, _ := GetUserFromRequestContext(request.RequestContext.Authorizer)
+
+	// merge patch is the default when the content type is not json patch
+	ub := newUpdateBuilder()
+	contentType := strings.TrimSpace(strings.Split(getHeader(request.Headers, "content-type"), ";")[0])
+
+	patchSchema := MergePatchSchema
+	if contentType == JsonPatchContentType {
+		patchSchema = JsonPatchSchema
+	}
+	if violations := patchSchema.Validate([]byte(request.Body)); len(violations) > 0 {
+		return client.NewValidationResponse(violations), nil
+	}
+
+	logger.Debug("new dynamodb client session")
+	ddb := client.NewDynamodb()
+
+	// same rules as ModifyOrder: placed and not acknowledged within ten minutes
+	current := OrderItem{}
+	modifiedOn := time.Now().UnixMilli()
+	stored, err := ddb.GetItem(ctx, &dynamodb.GetItemInput{TableName: aws.String(tableName), Key: orderKey(userid, orderid)})
+	if err != nil {
+		return nil, err
+	} else if stored.Item == nil {
+		return client.NewErrorResponse(404, fmt.Sprintf("order %s not found", orderid)), nil
+	} else if err := attributevalue.UnmarshalMap(stored.Item, &current); err != nil {
+		return nil, err
+	} else if !patchable(current.Data, modifiedOn) {
+		return client.NewErrorResponse(409, fmt.Sprintf("order %s not patchable. order acknowledged or placed more than ten minutes ago", orderid)), nil
+	}
+
+	// patch documents the order can not apply are client errors
+	target := newPatchTarget(current.Data, hasDataMap(stored.Item, "deliveryaddress"))
+	if contentType == JsonPatchContentType {
+		if err := applyJsonPatch(ub, []byte(request.Body), target); err != nil {
+			return client.NewErrorResponse(400, err.Error()), nil
+		}
+	} else if err := applyMergePatch(ub, []byte(request.Body), target); err != nil {
+		return client.NewErrorResponse(400, err.Error()), nil
+	}
+
+	if len(ub.sets) == 0 && len(ub.removes) == 0 {
+		return client.NewErrorResponse(400, "patch document does not contain any updates"), nil
+	}
+
+	// delivery address changes are checked against the restaurant delivery zones
+	if address, changed, err := patchedAddress(current.Data.DeliveryAddress, contentType, []byte(request.Body)); err != nil {
+		return nil, err
+	} else if !changed {
+		logger.Debug("delivery address not patched")
+	} else if _, rejected, err := checkDeliveryZone(ctx, ddb, current.Data.RestaurantId, address); err != nil || rejected != nil {
+		return rejected, err
+	}
+
+	if err := ub.set(ub.path("modifiedon"), modifiedOn); err != nil {
+		return nil, err
+	} else if err := ub.appendEvent(OrderEvent{Type: EventModified, Status: Placed, Actor: userid, OccurredOn: UnixMilliTime(modifiedOn)}); err != nil {
+		return nil, err
+	}
+
+	// the order may be acknowledged or modified after it was read. the total is of the items read.
+	condition := ub.placedWithin(modifiedOn) + " AND " + ub.unmodifiedSince(current.Data.ModifiedOn)
+
+	params := dynamodb.UpdateItemInput{
+		TableName:                 aws.String(tableName),
+		Key:                       orderKey(userid, orderid),
+		UpdateExpression:          aws.String(ub.expression()),
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeNames:  ub.names,
+		ExpressionAttributeValues: ub.values,
+		ReturnValues:              types.ReturnValueAllNew,
+	}
+
+	logger.Debug("update expression", zap.String("expression", *params.UpdateExpression))
+
+	order := Order{}
+	var conditionFailed *types.ConditionalCheckFailedException
+	if output, err := ddb.UpdateItem(ctx, &params); errors.As(err, &conditionFailed) {
+		return client.NewErrorResponse(409, fmt.Sprintf("order %s not patchable. order acknowledged, modified or placed more than ten minutes ago", orderid)), nil
+	} else if err != nil {
+		return nil, err
+	} else if err := attributevalue.Unmarshal(output.Attributes[OrderDataAttribute], &order); err != nil {
+		return nil, err
+	} else if refreshed, err := RefreshEta(ctx, ddb, EtaTables{Orders: tableName, Restaurants: Config.RestaurantsTable}, userid, orderid, nil, 0); err != nil {
+		return nil, err
+	} else if refreshed != nil {
+		order.Eta = refreshed.Eta
+	}
+
+	if body, err := json.Marshal(order); err != nil {
+		return nil, err
+	} else {
+		metrics.Count(metrics.OrdersModified, metrics.Dim("RestaurantId", order.RestaurantId))
+		response := events.APIGatewayProxyResponse{
+			StatusCode: 200,
+			Headers:    client.HttpResponseHeaders,
+			Body:       string(body),
+		}
+
+		return &response, nil
+	}
+}
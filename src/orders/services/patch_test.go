@@ -0,0 +1,256 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// stored order items, total 15
+var storedItems = []Items{{ItemId: "1", Quanity: 2, Amount: 3}, {ItemId: "2", Quanity: 1, Amount: 4}, {ItemId: "3", Quanity: 1, Amount: 5}}
+
+// patchedTotal returns the order total set by the update expression, 0 when not set
+func patchedTotal(t *testing.T, ub *updateBuilder) float64 {
+	for _, set := range ub.sets {
+		if placeholder, found := strings.CutPrefix(set, "#data.#totalamount = "); found {
+			total := 0.0
+			if n, ok := ub.values[placeholder].(*types.AttributeValueMemberN); !ok {
+				t.Fatalf("total %s not a number", placeholder)
+			} else if _, err := fmt.Sscan(n.Value, &total); err != nil {
+				t.Fatal(err)
+			}
+			return total
+		}
+	}
+	return 0
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		expression string
+		err        string
+	}{
+		{"tip", `{"tip": 2.5}`, "SET #data.#tip = :v0", ""},
+		{"remove tip", `{"tip": null}`, "REMOVE #data.#tip", ""},
+		{"notes", `{"notes": "ring twice"}`, "SET #data.#notes = :v0", ""},
+		{"address field", `{"deliveryaddress": {"city": "Seattle"}}`, "SET #data.#deliveryaddress.#city = :v0", ""},
+		{"remove address", `{"deliveryaddress": null}`, "REMOVE #data.#deliveryaddress", ""},
+		{"items", `{"items": [{"itemid": "1", "quanity": 2, "amount": 3}]}`, "SET #data.#items = :v0, #data.#totalamount = :v1", ""},
+		{"remove items", `{"items": null}`, "", "items can not be removed"},
+		{"empty items", `{"items": []}`, "", "requires: at least one item"},
+		{"negative tip", `{"tip": -1}`, "", "requires: tip >= 0"},
+		{"address field not patchable", `{"deliveryaddress": {"country": "US"}}`, "", "deliveryaddress/country not patchable"},
+		{"attribute not patchable", `{"status": "cancelled"}`, "", "status not patchable"},
+		{"items total too large", `{"items": [{"itemid": "1", "quanity": 100, "amount": 10000}]}`, "", "requires: totalamount"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ub := newUpdateBuilder()
+			err := applyMergePatch(ub, []byte(test.body), newPatchTarget(Order{Items: storedItems}, true))
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("err = %v, want %q", err, test.err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+
+			if expression := ub.expression(); expression != test.expression {
+				t.Errorf("expression = %q, want %q", expression, test.expression)
+			}
+		})
+	}
+}
+
+func TestApplyJsonPatch(t *testing.T) {
+	item := `{"itemid": "1", "quanity": 2, "amount": 3}`
+
+	tests := []struct {
+		name       string
+		body       string
+		expression string
+		err        string
+	}{
+		{"replace tip", `[{"op": "replace", "path": "/tip", "value": 2}]`, "SET #data.#tip = :v0", ""},
+		{"remove notes", `[{"op": "remove", "path": "/notes"}]`, "REMOVE #data.#notes", ""},
+		{"item quantity", `[{"op": "replace", "path": "/items/0/quanity", "value": 3}]`, "SET #data.#items[0].#quantity = :v0, #data.#totalamount = :v1", ""},
+		{"address field", `[{"op": "replace", "path": "/deliveryaddress/city", "value": "Seattle"}]`, "SET #data.#deliveryaddress.#city = :v0", ""},
+		{"distinct paths", `[{"op": "replace", "path": "/items/2", "value": ` + item + `}, {"op": "remove", "path": "/items/0"}]`,
+			"SET #data.#items[2] = :v0, #data.#totalamount = :v1 REMOVE #data.#items[0]", ""},
+		{"add items in order", `[{"op": "add", "path": "/items/-", "value": ` + item + `}, {"op": "add", "path": "/items/-", "value": ` + item + `}]`,
+			"SET #data.#items = list_append(#data.#items, :v0), #data.#totalamount = :v1", ""},
+
+		{"unsupported op", `[{"op": "move", "from": "/tip", "path": "/notes"}]`, "", "json patch op move not supported"},
+		{"missing value", `[{"op": "replace", "path": "/tip"}]`, "", "requires value"},
+		{"same path", `[{"op": "remove", "path": "/tip"}, {"op": "add", "path": "/tip", "value": 1}]`, "", "overlaps /tip"},
+		{"parent path", `[{"op": "replace", "path": "/deliveryaddress/city", "value": "Seattle"}, {"op": "remove", "path": "/deliveryaddress"}]`,
+			"", "overlaps /deliveryaddress/city"},
+		{"child path", `[{"op": "replace", "path": "/items", "value": [` + item + `]}, {"op": "replace", "path": "/items/0/amount", "value": 1}]`,
+			"", "overlaps /items"},
+		{"shifted index", `[{"op": "remove", "path": "/items/0"}, {"op": "remove", "path": "/items/1"}]`, "", "changes the item indexes"},
+		{"index after add", `[{"op": "add", "path": "/items/-", "value": ` + item + `}, {"op": "replace", "path": "/items/1/amount", "value": 1}]`,
+			"", "overlaps /items/-"},
+		{"add after index", `[{"op": "replace", "path": "/items/0/amount", "value": 1}, {"op": "add", "path": "/items/-", "value": ` + item + `}]`,
+			"", "overlaps /items/0/amount"},
+		{"add at index", `[{"op": "add", "path": "/items/0", "value": ` + item + `}]`, "", "use /items/- to add an item"},
+		{"not patchable", `[{"op": "replace", "path": "/status", "value": "cancelled"}]`, "", "/status not patchable"},
+		{"item past the last item", `[{"op": "replace", "path": "/items/3/amount", "value": 1}]`, "", "/items/3/amount not found. the order has 3 items"},
+		{"remove past the last item", `[{"op": "remove", "path": "/items/5"}]`, "", "/items/5 not found"},
+		{"fractional quantity", `[{"op": "replace", "path": "/items/0/quanity", "value": 1.5}]`, "", "requires: /items/0/quanity integer"},
+		{"no quantity", `[{"op": "replace", "path": "/items/0/quanity", "value": 0}]`, "", "requires:"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ub := newUpdateBuilder()
+			err := applyJsonPatch(ub, []byte(test.body), newPatchTarget(Order{Items: storedItems}, true))
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("err = %v, want %q", err, test.err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+
+			if expression := ub.expression(); expression != test.expression {
+				t.Errorf("expression = %q, want %q", expression, test.expression)
+			}
+		})
+	}
+}
+
+func TestPatchRemovedAddress(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expression  string
+		err         string
+	}{
+		{"merge patch fields", MergePatchContentType, `{"deliveryaddress": {"city": "Seattle"}}`, "SET #data.#deliveryaddress = :v0", ""},
+		{"merge patch null fields", MergePatchContentType, `{"deliveryaddress": {"city": null}}`, "", ""},
+		{"merge patch remove", MergePatchContentType, `{"deliveryaddress": null}`, "REMOVE #data.#deliveryaddress", ""},
+		{"add address", JsonPatchContentType, `[{"op": "add", "path": "/deliveryaddress", "value": {"city": "Seattle"}}]`, "SET #data.#deliveryaddress = :v0", ""},
+		{"replace field", JsonPatchContentType, `[{"op": "replace", "path": "/deliveryaddress/city", "value": "Seattle"}]`, "",
+			"/deliveryaddress/city not found. the order has no delivery address"},
+		{"remove field", JsonPatchContentType, `[{"op": "remove", "path": "/deliveryaddress/city"}]`, "", "/deliveryaddress/city not found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ub := newUpdateBuilder()
+			target := newPatchTarget(Order{Items: storedItems}, false)
+
+			var err error
+			if test.contentType == JsonPatchContentType {
+				err = applyJsonPatch(ub, []byte(test.body), target)
+			} else {
+				err = applyMergePatch(ub, []byte(test.body), target)
+			}
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("err = %v, want %q", err, test.err)
+				}
+			} else if err != nil {
+				t.Fatalf("err = %v", err)
+			} else if expression := ub.expression(); expression != test.expression {
+				t.Errorf("expression = %q, want %q", expression, test.expression)
+			}
+		})
+	}
+}
+
+func TestHasDataMap(t *testing.T) {
+	address := &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{"city": &types.AttributeValueMemberS{Value: "Seattle"}}}
+
+	tests := []struct {
+		name string
+		item map[string]types.AttributeValue
+		want bool
+	}{
+		{"address", map[string]types.AttributeValue{"data": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{"deliveryaddress": address}}}, true},
+		{"removed address", map[string]types.AttributeValue{"data": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{}}}, false},
+		{"null address", map[string]types.AttributeValue{"data": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{"deliveryaddress": &types.AttributeValueMemberNULL{Value: true}}}}, false},
+		{"no data", map[string]types.AttributeValue{}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := hasDataMap(test.item, "deliveryaddress"); got != test.want {
+				t.Errorf("hasDataMap = %t, want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPatchedTotal(t *testing.T) {
+	item := `{"itemid": "1", "quanity": 2, "amount": 3}`
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		total       float64
+	}{
+		{"merge patch items", MergePatchContentType, `{"items": [` + item + `]}`, 6},
+		{"merge patch tip", MergePatchContentType, `{"tip": 2}`, 0},
+		{"replace items", JsonPatchContentType, `[{"op": "replace", "path": "/items", "value": [` + item + `, ` + item + `]}]`, 12},
+		{"item quantity", JsonPatchContentType, `[{"op": "replace", "path": "/items/0/quanity", "value": 3}]`, 18},
+		{"item amount", JsonPatchContentType, `[{"op": "replace", "path": "/items/1/amount", "value": 4.005}]`, 15.01},
+		{"replace and remove", JsonPatchContentType, `[{"op": "replace", "path": "/items/2", "value": ` + item + `}, {"op": "remove", "path": "/items/0"}]`, 10},
+		{"add items", JsonPatchContentType, `[{"op": "add", "path": "/items/-", "value": ` + item + `}, {"op": "add", "path": "/items/-", "value": ` + item + `}]`, 27},
+		{"json patch notes", JsonPatchContentType, `[{"op": "replace", "path": "/notes", "value": "ring twice"}]`, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ub := newUpdateBuilder()
+			target := newPatchTarget(Order{Items: storedItems}, true)
+
+			var err error
+			if test.contentType == JsonPatchContentType {
+				err = applyJsonPatch(ub, []byte(test.body), target)
+			} else {
+				err = applyMergePatch(ub, []byte(test.body), target)
+			}
+			if err != nil {
+				t.Fatal(err)
+			} else if total := patchedTotal(t, ub); total != test.total {
+				t.Errorf("total = %.2f, want %.2f", total, test.total)
+			} else if storedItems[0].Quanity != 2 || len(storedItems) != 3 {
+				t.Errorf("stored items changed %v", storedItems)
+			}
+		})
+	}
+}
+
+func TestPatchable(t *testing.T) {
+	const now = 1_700_000_600_000
+
+	tests := []struct {
+		name  string
+		order Order
+		want  bool
+	}{
+		{"placed", Order{Status: Placed, PlacedOn: now - 60_000}, true},
+		{"placed ten minutes ago", Order{Status: Placed, PlacedOn: now - MaxElapseTimeMilliSecs}, true},
+		{"placed more than ten minutes ago", Order{Status: Placed, PlacedOn: now - MaxElapseTimeMilliSecs - 1}, false},
+		{"acknowledged", Order{Status: Acknowledged, PlacedOn: now - 60_000}, false},
+		{"cancelled", Order{Status: Cancelled, PlacedOn: now - 60_000}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := patchable(test.order, now); got != test.want {
+				t.Errorf("patchable = %t, want %t", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/internal/push"
+	"github.com/kscott5/fds/orders/eta"
+	"github.com/kscott5/fds/orders/zones"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"go.uber.org/zap"
+)
+
+// Push sends order updates to the WebSocket subscribers of the order. Lambda mains set it
+// when the WebSocket api is deployed.
+var Push push.Publisher = push.Discard
+
+// RiderLocation is the assigned rider's last location
+type RiderLocation struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// OrderUpdate is pushed to the subscribers of the order when it changes
+type OrderUpdate struct {
+	Type          string         `json:"type"` // order
+	OrderId       string         `json:"orderid"`
+	Status        OrderStatus    `json:"status"`
+	Eta           *eta.Estimate  `json:"eta,omitempty"`
+	RiderId       string         `json:"riderid,omitempty"`
+	RiderLocation *RiderLocation `json:"riderlocation,omitempty"`
+	ModifiedOn    UnixMilliTime  `json:"modifiedon"`
+}
+
+// publishOrder pushes the order to its subscribers. Failures are logged; the order is
+// already stored.
+func publishOrder(ctx context.Context, order Order, rider *zones.Point) {
+	update := OrderUpdate{
+		Type:       "order",
+		OrderId:    order.OrderId,
+		Status:     order.Status,
+		Eta:        order.Eta,
+		RiderId:    order.RiderId,
+		ModifiedOn: order.ModifiedOn,
+	}
+	if rider != nil {
+		update.RiderLocation = &RiderLocation{Latitude: rider.Latitude, Longitude: rider.Longitude}
+	}
+
+	if err := Push.Publish(ctx, order.OrderId, update); err != nil {
+		logging.FromContext(ctx).Warn("order update not pushed", zap.String("orderid", order.OrderId), zap.Error(err))
+	}
+}
+
+// OrderOwner reports whether the order belongs to the customer. WebSocket subscriptions are
+// limited to the customer's orders.
+func OrderOwner(tableName string) push.Owns {
+	return func(ctx context.Context, userid, orderid string) (bool, error) {
+		output, err := client.NewDynamodb().GetItem(ctx, &dynamodb.GetItemInput{
+			TableName:            aws.String(tableName),
+			Key:                  orderKey(userid, orderid),
+			ProjectionExpression: aws.String("orderid"),
+		})
+		if err != nil {
+			return false, err
+		}
+		return output.Item != nil, nil
+	}
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"github.com/kscott5/fds/internal/config"
+	"github.com/kscott5/fds/internal/router"
+	"github.com/kscott5/fds/internal/schema"
+	"github.com/kscott5/fds/orders/zones"
+)
+
+// Config is loaded by the lambda main before the first request
+var Config config.Orders
+
+// Register adds the orders service routes. The order is the OpenAPI document order.
+func Register(r *router.Router) {
+	r.Handle(router.Route{
+		Method: "GET", Resource: "/orders", Handler: ListOrders,
+		Summary: "List orders",
+		Query: map[string]string{
+			"limit": "page size. defaults to 100.",
+			"next":  "next page token from the x-fds-next-token response header",
+		},
+		Response: []OrderItem{},
+	})
+	r.Handle(router.Route{
+		Method: "PUT", Resource: "/orders", Aliases: []string{"/order"}, Handler: CreateOrder,
+		Summary:  "Place an order",
+		Request:  OrderSchema,
+		Response: map[string]string{},
+	})
+	r.Handle(router.Route{
+		Method: "GET", Resource: "/orders/{id}", Aliases: []string{"/order/{id}"}, Handler: GetOrder,
+		Summary:  "Get the caller's order with its delivery estimate",
+		Response: Order{},
+	})
+	r.Handle(router.Route{
+		Method: "PUT", Resource: "/orders/{id}", Aliases: []string{"/order/{id}"}, Handler: ModifyOrder,
+		Summary:  "Replace an order placed within ten minutes",
+		Request:  OrderSchema,
+		Response: map[string]string{},
+	})
+	r.Handle(router.Route{
+		Method: "PATCH", Resource: "/orders/{id}", Aliases: []string{"/order/{id}"}, Handler: PatchOrder,
+		Summary: "Update an order placed within ten minutes with a merge patch or json patch document",
+		RequestTypes: map[string]*schema.Field{
+			MergePatchContentType: MergePatchSchema,
+			JsonPatchContentType:  JsonPatchSchema,
+		},
+		Response: Order{},
+	})
+	r.Handle(router.Route{
+		Method: "POST", Resource: "/orders/{id}/cancel", Aliases: []string{"/order/{id}/cancel"}, Handler: CancelOrder,
+		Summary:  "Cancel an order placed within ten minutes",
+		Request:  CancelSchema,
+		Response: map[string]string{},
+	})
+	r.Handle(router.Route{
+		Method: "GET", Resource: "/orders/{id}/timeline", Aliases: []string{"/order/{id}/timeline"}, Handler: GetTimeline,
+		Summary: "Order status history, oldest first",
+		Query: map[string]string{
+			"userid": "customer of the order. administrators only.",
+		},
+		Response: Timeline{},
+	})
+	r.Handle(router.Route{
+		Method: "GET", Resource: "/zones", Handler: GetZones,
+		Summary:  "List delivery zones. administrators only.",
+		Response: []zones.Zone{},
+	})
+	r.Handle(router.Route{
+		Method: "GET", Resource: "/zones/{id}", Handler: GetZone,
+		Summary:  "Get a delivery zone. administrators only.",
+		Response: zones.Zone{},
+	})
+	r.Handle(router.Route{
+		Method: "PUT", Resource: "/zones/{id}", Handler: PutZone,
+		Summary:  "Create or replace a restaurant or city delivery zone with a GeoJSON polygon. administrators only.",
+		Request:  zones.ZoneSchema,
+		Response: zones.Zone{},
+	})
+	r.Handle(router.Route{
+		Method: "DELETE", Resource: "/zones/{id}", Handler: DeleteZone,
+		Summary:  "Delete a delivery zone. administrators only.",
+		Response: map[string]string{},
+	})
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"github.com/kscott5/fds/internal/schema"
+)
+
+const (
+	MaxOrderItems  = 50
+	MaxItemQuanity = 100
+	MaxOrderAmount = 10000
+)
+
+func itemSchema() *schema.Field {
+	return schema.Object(map[string]*schema.Field{
+		"itemid":      schema.String().Required().MaxLength(64),
+		"description": schema.String().MaxLength(256),
+		"quanity":     schema.Integer().Required().Min(1).Max(MaxItemQuanity),
+		"amount":      schema.Number().Required().Min(0).Max(MaxOrderAmount),
+	})
+}
+
+// addressSchema with nullable properties is used with merge patch documents
+func addressSchema(nullable bool) *schema.Field {
+	properties := map[string]*schema.Field{
+		"street":     schema.String().MaxLength(128),
+		"city":       schema.String().MaxLength(64),
+		"state":      schema.String().MaxLength(64),
+		"postalcode": schema.String().MaxLength(16).Pattern(`^[0-9A-Za-z -]+$`),
+		"latitude":   schema.Number().Min(-90).Max(90),
+		"longitude":  schema.Number().Min(-180).Max(180),
+	}
+
+	if nullable {
+		for _, property := range properties {
+			property.Nullable()
+		}
+		return schema.Object(properties).Nullable()
+	}
+	return schema.Object(properties)
+}
+
+// OrderSchema validates PUT /orders and PUT /orders/{id} request bodies
+var OrderSchema = schema.Object(map[string]*schema.Field{
+	"restaurantid":    schema.String().Required().MaxLength(64),
+	"totalamount":     schema.Number().Required().Min(0.01).Max(MaxOrderAmount),
+	"items":           schema.Array(itemSchema()).Required().MinItems(1).MaxItems(MaxOrderItems),
+	"tip":             schema.Number().Min(0).Max(MaxOrderAmount),
+	"notes":           schema.String().MaxLength(500),
+	"deliveryaddress": addressSchema(false),
+
+	// read only attributes returned with GET /orders/{id}. the cancel and dispatch
+	// attributes are not accepted, they are set by the cancel path and dispatch only.
+	"orderid":    schema.String().MaxLength(64),
+	"userid":     schema.String().MaxLength(64),
+	"status":     schema.String(),
+	"placedon":   schema.Integer(),
+	"modifiedon": schema.Integer(),
+	"eta":        schema.AnyValue(),
+})
+
+// MergePatchSchema validates PATCH /orders/{id} merge patch documents. null removes the attribute.
+var MergePatchSchema = schema.Object(map[string]*schema.Field{
+	"items":           schema.Array(itemSchema()).MinItems(1).MaxItems(MaxOrderItems),
+	"tip":             schema.Number().Nullable().Min(0).Max(MaxOrderAmount),
+	"notes":           schema.String().Nullable().MaxLength(500),
+	"deliveryaddress": addressSchema(true),
+})
+
+// JsonPatchSchema validates PATCH /orders/{id} json patch documents
+var JsonPatchSchema = schema.Array(schema.Object(map[string]*schema.Field{
+	"op":    schema.String().Required().Enum("add", "replace", "remove"),
+	"path":  schema.String().Required().Pattern(`^/(items|tip|notes|deliveryaddress)(/.*)?$`),
+	"value": schema.AnyValue().Nullable(),
+})).MinItems(1).MaxItems(MaxOrderItems)
+
+// CancelSchema validates cancel order request bodies
+var CancelSchema = schema.Object(map[string]*schema.Field{
+	"reason": schema.String().MaxLength(256),
+})
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/logging"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"go.uber.org/zap"
+)
+
+// OrderEventsAttribute is the order history. It is outside the order data attribute so
+// replacing the order keeps it.
+const OrderEventsAttribute = "events"
+
+// EventType is the change recorded with an order event
+type EventType string
+
+const (
+	EventPlaced   EventType = "placed"
+	EventModified EventType = "modified"
+	// status changed with the order workflow or an operator
+	EventTransitioned EventType = "transitioned"
+	EventCancelled    EventType = "cancelled"
+	EventAssigned     EventType = "assigned"
+)
+
+// OperatorActor is the actor of fdsctl status changes. Customers are their user id.
+const OperatorActor = "operator"
+
+// RiderActor is the actor of rider changes
+func RiderActor(riderid string) string {
+	return "rider:" + riderid
+}
+
+// OrderEvent is a change of the order. Status is the order status after the change.
+type OrderEvent struct {
+	Type       EventType     `json:"type" dynamodbav:"type"`
+	Status     OrderStatus   `json:"status" dynamodbav:"status"`
+	Actor      string        `json:"actor" dynamodbav:"actor"`
+	OccurredOn UnixMilliTime `json:"occurredon" dynamodbav:"occurredon"`
+	Reason     string        `json:"reason,omitempty" dynamodbav:"reason,omitempty"`
+	RiderId    string        `json:"riderid,omitempty" dynamodbav:"riderid,omitempty"`
+}
+
+// Timeline is returned with GET /orders/{id}/timeline, oldest event first
+type Timeline struct {
+	OrderId string       `json:"orderid"`
+	UserId  string       `json:"userid"`
+	Status  OrderStatus  `json:"status"`
+	Events  []OrderEvent `json:"events"`
+}
+
+// appendEvent adds the event to the order history in the update expression
+func (ub *updateBuilder) appendEvent(event OrderEvent) error {
+	placeholder, err := ub.value([]OrderEvent{event})
+	if err != nil {
+		return err
+	}
+
+	empty, _ := ub.value([]OrderEvent{})
+	history := ub.name(OrderEventsAttribute)
+	ub.sets = append(ub.sets, fmt.Sprintf("%s = list_append(if_not_exists(%s, %s), %s)", history, history, empty, placeholder))
+	return nil
+}
+
+// GetTimeline returns the status history of an order. Administrators read the orders of
+// other customers with the userid query parameter.
+func GetTimeline(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb get order timeline")
+	logger.Debug("path parameters", zap.Any("parameters", request.PathParameters))
+
+	orderid := request.PathParameters["id"]
+	requires := map[string]string{"id": "string"}
+	if orderid == "" {
+		return client.NewErrorResponse(400, fmt.Sprintf("requires: %s", requires)), nil
+	}
+
+	userid, err := GetUserFromRequestContext(request.RequestContext.Authorizer)
+	if other := request.QueryStringParameters["userid"]; other != "" && other != userid {
+		if denied := adminOnly(request); denied != nil {
+			return client.NewErrorResponse(403, "timelines of other customers require administrator"), nil
+		}
+		userid, err = other, nil
+	}
+	if err != nil {
+		return client.NewErrorResponse(403, err.Error()), nil
+	}
+
+	ddb := client.NewDynamodb()
+	output, err := ddb.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(Config.OrdersTable),
+		Key:       orderKey(userid, orderid),
+	})
+	if err != nil {
+		return nil, err
+	} else if output.Item == nil {
+		return client.NewErrorResponse(404, fmt.Sprintf("order %s not found", orderid)), nil
+	}
+
+	item := OrderItem{}
+	if err := attributevalue.UnmarshalMap(output.Item, &item); err != nil {
+		return nil, err
+	}
+
+	timeline := Timeline{OrderId: orderid, UserId: userid, Status: item.Data.Status, Events: item.Events}
+	if timeline.Events == nil {
+		timeline.Events = []OrderEvent{}
+	}
+
+	body, err := json.Marshal(timeline)
+	if err != nil {
+		return nil, err
+	}
+
+	response := events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers:    client.HttpResponseHeaders,
+		Body:       string(body),
+	}
+	return &response, nil
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Transitions are the order status changes available to operators and the order workflow
+var Transitions = map[OrderStatus][]OrderStatus{
+	Placed:       {Acknowledged, Paused, Cancelled},
+	Paused:       {Placed, Cancelled},
+	Acknowledged: {Cancelled},
+}
+
+var ErrTransitionNotAllowed = errors.New("order status transition not allowed")
+
+// TransitionOrder changes the order status when the current status allows the change. The
+// change is recorded in the order timeline with the actor and reason.
+func TransitionOrder(ctx context.Context, ddb *dynamodb.Client, tableName, userid, orderid string, to OrderStatus, actor, reason string) (*Order, error) {
+	now := time.Now().UnixMilli()
+	event := OrderEvent{Type: EventTransitioned, Status: to, Actor: actor, OccurredOn: UnixMilliTime(now), Reason: reason}
+	if to == Cancelled {
+		event.Type = EventCancelled
+	}
+
+	ub := newUpdateBuilder()
+	if err := ub.set(ub.path("status"), to); err != nil {
+		return nil, err
+	} else if err := ub.set(ub.path("modifiedon"), now); err != nil {
+		return nil, err
+	} else if err := ub.appendEvent(event); err != nil {
+		return nil, err
+	}
+	if to == Cancelled && reason != "" {
+		if err := ub.set(ub.path("cancelreason"), reason); err != nil {
+			return nil, err
+		}
+	}
+	if to == Cancelled {
+		ub.remove(ub.path("eta"))
+	}
+
+	from := []string{}
+	for status, next := range Transitions {
+		for _, v := range next {
+			if v == to {
+				placeholder, _ := ub.value(status)
+				from = append(from, placeholder)
+			}
+		}
+	}
+	if len(from) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTransitionNotAllowed, to)
+	}
+
+	condition := fmt.Sprintf("attribute_exists(orderid) AND %s IN (%s)", ub.path("status"), strings.Join(from, ", "))
+	params := dynamodb.UpdateItemInput{
+		TableName:                 aws.String(tableName),
+		Key:                       orderKey(userid, orderid),
+		UpdateExpression:          aws.String(ub.expression()),
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeNames:  ub.names,
+		ExpressionAttributeValues: ub.values,
+		ReturnValues:              types.ReturnValueAllNew,
+	}
+
+	order := Order{}
+	var conditionFailed *types.ConditionalCheckFailedException
+	if output, err := ddb.UpdateItem(ctx, &params); errors.As(err, &conditionFailed) {
+		return nil, fmt.Errorf("%w: order %s not found or not able to change to %s", ErrTransitionNotAllowed, orderid, to)
+	} else if err != nil {
+		return nil, err
+	} else if err := attributevalue.Unmarshal(output.Attributes[OrderDataAttribute], &order); err != nil {
+		return nil, err
+	} else {
+		return &order, nil
+	}
+}
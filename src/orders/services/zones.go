@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/internal/metrics"
+	"github.com/kscott5/fds/orders/zones"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"go.uber.org/zap"
+)
+
+// zones of order placements in this container
+var zonesCache = zones.Cache{TTL: time.Minute}
+
+// checkDeliveryZone returns a 422 response when the restaurant does not deliver to the address.
+// The restaurant is returned for the order estimate.
+func checkDeliveryZone(ctx context.Context, ddb *dynamodb.Client, restaurantid string, address Address) (*restaurantSite, *events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+
+	restaurant, err := getRestaurantSite(ctx, ddb, Config.RestaurantsTable, restaurantid)
+	if err != nil {
+		return nil, nil, err
+	} else if restaurant == nil {
+		return nil, client.NewErrorResponse(422, fmt.Sprintf("restaurant %s not found", restaurantid)), nil
+	}
+
+	all, err := zonesCache.Get(time.Now(), func() ([]zones.Zone, error) {
+		return zones.GetZones(ctx, ddb, Config.ZonesTable)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	applicable := zones.Applicable(all, restaurantid, restaurant.Address.City)
+	pickup := zones.Point{Latitude: restaurant.Address.Latitude, Longitude: restaurant.Address.Longitude}
+	delivery := zones.Point{Latitude: address.Latitude, Longitude: address.Longitude}
+	if pickup.IsZero() {
+		logger.Warn("restaurant has no location, the bike distance is not checked", zap.String("restaurantid", restaurantid))
+	}
+	if err := zones.Check(restaurantid, pickup, delivery, applicable); err != nil {
+		logger.Info("order outside the delivery zone", zap.String("restaurantid", restaurantid), zap.Error(err))
+		metrics.Count(metrics.OrdersOutOfZone, metrics.Dim("RestaurantId", restaurantid))
+		return nil, client.NewErrorResponse(422, err.Error()), nil
+	}
+	return restaurant, nil, nil
+}
+
+func adminOnly(request *events.APIGatewayProxyRequest) *events.APIGatewayProxyResponse {
+	if !client.IsMemberOf(request.RequestContext.Authorizer, Config.AdminGroupName) {
+		return client.NewErrorResponse(403, "delivery zones require administrator")
+	}
+	return nil
+}
+
+func newZoneResponse(statusCode int, v interface{}) (*events.APIGatewayProxyResponse, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	response := events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers:    client.HttpResponseHeaders,
+		Body:       string(body),
+	}
+	return &response, nil
+}
+
+func GetZones(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb scan delivery zones")
+
+	if denied := adminOnly(request); denied != nil {
+		return denied, nil
+	}
+
+	if all, err := zones.GetZones(ctx, client.NewDynamodb(), Config.ZonesTable); err != nil {
+		return nil, err
+	} else {
+		return newZoneResponse(200, all)
+	}
+}
+
+func GetZone(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb get delivery zone")
+
+	if denied := adminOnly(request); denied != nil {
+		return denied, nil
+	}
+
+	zoneid := request.PathParameters["id"]
+	if zone, err := zones.GetZone(ctx, client.NewDynamodb(), Config.ZonesTable, zoneid); err != nil {
+		return nil, err
+	} else if zone == nil {
+		return client.NewErrorResponse(404, fmt.Sprintf("zone %s not found", zoneid)), nil
+	} else {
+		return newZoneResponse(200, zone)
+	}
+}
+
+// PutZone creates or replaces a delivery zone
+func PutZone(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb put delivery zone")
+
+	if denied := adminOnly(request); denied != nil {
+		return denied, nil
+	}
+
+	zoneid := request.PathParameters["id"]
+	requires := map[string]string{"id": "string"}
+	if zoneid == "" {
+		return client.NewErrorResponse(400, fmt.Sprintf("requires: %s", requires)), nil
+	}
+
+	if violations := zones.ZoneSchema.Validate([]byte(request.Body)); len(violations) > 0 {
+		return client.NewValidationResponse(violations), nil
+	}
+
+	zone := zones.Zone{}
+	if err := json.Unmarshal([]byte(request.Body), &zone); err != nil {
+		return nil, err
+	} else if zone.RestaurantId == "" && zone.City == "" {
+		return client.NewErrorResponse(400, "zone requires restaurantid or city"), nil
+	} else if _, err := zone.Area.Polygons(); err != nil {
+		return client.NewValidationResponse([]client.Violation{{Path: "$.area.coordinates", Message: err.Error()}}), nil
+	}
+
+	ddb := client.NewDynamodb()
+	now := time.Now().UnixMilli()
+	zone.ZoneId, zone.CreatedOn, zone.ModifiedOn = zoneid, now, now
+	if previous, err := zones.GetZone(ctx, ddb, Config.ZonesTable, zoneid); err != nil {
+		return nil, err
+	} else if previous != nil {
+		zone.CreatedOn = previous.CreatedOn
+	}
+
+	if err := zones.PutZone(ctx, ddb, Config.ZonesTable, zone); err != nil {
+		return nil, err
+	}
+	zonesCache.Invalidate()
+	return newZoneResponse(200, zone)
+}
+
+func DeleteZone(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb delete delivery zone")
+
+	if denied := adminOnly(request); denied != nil {
+		return denied, nil
+	}
+
+	zoneid := request.PathParameters["id"]
+	if deleted, err := zones.DeleteZone(ctx, client.NewDynamodb(), Config.ZonesTable, zoneid); err != nil {
+		return nil, err
+	} else if !deleted {
+		return client.NewErrorResponse(404, fmt.Sprintf("zone %s not found", zoneid)), nil
+	} else {
+		zonesCache.Invalidate()
+		return newZoneResponse(200, map[string]string{"zoneid": zoneid, "description": "zone deleted"})
+	}
+}
+
+// patchedAddress returns the delivery address after the patch document. false is returned
+// when the document does not change the delivery address.
+func patchedAddress(current Address, contentType string, body []byte) (Address, bool, error) {
+	fields := map[string]json.RawMessage{}
+	if encoded, err := json.Marshal(current); err != nil {
+		return current, false, err
+	} else if err := json.Unmarshal(encoded, &fields); err != nil {
+		return current, false, err
+	}
+
+	changed := false
+	if contentType == JsonPatchContentType {
+		operations := []JsonPatchOperation{}
+		if err := json.Unmarshal(body, &operations); err != nil {
+			return current, false, err
+		}
+
+		for _, operation := range operations {
+			segments := strings.Split(strings.TrimPrefix(operation.Path, "/"), "/")
+			if segments[0] != "deliveryaddress" {
+				continue
+			}
+
+			changed = true
+			if len(segments) == 1 && operation.Op == "remove" {
+				fields = map[string]json.RawMessage{}
+			} else if len(segments) == 1 {
+				fields = map[string]json.RawMessage{}
+				if err := json.Unmarshal(operation.Value, &fields); err != nil {
+					return current, false, err
+				}
+			} else if operation.Op == "remove" {
+				delete(fields, segments[1])
+			} else {
+				fields[segments[1]] = operation.Value
+			}
+		}
+	} else {
+		document := map[string]json.RawMessage{}
+		if err := json.Unmarshal(body, &document); err != nil {
+			return current, false, err
+		}
+
+		raw, found := document["deliveryaddress"]
+		if !found {
+			return current, false, nil
+		}
+
+		changed = true
+		if isNull(raw) {
+			fields = map[string]json.RawMessage{}
+		} else {
+			patch := map[string]json.RawMessage{}
+			if err := json.Unmarshal(raw, &patch); err != nil {
+				return current, false, err
+			}
+			for name, value := range patch {
+				if isNull(value) {
+					delete(fields, name)
+				} else {
+					fields[name] = value
+				}
+			}
+		}
+	}
+
+	patched := Address{}
+	if encoded, err := json.Marshal(fields); err != nil {
+		return current, false, err
+	} else if err := json.Unmarshal(encoded, &patched); err != nil {
+		return current, false, err
+	}
+	return patched, changed, nil
+}
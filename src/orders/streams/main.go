@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/config"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/internal/tracing"
+	"github.com/kscott5/fds/orders/changes"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	_ "github.com/aws/aws-lambda-go/lambdacontext" // IMPORTANT: package level init() in use.
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+var (
+	settings  config.Streams
+	publisher changes.Publisher
+)
+
+// publish converts the orders table stream batch to order events. Failed records are
+// returned so only the remainder of the batch is retried.
+func publish(ctx context.Context, batch events.DynamoDBEvent) (_ events.DynamoDBEventResponse, err error) {
+	ctx, span := tracing.StartInvocation(ctx, "publish order events", attribute.Int("aws.dynamodb.records", len(batch.Records)))
+	defer func() { tracing.EndInvocation(ctx, span, err) }()
+
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: orders stream", zap.Int("records", len(batch.Records)))
+	return changes.Process(ctx, publisher, batch), nil
+}
+
+func main() {
+	config.MustLoad(&settings)
+	publisher = changes.New(client.LoadConfig(), settings.EventBusName, settings.TopicArn)
+	lambda.Start(publish)
+}
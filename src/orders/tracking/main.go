@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/config"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/internal/push"
+	"github.com/kscott5/fds/internal/tracing"
+	"github.com/kscott5/fds/orders/services"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	_ "github.com/aws/aws-lambda-go/lambdacontext" // IMPORTANT: package level init() in use.
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+var settings config.Tracking
+
+// track handles the $connect, $disconnect, subscribe and unsubscribe routes of the WebSocket
+// api. Order updates are pushed by the orders and riders lambdas.
+func track(ctx context.Context, request *events.APIGatewayWebsocketProxyRequest) (_ *events.APIGatewayProxyResponse, err error) {
+	route, connectionid := request.RequestContext.RouteKey, request.RequestContext.ConnectionID
+	ctx, span := tracing.StartInvocation(ctx, "track orders", attribute.String("aws.apigateway.route_key", route))
+	defer func() { tracing.EndInvocation(ctx, span, err) }()
+
+	logger := logging.FromContext(ctx).With(zap.String("route", route), zap.String("connectionid", connectionid))
+	ctx = logging.NewContext(ctx, logger)
+
+	store := push.Table{DDB: client.NewDynamodb(), TableName: settings.ConnectionsTable}
+	switch route {
+	case "$connect":
+		logger.Info("lambda function: websocket connected")
+		return &events.APIGatewayProxyResponse{StatusCode: 200}, nil
+	case "$disconnect":
+		logger.Info("lambda function: websocket disconnected")
+		if err := store.Disconnect(ctx, connectionid); err != nil {
+			return nil, err
+		}
+		return &events.APIGatewayProxyResponse{StatusCode: 200}, nil
+	}
+
+	// the $connect authorizer context is available with every route of the connection
+	logger.Info("lambda function: websocket action")
+	authorizer, _ := request.RequestContext.Authorizer.(map[string]interface{})
+	userid, _ := client.GetPrincipalIdFrom(authorizer)
+
+	reply, err := push.Handle(ctx, store, services.OrderOwner(settings.OrdersTable), connectionid, userid, []byte(request.Body), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	logger.Debug("websocket reply", zap.String("type", reply.Type), zap.String("orderid", reply.OrderId))
+
+	body, err := json.Marshal(reply)
+	if err != nil {
+		return nil, err
+	}
+	return &events.APIGatewayProxyResponse{StatusCode: 200, Body: string(body)}, nil
+}
+
+func main() {
+	config.MustLoad(&settings)
+	lambda.Start(track)
+}
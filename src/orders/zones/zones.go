@@ -0,0 +1,351 @@
+// Package zones is the hyperlocal delivery zone of a restaurant or city. A zone is a GeoJSON
+// Polygon or MultiPolygon; orders are accepted when the delivery address is inside a zone and
+// within the zone's e-bike distance of the restaurant.
+package zones
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/kscott5/fds/internal/schema"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	DefaultZonesTable = "FDSAppsZones"
+
+	// bike distance of zones without maxbikekm and of restaurants without a zone
+	DefaultMaxBikeKm = 8.0
+)
+
+// Point is a WGS84 coordinate
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+func (p Point) IsZero() bool {
+	return p.Latitude == 0 && p.Longitude == 0
+}
+
+const earthRadiusKm = 6371.0
+
+// DistanceKm is the haversine great circle distance
+func DistanceKm(a, b Point) float64 {
+	lat1, lat2 := a.Latitude*math.Pi/180, b.Latitude*math.Pi/180
+	dlat := lat2 - lat1
+	dlon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dlat/2)*math.Sin(dlat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dlon/2)*math.Sin(dlon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Ring is a closed linear ring of [longitude, latitude] positions
+type Ring [][]float64
+
+// Polygon is an outer ring followed by its holes
+type Polygon []Ring
+
+// Geometry is a GeoJSON Polygon or MultiPolygon geometry. It is stored as GeoJSON text.
+type Geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+func (g Geometry) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	body, err := json.Marshal(g)
+	if err != nil {
+		return nil, err
+	}
+	return &types.AttributeValueMemberS{Value: string(body)}, nil
+}
+
+func (g *Geometry) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	if s, ok := av.(*types.AttributeValueMemberS); !ok {
+		return fmt.Errorf("zone area requires a GeoJSON string attribute")
+	} else {
+		return json.Unmarshal([]byte(s.Value), g)
+	}
+}
+
+// Polygons decodes and validates the coordinates
+func (g Geometry) Polygons() ([]Polygon, error) {
+	polygons := []Polygon{}
+	switch g.Type {
+	case "Polygon":
+		polygon := Polygon{}
+		if err := json.Unmarshal(g.Coordinates, &polygon); err != nil {
+			return nil, fmt.Errorf("polygon coordinates: %w", err)
+		}
+		polygons = append(polygons, polygon)
+	case "MultiPolygon":
+		if err := json.Unmarshal(g.Coordinates, &polygons); err != nil {
+			return nil, fmt.Errorf("multipolygon coordinates: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("geometry type %s not available. available: Polygon, MultiPolygon", g.Type)
+	}
+
+	if len(polygons) == 0 {
+		return nil, fmt.Errorf("geometry requires at least one polygon")
+	}
+	for i, polygon := range polygons {
+		if len(polygon) == 0 {
+			return nil, fmt.Errorf("polygon %d requires an outer ring", i)
+		}
+		for j, ring := range polygon {
+			if len(ring) < 4 {
+				return nil, fmt.Errorf("polygon %d ring %d requires at least four positions", i, j)
+			}
+			for _, position := range ring {
+				if len(position) < 2 || position[0] < -180 || position[0] > 180 || position[1] < -90 || position[1] > 90 {
+					return nil, fmt.Errorf("polygon %d ring %d position %v is not [longitude, latitude]", i, j, position)
+				}
+			}
+			first, last := ring[0], ring[len(ring)-1]
+			if first[0] != last[0] || first[1] != last[1] {
+				return nil, fmt.Errorf("polygon %d ring %d is not closed", i, j)
+			}
+		}
+	}
+	return polygons, nil
+}
+
+// contains is the even-odd ray casting test. Points on an edge may be either side.
+func (ring Ring) contains(p Point) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > p.Latitude) != (yj > p.Latitude) && p.Longitude < (xj-xi)*(p.Latitude-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Contains reports whether the point is inside an outer ring and outside its holes
+func (g Geometry) Contains(p Point) bool {
+	polygons, err := g.Polygons()
+	if err != nil {
+		return false
+	}
+
+	for _, polygon := range polygons {
+		if !polygon[0].contains(p) {
+			continue
+		}
+
+		hole := false
+		for _, ring := range polygon[1:] {
+			hole = hole || ring.contains(p)
+		}
+		if !hole {
+			return true
+		}
+	}
+	return false
+}
+
+// Zone is the delivery area of a restaurant, or of every restaurant in the city without zones
+type Zone struct {
+	ZoneId       string   `json:"zoneid" dynamodbav:"zoneid"`
+	Name         string   `json:"name" dynamodbav:"name"`
+	RestaurantId string   `json:"restaurantid,omitempty" dynamodbav:"restaurantid,omitempty"`
+	City         string   `json:"city,omitempty" dynamodbav:"city,omitempty"`
+	MaxBikeKm    float64  `json:"maxbikekm,omitempty" dynamodbav:"maxbikekm,omitempty"`
+	Area         Geometry `json:"area" dynamodbav:"area"`
+	CreatedOn    int64    `json:"createdon" dynamodbav:"createdon"`
+	ModifiedOn   int64    `json:"modifiedon" dynamodbav:"modifiedon"`
+}
+
+func (zone Zone) maxBikeKm() float64 {
+	if zone.MaxBikeKm > 0 {
+		return zone.MaxBikeKm
+	}
+	return DefaultMaxBikeKm
+}
+
+// ZoneSchema validates PUT /zones/{id} request bodies. Either restaurantid or city is required.
+var ZoneSchema = schema.Object(map[string]*schema.Field{
+	"name":         schema.String().Required().MaxLength(128),
+	"restaurantid": schema.String().MaxLength(64),
+	"city":         schema.String().MaxLength(64),
+	"maxbikekm":    schema.Number().Min(0.1).Max(50),
+	"area": schema.Object(map[string]*schema.Field{
+		"type":        schema.String().Required().Enum("Polygon", "MultiPolygon"),
+		"coordinates": schema.AnyValue().Required(),
+	}).Required(),
+
+	// read only attributes returned with GET /zones/{id}
+	"zoneid":     schema.String().MaxLength(64),
+	"createdon":  schema.Integer(),
+	"modifiedon": schema.Integer(),
+})
+
+// Applicable returns the zones of the restaurant, or the zones of its city when the
+// restaurant has none
+func Applicable(zones []Zone, restaurantid, city string) []Zone {
+	restaurant, cities := []Zone{}, []Zone{}
+	for _, zone := range zones {
+		if zone.RestaurantId != "" {
+			if zone.RestaurantId == restaurantid {
+				restaurant = append(restaurant, zone)
+			}
+		} else if city != "" && strings.EqualFold(zone.City, city) {
+			cities = append(cities, zone)
+		}
+	}
+
+	if len(restaurant) > 0 {
+		return restaurant
+	}
+	return cities
+}
+
+// Rejection is the reason an order is outside the delivery range
+type Rejection struct {
+	Reason string
+}
+
+func (r *Rejection) Error() string {
+	return r.Reason
+}
+
+// Check returns a *Rejection when the delivery address is outside the applicable zones or
+// beyond the bike distance of the restaurant. Restaurants without zones only have the
+// DefaultMaxBikeKm distance. The distance of restaurants without a location is unknown, so
+// only their zones are checked.
+func Check(restaurantid string, restaurant, delivery Point, applicable []Zone) error {
+	if delivery.IsZero() {
+		return &Rejection{Reason: "deliveryaddress latitude and longitude are required to check the delivery zone"}
+	}
+
+	distance := DistanceKm(restaurant, delivery)
+	if len(applicable) == 0 {
+		if restaurant.IsZero() {
+			return &Rejection{Reason: fmt.Sprintf("restaurant %s has no location and no delivery zone. its address latitude and longitude are required to check the delivery distance", restaurantid)}
+		} else if distance > DefaultMaxBikeKm {
+			return &Rejection{Reason: fmt.Sprintf("delivery address is %.1f km from restaurant %s, beyond the %.1f km bike range", distance, restaurantid, DefaultMaxBikeKm)}
+		}
+		return nil
+	}
+
+	var tooFar *Rejection
+	names := []string{}
+	for _, zone := range applicable {
+		names = append(names, zone.Name)
+		if !zone.Area.Contains(delivery) {
+			continue
+		} else if restaurant.IsZero() || distance <= zone.maxBikeKm() {
+			return nil
+		} else if tooFar == nil {
+			tooFar = &Rejection{Reason: fmt.Sprintf("delivery address is %.1f km from restaurant %s, beyond the %.1f km bike range of zone %s", distance, restaurantid, zone.maxBikeKm(), zone.Name)}
+		}
+	}
+
+	if tooFar != nil {
+		return tooFar
+	}
+	return &Rejection{Reason: fmt.Sprintf("delivery address is outside the delivery zones of restaurant %s: %s", restaurantid, strings.Join(names, ", "))}
+}
+
+// GetZones returns every zone. Zones are few and managed by administrators.
+func GetZones(ctx context.Context, ddb *dynamodb.Client, tableName string) ([]Zone, error) {
+	zones := []Zone{}
+	paginator := dynamodb.NewScanPaginator(ddb, &dynamodb.ScanInput{TableName: aws.String(tableName)})
+	for paginator.HasMorePages() {
+		page := []Zone{}
+		if output, err := paginator.NextPage(ctx); err != nil {
+			return nil, err
+		} else if err := attributevalue.UnmarshalListOfMaps(output.Items, &page); err != nil {
+			return nil, err
+		}
+		zones = append(zones, page...)
+	}
+	return zones, nil
+}
+
+// Cache keeps the zones read by a container so order placements do not scan the zones table.
+// Zone changes apply to other containers within the TTL.
+type Cache struct {
+	TTL time.Duration
+
+	mu       sync.Mutex
+	zones    []Zone
+	loadedOn time.Time
+}
+
+// Get returns the cached zones, loading them again when they are older than the TTL
+func (c *Cache) Get(now time.Time, load func() ([]Zone, error)) ([]Zone, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.zones != nil && now.Sub(c.loadedOn) < c.TTL {
+		return c.zones, nil
+	}
+
+	zones, err := load()
+	if err != nil {
+		return nil, err
+	}
+	c.zones, c.loadedOn = zones, now
+	return zones, nil
+}
+
+// Invalidate loads the zones again with the next Get
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.zones = nil
+}
+
+func zoneKey(zoneid string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"zoneid": &types.AttributeValueMemberS{Value: zoneid}}
+}
+
+// GetZone returns nil when the zone does not exist
+func GetZone(ctx context.Context, ddb *dynamodb.Client, tableName, zoneid string) (*Zone, error) {
+	output, err := ddb.GetItem(ctx, &dynamodb.GetItemInput{TableName: aws.String(tableName), Key: zoneKey(zoneid)})
+	if err != nil || output.Item == nil {
+		return nil, err
+	}
+
+	zone := Zone{}
+	if err := attributevalue.UnmarshalMap(output.Item, &zone); err != nil {
+		return nil, err
+	}
+	return &zone, nil
+}
+
+func PutZone(ctx context.Context, ddb *dynamodb.Client, tableName string, zone Zone) error {
+	item, err := attributevalue.MarshalMap(zone)
+	if err != nil {
+		return err
+	}
+
+	_, err = ddb.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(tableName), Item: item})
+	return err
+}
+
+// DeleteZone reports whether the zone existed
+func DeleteZone(ctx context.Context, ddb *dynamodb.Client, tableName, zoneid string) (bool, error) {
+	output, err := ddb.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:    aws.String(tableName),
+		Key:          zoneKey(zoneid),
+		ReturnValues: types.ReturnValueAllOld,
+	})
+	if err != nil {
+		return false, err
+	}
+	return output.Attributes != nil, nil
+}
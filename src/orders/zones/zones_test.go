@@ -0,0 +1,161 @@
+package zones
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// downtown is a 0.05 x 0.03 degree square with a hole in the middle
+var downtown = Geometry{Type: "Polygon", Coordinates: []byte(`[
+	[[-122.35, 47.60], [-122.30, 47.60], [-122.30, 47.63], [-122.35, 47.63], [-122.35, 47.60]],
+	[[-122.33, 47.61], [-122.32, 47.61], [-122.32, 47.62], [-122.33, 47.62], [-122.33, 47.61]]
+]`)}
+
+var islands = Geometry{Type: "MultiPolygon", Coordinates: []byte(`[
+	[[[0, 0], [1, 0], [1, 1], [0, 1], [0, 0]]],
+	[[[2, 2], [3, 2], [3, 3], [2, 3], [2, 2]]]
+]`)}
+
+func TestGeometryContains(t *testing.T) {
+	tests := []struct {
+		name     string
+		geometry Geometry
+		point    Point
+		contains bool
+	}{
+		{"inside", downtown, Point{Latitude: 47.605, Longitude: -122.34}, true},
+		{"outside", downtown, Point{Latitude: 47.65, Longitude: -122.34}, false},
+		{"in the hole", downtown, Point{Latitude: 47.615, Longitude: -122.325}, false},
+		{"first polygon", islands, Point{Latitude: 0.5, Longitude: 0.5}, true},
+		{"second polygon", islands, Point{Latitude: 2.5, Longitude: 2.5}, true},
+		{"between polygons", islands, Point{Latitude: 1.5, Longitude: 1.5}, false},
+		{"unknown type", Geometry{Type: "Point", Coordinates: []byte(`[0.5, 0.5]`)}, Point{Latitude: 0.5, Longitude: 0.5}, false},
+		{"open ring", Geometry{Type: "Polygon", Coordinates: []byte(`[[[0, 0], [1, 0], [1, 1], [0, 1]]]`)}, Point{Latitude: 0.5, Longitude: 0.5}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if contains := test.geometry.Contains(test.point); contains != test.contains {
+				t.Errorf("Contains(%v) = %t, want %t", test.point, contains, test.contains)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	restaurant := Point{Latitude: 47.6097, Longitude: -122.3422}
+	inside := Point{Latitude: 47.6145, Longitude: -122.3385}
+	outside := Point{Latitude: 47.65, Longitude: -122.34}
+	// inside the zone but 5.3 km east of the restaurant
+	farEast := Point{Latitude: 47.6097, Longitude: -122.2710}
+	// 10.7 km east of the restaurant
+	tooFar := Point{Latitude: 47.6097, Longitude: -122.2000}
+
+	zone := Zone{Name: "downtown", Area: downtown}
+	wide := Zone{Name: "wide", MaxBikeKm: 2, Area: Geometry{Type: "Polygon", Coordinates: []byte(`[[[-122.40, 47.55], [-122.20, 47.55], [-122.20, 47.70], [-122.40, 47.70], [-122.40, 47.55]]]`)}}
+
+	tests := []struct {
+		name       string
+		restaurant Point
+		delivery   Point
+		applicable []Zone
+		rejection  string
+	}{
+		{"inside the zone", restaurant, inside, []Zone{zone}, ""},
+		{"outside the zone", restaurant, outside, []Zone{zone}, "outside the delivery zones of restaurant r-1: downtown"},
+		{"beyond the zone bike range", restaurant, farEast, []Zone{wide}, "beyond the 2.0 km bike range of zone wide"},
+		{"no zone within range", restaurant, inside, nil, ""},
+		{"no zone beyond range", restaurant, tooFar, nil, "beyond the 8.0 km bike range"},
+		{"no delivery location", restaurant, Point{}, []Zone{zone}, "deliveryaddress latitude and longitude are required"},
+		{"no restaurant location inside the zone", Point{}, farEast, []Zone{wide}, ""},
+		{"no restaurant location outside the zone", Point{}, outside, []Zone{zone}, "outside the delivery zones"},
+		{"no restaurant location and no zone", Point{}, inside, nil, "restaurant r-1 has no location and no delivery zone"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := Check("r-1", test.restaurant, test.delivery, test.applicable)
+			if test.rejection == "" {
+				if err != nil {
+					t.Errorf("Check = %v, want nil", err)
+				}
+				return
+			}
+
+			if _, ok := err.(*Rejection); !ok {
+				t.Fatalf("Check = %v, want a *Rejection", err)
+			} else if !strings.Contains(err.Error(), test.rejection) {
+				t.Errorf("Check = %q, want %q", err.Error(), test.rejection)
+			}
+		})
+	}
+}
+
+func TestApplicable(t *testing.T) {
+	all := []Zone{
+		{ZoneId: "z1", RestaurantId: "r-1"},
+		{ZoneId: "z2", City: "Seattle"},
+		{ZoneId: "z3", City: "Tacoma"},
+	}
+
+	tests := []struct {
+		name         string
+		restaurantid string
+		city         string
+		want         []string
+	}{
+		{"restaurant zones", "r-1", "Seattle", []string{"z1"}},
+		{"city zones", "r-2", "seattle", []string{"z2"}},
+		{"no zones", "r-2", "Portland", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := []string{}
+			for _, zone := range Applicable(all, test.restaurantid, test.city) {
+				got = append(got, zone.ZoneId)
+			}
+			if strings.Join(got, ",") != strings.Join(test.want, ",") {
+				t.Errorf("Applicable = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCache(t *testing.T) {
+	cache := Cache{TTL: time.Minute}
+	start := time.Now()
+	loads := 0
+	load := func() ([]Zone, error) {
+		loads++
+		return []Zone{{ZoneId: fmt.Sprintf("z-%d", loads)}}, nil
+	}
+
+	tests := []struct {
+		name       string
+		at         time.Duration
+		invalidate bool
+		zoneid     string
+	}{
+		{"first placement", 0, false, "z-1"},
+		{"within the ttl", 30 * time.Second, false, "z-1"},
+		{"after the ttl", 61 * time.Second, false, "z-2"},
+		{"zone changed", 62 * time.Second, true, "z-3"},
+		{"after the change", 63 * time.Second, false, "z-3"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.invalidate {
+				cache.Invalidate()
+			}
+			if zones, err := cache.Get(start.Add(test.at), load); err != nil {
+				t.Fatal(err)
+			} else if zones[0].ZoneId != test.zoneid {
+				t.Errorf("zones of %s = %s, want %s", test.at, zones[0].ZoneId, test.zoneid)
+			}
+		})
+	}
+}
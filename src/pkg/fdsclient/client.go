@@ -0,0 +1,197 @@
+// Package fdsclient is a Go client for the FDS rest api.
+//
+//	fds := fdsclient.New(os.Getenv("FDS_API_URL"), accessToken)
+//	orders, err := fds.Orders.List(ctx)
+//
+// The base url is the api gateway stage invoke url, e.g. terraform output rest_api,
+// or a local dev gateway such as http://localhost:3000/dev.
+package fdsclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultMaxRetries = 3
+	DefaultRetryWait  = 500 * time.Millisecond
+	DefaultTimeout    = 30 * time.Second
+)
+
+type Client struct {
+	BaseURL    string
+	Token      string
+	HTTPClient *http.Client
+
+	// requests with 429 responses, and idempotent requests with 503 responses, are retried
+	// with exponential backoff. a Retry-After header in seconds overrides the wait.
+	MaxRetries int
+	RetryWait  time.Duration
+
+	Users  *UsersService
+	Orders *OrdersService
+}
+
+// New returns a client that sends the token as a bearer token. Without a base url FDS_API_URL is used.
+func New(baseURL, token string) *Client {
+	if baseURL == "" {
+		baseURL = os.Getenv("FDS_API_URL")
+	}
+
+	c := &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: DefaultTimeout},
+		MaxRetries: DefaultMaxRetries,
+		RetryWait:  DefaultRetryWait,
+	}
+	c.Users = &UsersService{client: c}
+	c.Orders = &OrdersService{client: c}
+	return c
+}
+
+// Error is the api error model. Validation errors include every violation.
+type Error struct {
+	ErrorBody
+}
+
+func (e *Error) Error() string {
+	message := fmt.Sprintf("fds api %d: %s", e.StatusCode, e.Message)
+	if len(e.Violations) > 0 {
+		violations := []string{}
+		for _, v := range e.Violations {
+			violations = append(violations, fmt.Sprintf("%s %s", v.Path, v.Message))
+		}
+		message = fmt.Sprintf("%s: %s", message, strings.Join(violations, "; "))
+	}
+
+	if e.TraceId != "" {
+		message = fmt.Sprintf("%s (trace %s)", message, e.TraceId)
+	}
+	return message
+}
+
+func IsNotFound(err error) bool   { return hasStatus(err, http.StatusNotFound) }
+func IsConflict(err error) bool   { return hasStatus(err, http.StatusConflict) }
+func IsForbidden(err error) bool  { return hasStatus(err, http.StatusForbidden) }
+func IsValidation(err error) bool { return hasStatus(err, http.StatusBadRequest) }
+
+func hasStatus(err error, statusCode int) bool {
+	e, ok := err.(*Error)
+	return ok && e.StatusCode == statusCode
+}
+
+// do sends the request and decodes the json response into out. It returns the response headers.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, contentType string, in, out interface{}) (http.Header, error) {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return nil, err
+		}
+	}
+
+	endpoint := c.BaseURL + path
+	if len(query) > 0 {
+		endpoint = endpoint + "?" + query.Encode()
+	}
+
+	wait := c.RetryWait
+	for attempt := 0; ; attempt++ {
+		request, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Set("Accept", "application/json")
+		if in != nil {
+			request.Header.Set("Content-Type", contentType)
+		}
+		if c.Token != "" {
+			request.Header.Set("Authorization", "Bearer "+c.Token)
+		}
+
+		response, err := c.HTTPClient.Do(request)
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if retryable(method, response.StatusCode) && attempt < c.MaxRetries {
+			if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil {
+				wait = time.Duration(seconds) * time.Second
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+			wait = wait * 2
+			continue
+		}
+
+		if response.StatusCode >= 400 {
+			apiError := &Error{}
+			if err := json.Unmarshal(data, &apiError.ErrorBody); err != nil || apiError.Message == "" {
+				apiError.Message = strings.TrimSpace(string(data))
+			}
+			apiError.StatusCode = response.StatusCode
+			if apiError.TraceId == "" {
+				apiError.TraceId = response.Header.Get(TraceIdHeader)
+			}
+			return response.Header, apiError
+		}
+
+		if out != nil && len(data) > 0 {
+			if err := json.Unmarshal(data, out); err != nil {
+				return response.Header, err
+			}
+		}
+		return response.Header, nil
+	}
+}
+
+// retryable reports whether the response may be retried. Throttled requests were not
+// processed. A 503 may follow a processed request, so only idempotent methods are retried;
+// a retried PUT /orders or POST would place or change the order twice.
+func retryable(method string, statusCode int) bool {
+	if statusCode == http.StatusTooManyRequests {
+		return true
+	} else if statusCode == http.StatusServiceUnavailable {
+		return method == http.MethodGet || method == http.MethodHead || method == http.MethodDelete
+	}
+	return false
+}
+
+// list requests every page of a list resource. page decodes one page of items.
+func (c *Client) list(ctx context.Context, path string, query url.Values, page func(data json.RawMessage) error) error {
+	if query == nil {
+		query = url.Values{}
+	}
+
+	for {
+		data := json.RawMessage{}
+		if headers, err := c.do(ctx, http.MethodGet, path, query, "", nil, &data); err != nil {
+			return err
+		} else if err := page(data); err != nil {
+			return err
+		} else if next := headers.Get(NextTokenHeader); next == "" {
+			return nil
+		} else {
+			query.Set("next", next)
+		}
+	}
+}
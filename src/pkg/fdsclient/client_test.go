@@ -0,0 +1,48 @@
+package fdsclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		statusCode int
+		requests   int
+	}{
+		{"throttled get", http.MethodGet, http.StatusTooManyRequests, 3},
+		{"throttled put", http.MethodPut, http.StatusTooManyRequests, 3},
+		{"unavailable get", http.MethodGet, http.StatusServiceUnavailable, 3},
+		{"unavailable delete", http.MethodDelete, http.StatusServiceUnavailable, 3},
+		{"unavailable put", http.MethodPut, http.StatusServiceUnavailable, 1},
+		{"unavailable post", http.MethodPost, http.StatusServiceUnavailable, 1},
+		{"unavailable patch", http.MethodPatch, http.StatusServiceUnavailable, 1},
+		{"server error get", http.MethodGet, http.StatusInternalServerError, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.WriteHeader(test.statusCode)
+			}))
+			defer server.Close()
+
+			c := New(server.URL, "token")
+			c.MaxRetries = 2
+			c.RetryWait = time.Millisecond
+
+			if _, err := c.do(context.Background(), test.method, "/orders", nil, "", nil, nil); !hasStatus(err, test.statusCode) {
+				t.Errorf("do = %v, want status %d", err, test.statusCode)
+			} else if requests != test.requests {
+				t.Errorf("requests = %d, want %d", requests, test.requests)
+			}
+		})
+	}
+}
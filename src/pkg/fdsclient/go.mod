@@ -0,0 +1,3 @@
+module github.com/kscott5/fds/pkg/fdsclient
+
+go 1.22.1
@@ -0,0 +1,70 @@
+package fdsclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+type OrdersService struct {
+	client *Client
+}
+
+// Create places the order and returns the order id
+func (s *OrdersService) Create(ctx context.Context, order Order) (string, error) {
+	out := struct {
+		OrderId string `json:"orderid"`
+	}{}
+	_, err := s.client.do(ctx, http.MethodPut, "/orders", nil, "application/json", order, &out)
+	return out.OrderId, err
+}
+
+func (s *OrdersService) Get(ctx context.Context, orderid string) (*Order, error) {
+	order := Order{}
+	if _, err := s.client.do(ctx, http.MethodGet, "/orders/"+url.PathEscape(orderid), nil, "", nil, &order); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// List returns every order
+func (s *OrdersService) List(ctx context.Context) ([]Order, error) {
+	orders := []Order{}
+	err := s.client.list(ctx, "/orders", nil, func(data json.RawMessage) error {
+		page := []orderItem{}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		for _, item := range page {
+			orders = append(orders, item.Data)
+		}
+		return nil
+	})
+	return orders, err
+}
+
+// Modify replaces an order placed within ten minutes
+func (s *OrdersService) Modify(ctx context.Context, orderid string, order Order) error {
+	_, err := s.client.do(ctx, http.MethodPut, "/orders/"+url.PathEscape(orderid), nil, "application/json", order, nil)
+	return err
+}
+
+// Patch updates an order placed within ten minutes with a merge patch document
+func (s *OrdersService) Patch(ctx context.Context, orderid string, patch map[string]interface{}) (*Order, error) {
+	order := Order{}
+	if _, err := s.client.do(ctx, http.MethodPatch, "/orders/"+url.PathEscape(orderid), nil, MergePatchContentType, patch, &order); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// Cancel cancels an order placed within ten minutes. The reason is optional.
+func (s *OrdersService) Cancel(ctx context.Context, orderid, reason string) error {
+	body := map[string]string{}
+	if reason != "" {
+		body["reason"] = reason
+	}
+	_, err := s.client.do(ctx, http.MethodPost, "/orders/"+url.PathEscape(orderid)+"/cancel", nil, "application/json", body, nil)
+	return err
+}
@@ -0,0 +1,107 @@
+package fdsclient
+
+// Wire types of the FDS rest api. They mirror the json of the orders and users services
+// so the client does not depend on the service modules.
+
+const (
+	// TraceIdHeader is the response header with the trace id of the request
+	TraceIdHeader = "x-fds-trace-id"
+	// NextTokenHeader is the list response header with the token of the next page
+	NextTokenHeader = "x-fds-next-token"
+
+	// RFC 7396 JSON Merge Patch
+	MergePatchContentType = "application/merge-patch+json"
+)
+
+type OrderStatus string
+
+const (
+	Placed       OrderStatus = "placed"
+	Acknowledged OrderStatus = "acknowledged"
+	Cancelled    OrderStatus = "cancelled"
+	Paused       OrderStatus = "paused"
+)
+
+type Items struct {
+	ItemId      string  `json:"itemid"`
+	Description string  `json:"description"`
+	Quanity     int     `json:"quanity"`
+	Amount      float64 `json:"amount"`
+}
+
+type Address struct {
+	Street     string  `json:"street"`
+	City       string  `json:"city"`
+	State      string  `json:"state"`
+	PostalCode string  `json:"postalcode"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+}
+
+// Estimate is the delivery estimate of an order. Times are unix milliseconds.
+type Estimate struct {
+	ReadyOn    int64   `json:"readyon"`
+	PickupOn   int64   `json:"pickupon"`
+	DeliverOn  int64   `json:"deliveron"`
+	TravelKm   float64 `json:"travelkm"`
+	Basis      string  `json:"basis"`
+	ComputedOn int64   `json:"computedon"`
+}
+
+// Order times are unix milliseconds
+type Order struct {
+	RestaurantId    string      `json:"restaurantid"`
+	TotalAmount     float64     `json:"totalamount"`
+	Items           []Items     `json:"items"`
+	Tip             float64     `json:"tip"`
+	Notes           string      `json:"notes"`
+	DeliveryAddress Address     `json:"deliveryaddress"`
+	OrderId         string      `json:"orderid"`
+	UserId          string      `json:"userid"`
+	Status          OrderStatus `json:"status,omitempty"`
+	PlacedOn        int64       `json:"placedon"`
+	ModifiedOn      int64       `json:"modifiedon"`
+	CancelReason    string      `json:"cancelreason,omitempty"`
+	RiderId         string      `json:"riderid,omitempty"`
+	AssignedOn      int64       `json:"assignedon,omitempty"`
+	Eta             *Estimate   `json:"eta,omitempty"`
+}
+
+// orderItem is the GET /orders list item
+type orderItem struct {
+	OrderId string `json:"orderid"`
+	UserId  string `json:"userid"`
+	Data    Order  `json:"data"`
+}
+
+// Preferences are the order notifications of a user. Channels are email, sms and push.
+type Preferences struct {
+	Locale       string   `json:"locale,omitempty"`
+	Channels     []string `json:"channels"`
+	Email        string   `json:"email,omitempty"`
+	Phone        string   `json:"phone,omitempty"`
+	PushEndpoint string   `json:"pushendpoint,omitempty"`
+}
+
+// User profile. The user id is the Cognito sub of the owner.
+type User struct {
+	UserId        string       `json:"userid"`
+	UserName      string       `json:"username"`
+	FullName      string       `json:"fullname"`
+	Notifications *Preferences `json:"notifications,omitempty"`
+	DeletedAt     int64        `json:"deletedat,omitempty"`
+}
+
+// ErrorBody is the api error model returned with 4xx responses
+type ErrorBody struct {
+	StatusCode int         `json:"statuscode"`
+	Message    string      `json:"message"`
+	Violations []Violation `json:"violations,omitempty"`
+	TraceId    string      `json:"traceid,omitempty"`
+}
+
+// Violation is a request body field error. Path is the JSON path of the field, e.g. $.items[0].amount
+type Violation struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
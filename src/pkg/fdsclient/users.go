@@ -0,0 +1,62 @@
+package fdsclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+type UsersService struct {
+	client *Client
+}
+
+// Create creates the caller's user record and returns the user id
+func (s *UsersService) Create(ctx context.Context, user User) (string, error) {
+	out := struct {
+		UserId string `json:"userid"`
+	}{}
+	_, err := s.client.do(ctx, http.MethodPost, "/users", nil, "application/json", user, &out)
+	return out.UserId, err
+}
+
+func (s *UsersService) Get(ctx context.Context, userid string) (*User, error) {
+	user := User{}
+	if _, err := s.client.do(ctx, http.MethodGet, "/users/"+url.PathEscape(userid), nil, "", nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// List returns every user. Administrators include soft deleted users with deleted.
+func (s *UsersService) List(ctx context.Context, deleted bool) ([]User, error) {
+	query := url.Values{}
+	if deleted {
+		query.Set("deleted", "true")
+	}
+
+	users := []User{}
+	err := s.client.list(ctx, "/users", query, func(data json.RawMessage) error {
+		page := []User{}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		users = append(users, page...)
+		return nil
+	})
+	return users, err
+}
+
+func (s *UsersService) Update(ctx context.Context, user User) (*User, error) {
+	out := User{}
+	if _, err := s.client.do(ctx, http.MethodPut, "/users/"+url.PathEscape(user.UserId), nil, "application/json", user, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Delete soft deletes the user
+func (s *UsersService) Delete(ctx context.Context, userid string) error {
+	_, err := s.client.do(ctx, http.MethodDelete, "/users/"+url.PathEscape(userid), nil, "", nil, nil)
+	return err
+}
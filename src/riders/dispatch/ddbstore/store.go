@@ -0,0 +1,316 @@
+// Package ddbstore is the DynamoDB dispatch.Store. Offers are keyed by order id and attempt;
+// the status and rider indexes find expired offers and the open offers of a rider.
+package ddbstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/kscott5/fds/internal/metrics"
+	"github.com/kscott5/fds/orders/restaurants"
+	"github.com/kscott5/fds/orders/services"
+	"github.com/kscott5/fds/riders/dispatch"
+	"github.com/kscott5/fds/riders/fleet"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	DefaultOffersTable = "FDSAppsDispatchOffers"
+
+	StatusIndex = "status-expireson-index"
+	RiderIndex  = "riderid-expireson-index"
+)
+
+type Tables struct {
+	Orders      string
+	Restaurants string
+	Riders      string
+	Offers      string
+}
+
+type Store struct {
+	DDB    *dynamodb.Client
+	Tables Tables
+}
+
+func New(ddb *dynamodb.Client, tables Tables) *Store {
+	return &Store{DDB: ddb, Tables: tables}
+}
+
+func offerKey(orderid string, attempt int) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"orderid": &types.AttributeValueMemberS{Value: orderid},
+		"attempt": &types.AttributeValueMemberN{Value: fmt.Sprint(attempt)},
+	}
+}
+
+func (s *Store) Job(ctx context.Context, userid, orderid string) (*dispatch.Job, error) {
+	output, err := s.DDB.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.Tables.Orders),
+		Key: map[string]types.AttributeValue{
+			"userid":  &types.AttributeValueMemberS{Value: userid},
+			"orderid": &types.AttributeValueMemberS{Value: orderid},
+		},
+	})
+	if err != nil || output.Item == nil {
+		return nil, err
+	}
+
+	item := services.OrderItem{}
+	if err := attributevalue.UnmarshalMap(output.Item, &item); err != nil {
+		return nil, err
+	} else if !item.Data.Dispatchable() {
+		return nil, nil
+	}
+
+	restaurant := restaurants.Restaurant{}
+	if output, err := s.DDB.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.Tables.Restaurants),
+		Key:       map[string]types.AttributeValue{"restaurantid": &types.AttributeValueMemberS{Value: item.Data.RestaurantId}},
+	}); err != nil {
+		return nil, err
+	} else if output.Item == nil {
+		return nil, fmt.Errorf("restaurant %s of order %s not found", item.Data.RestaurantId, orderid)
+	} else if err := attributevalue.UnmarshalMap(output.Item, &restaurant); err != nil {
+		return nil, err
+	}
+
+	return &dispatch.Job{
+		OrderId:      orderid,
+		UserId:       userid,
+		RestaurantId: item.Data.RestaurantId,
+		Pickup:       dispatch.Point{Latitude: restaurant.Address.Latitude, Longitude: restaurant.Address.Longitude},
+		Dropoff:      dispatch.Point{Latitude: item.Data.DeliveryAddress.Latitude, Longitude: item.Data.DeliveryAddress.Longitude},
+	}, nil
+}
+
+// Candidates returns the riders on shift with a reported location
+func (s *Store) Candidates(ctx context.Context) ([]dispatch.Candidate, error) {
+	paginator := dynamodb.NewScanPaginator(s.DDB, &dynamodb.ScanInput{
+		TableName:                 aws.String(s.Tables.Riders),
+		FilterExpression:          aws.String("#shift = :on AND attribute_exists(lastlocation)"),
+		ExpressionAttributeNames:  map[string]string{"#shift": "shift"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":on": &types.AttributeValueMemberS{Value: string(fleet.OnShift)}},
+	})
+
+	candidates := []dispatch.Candidate{}
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		riders := []fleet.Rider{}
+		if err := attributevalue.UnmarshalListOfMaps(output.Items, &riders); err != nil {
+			return nil, err
+		}
+		for _, rider := range riders {
+			candidates = append(candidates, dispatch.Candidate{
+				RiderId:    rider.RiderId,
+				Location:   dispatch.Point{Latitude: rider.LastLocation.Latitude, Longitude: rider.LastLocation.Longitude},
+				LocatedOn:  rider.LastLocation.RecordedOn,
+				RangeKm:    rider.Bike.RangeKm,
+				Battery:    rider.LastLocation.Battery,
+				ActiveJobs: rider.ActiveJobs,
+			})
+		}
+	}
+	return candidates, nil
+}
+
+// Offers returns the offers of the order by attempt
+func (s *Store) Offers(ctx context.Context, orderid string) ([]dispatch.Offer, error) {
+	offers := []dispatch.Offer{}
+	paginator := dynamodb.NewQueryPaginator(s.DDB, &dynamodb.QueryInput{
+		TableName:                 aws.String(s.Tables.Offers),
+		KeyConditionExpression:    aws.String("orderid = :orderid"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":orderid": &types.AttributeValueMemberS{Value: orderid}},
+		ConsistentRead:            aws.Bool(true),
+	})
+	for paginator.HasMorePages() {
+		page := []dispatch.Offer{}
+		if output, err := paginator.NextPage(ctx); err != nil {
+			return nil, err
+		} else if err := attributevalue.UnmarshalListOfMaps(output.Items, &page); err != nil {
+			return nil, err
+		}
+		offers = append(offers, page...)
+	}
+	return offers, nil
+}
+
+func (s *Store) PutOffer(ctx context.Context, offer dispatch.Offer) error {
+	item, err := attributevalue.MarshalMap(offer)
+	if err != nil {
+		return err
+	}
+
+	var conditionFailed *types.ConditionalCheckFailedException
+	if _, err := s.DDB.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(s.Tables.Offers),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(orderid)"),
+	}); errors.As(err, &conditionFailed) {
+		return dispatch.ErrOfferClosed
+	} else if err != nil {
+		return err
+	}
+
+	metrics.Count(metrics.DispatchOffers, metrics.Dim("Status", string(offer.Status)))
+	return nil
+}
+
+// Close updates the offer. Accepted offers also assign the order and add the job to the
+// rider's load in the same transaction.
+func (s *Store) Close(ctx context.Context, offer dispatch.Offer, status dispatch.OfferStatus, now time.Time) error {
+	values, err := attributevalue.MarshalMap(map[string]interface{}{
+		":status": status,
+		":open":   offer.Status,
+		":now":    now.UnixMilli(),
+	})
+	if err != nil {
+		return err
+	}
+
+	update := types.Update{
+		TableName:                 aws.String(s.Tables.Offers),
+		Key:                       offerKey(offer.OrderId, offer.Attempt),
+		UpdateExpression:          aws.String("SET #status = :status, respondedon = :now"),
+		ConditionExpression:       aws.String("#status = :open"),
+		ExpressionAttributeNames:  map[string]string{"#status": "status"},
+		ExpressionAttributeValues: values,
+	}
+	if status == dispatch.Accepted {
+		// the offer is accepted by the offered rider before it expires
+		values[":riderid"] = &types.AttributeValueMemberS{Value: offer.RiderId}
+		update.ConditionExpression = aws.String("#status = :open AND riderid = :riderid AND expireson > :now")
+	}
+
+	items := []types.TransactWriteItem{{Update: &update}}
+	if status == dispatch.Accepted {
+		acknowledged, _ := attributevalue.Marshal(services.Acknowledged)
+		assigned := services.OrderEvent{
+			Type:       services.EventAssigned,
+			Status:     services.Acknowledged,
+			Actor:      services.RiderActor(offer.RiderId),
+			OccurredOn: services.UnixMilliTime(now.UnixMilli()),
+			RiderId:    offer.RiderId,
+		}
+		orderValues, err := attributevalue.MarshalMap(map[string]interface{}{
+			":riderid": offer.RiderId,
+			":now":     now.UnixMilli(),
+			":event":   []services.OrderEvent{assigned},
+			":empty":   []services.OrderEvent{},
+		})
+		if err != nil {
+			return err
+		}
+		orderValues[":acknowledged"] = acknowledged
+
+		items = append(items,
+			types.TransactWriteItem{Update: &types.Update{
+				TableName: aws.String(s.Tables.Orders),
+				Key: map[string]types.AttributeValue{
+					"userid":  &types.AttributeValueMemberS{Value: offer.UserId},
+					"orderid": &types.AttributeValueMemberS{Value: offer.OrderId},
+				},
+				UpdateExpression:          aws.String("SET #data.riderid = :riderid, #data.assignedon = :now, #data.modifiedon = :now, #events = list_append(if_not_exists(#events, :empty), :event)"),
+				ConditionExpression:       aws.String("#data.#status = :acknowledged AND attribute_not_exists(#data.riderid)"),
+				ExpressionAttributeNames:  map[string]string{"#data": services.OrderDataAttribute, "#status": "status", "#events": services.OrderEventsAttribute},
+				ExpressionAttributeValues: orderValues,
+			}},
+			types.TransactWriteItem{Update: &types.Update{
+				TableName:                aws.String(s.Tables.Riders),
+				Key:                      map[string]types.AttributeValue{"riderid": &types.AttributeValueMemberS{Value: offer.RiderId}},
+				UpdateExpression:         aws.String("ADD activejobs :one, jobs :job"),
+				ConditionExpression:      aws.String("#shift = :on"),
+				ExpressionAttributeNames: map[string]string{"#shift": "shift"},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":one": &types.AttributeValueMemberN{Value: "1"},
+					":job": &types.AttributeValueMemberSS{Value: []string{fleet.JobKey(offer.UserId, offer.OrderId)}},
+					":on":  &types.AttributeValueMemberS{Value: string(fleet.OnShift)},
+				},
+			}},
+		)
+	}
+
+	_, err = s.DDB.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+
+	var cancelled *types.TransactionCanceledException
+	if errors.As(err, &cancelled) {
+		for i, reason := range cancelled.CancellationReasons {
+			if aws.ToString(reason.Code) != "ConditionalCheckFailed" {
+				continue
+			} else if i == 0 {
+				return dispatch.ErrOfferClosed
+			} else if i == 1 {
+				return fmt.Errorf("%w: order %s cancelled or assigned", dispatch.ErrOfferClosed, offer.OrderId)
+			} else {
+				return fleet.ErrNotOnShift
+			}
+		}
+	} else if err != nil {
+		return err
+	}
+
+	metrics.Count(metrics.DispatchOffers, metrics.Dim("Status", string(status)))
+	return nil
+}
+
+// Due returns the open offers expired before now
+func (s *Store) Due(ctx context.Context, now time.Time) ([]dispatch.Offer, error) {
+	due := []dispatch.Offer{}
+	for _, status := range []dispatch.OfferStatus{dispatch.Offered, dispatch.Waiting} {
+		paginator := dynamodb.NewQueryPaginator(s.DDB, &dynamodb.QueryInput{
+			TableName:                aws.String(s.Tables.Offers),
+			IndexName:                aws.String(StatusIndex),
+			KeyConditionExpression:   aws.String("#status = :status AND expireson <= :now"),
+			ExpressionAttributeNames: map[string]string{"#status": "status"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":status": &types.AttributeValueMemberS{Value: string(status)},
+				":now":    &types.AttributeValueMemberN{Value: fmt.Sprint(now.UnixMilli())},
+			},
+		})
+		for paginator.HasMorePages() {
+			page := []dispatch.Offer{}
+			if output, err := paginator.NextPage(ctx); err != nil {
+				return nil, err
+			} else if err := attributevalue.UnmarshalListOfMaps(output.Items, &page); err != nil {
+				return nil, err
+			}
+			due = append(due, page...)
+		}
+	}
+	return due, nil
+}
+
+// RiderOffers returns the rider's offers waiting for a response
+func (s *Store) RiderOffers(ctx context.Context, riderid string, now time.Time) ([]dispatch.Offer, error) {
+	output, err := s.DDB.Query(ctx, &dynamodb.QueryInput{
+		TableName:                aws.String(s.Tables.Offers),
+		IndexName:                aws.String(RiderIndex),
+		KeyConditionExpression:   aws.String("riderid = :riderid AND expireson > :now"),
+		FilterExpression:         aws.String("#status = :offered"),
+		ExpressionAttributeNames: map[string]string{"#status": "status"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":riderid": &types.AttributeValueMemberS{Value: riderid},
+			":now":     &types.AttributeValueMemberN{Value: fmt.Sprint(now.UnixMilli())},
+			":offered": &types.AttributeValueMemberS{Value: string(dispatch.Offered)},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	offers := []dispatch.Offer{}
+	if err := attributevalue.UnmarshalListOfMaps(output.Items, &offers); err != nil {
+		return nil, err
+	}
+	return offers, nil
+}
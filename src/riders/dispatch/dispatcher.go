@@ -0,0 +1,175 @@
+package dispatch
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrOfferNotFound = errors.New("offer not found")
+	ErrOfferClosed   = errors.New("offer no longer open")
+)
+
+type OfferStatus string
+
+const (
+	Offered  OfferStatus = "offered"
+	Accepted OfferStatus = "accepted"
+	Declined OfferStatus = "declined"
+	Expired  OfferStatus = "expired"
+
+	// no rider was available. the job is dispatched again when the offer expires.
+	Waiting OfferStatus = "waiting"
+)
+
+// Offer is one attempt to assign the order. Attempts are numbered from one.
+type Offer struct {
+	OrderId     string      `json:"orderid" dynamodbav:"orderid"`
+	Attempt     int         `json:"attempt" dynamodbav:"attempt"`
+	UserId      string      `json:"userid" dynamodbav:"userid"`
+	RiderId     string      `json:"riderid,omitempty" dynamodbav:"riderid,omitempty"`
+	Status      OfferStatus `json:"status" dynamodbav:"status"`
+	PickupKm    float64     `json:"pickupkm,omitempty" dynamodbav:"pickupkm,omitempty"`
+	Score       float64     `json:"-" dynamodbav:"score,omitempty"`
+	OfferedOn   int64       `json:"offeredon" dynamodbav:"offeredon"`
+	ExpiresOn   int64       `json:"expireson" dynamodbav:"expireson"`
+	RespondedOn int64       `json:"respondedon,omitempty" dynamodbav:"respondedon,omitempty"`
+}
+
+// Open reports whether the offer waits for a response or a retry
+func (offer Offer) Open(now time.Time) bool {
+	return (offer.Status == Offered || offer.Status == Waiting) && now.UnixMilli() < offer.ExpiresOn
+}
+
+// Request is the dispatcher lambda payload of an acknowledged order
+type Request struct {
+	OrderId string `json:"orderid"`
+	UserId  string `json:"userid"`
+}
+
+// Store is the dispatch state. ddbstore is the DynamoDB implementation.
+type Store interface {
+	// Job returns nil when the order is not acknowledged or already has a rider
+	Job(ctx context.Context, userid, orderid string) (*Job, error)
+	Candidates(ctx context.Context) ([]Candidate, error)
+	Offers(ctx context.Context, orderid string) ([]Offer, error)
+
+	// PutOffer returns ErrOfferClosed when the attempt was already made
+	PutOffer(ctx context.Context, offer Offer) error
+
+	// Close changes an open offer to the status. Accepted offers assign the order to the
+	// rider. ErrOfferClosed is returned when the offer is no longer open.
+	Close(ctx context.Context, offer Offer, status OfferStatus, now time.Time) error
+
+	// Due returns the open offers expired before now
+	Due(ctx context.Context, now time.Time) ([]Offer, error)
+}
+
+type Dispatcher struct {
+	Engine Engine
+	Store  Store
+}
+
+// Dispatch offers the order to the next rider. An open offer is returned unchanged, so
+// retried invocations do not offer the job twice. nil is returned when the order does not
+// need a rider.
+func (d Dispatcher) Dispatch(ctx context.Context, userid, orderid string, now time.Time) (*Offer, error) {
+	job, err := d.Store.Job(ctx, userid, orderid)
+	if err != nil || job == nil {
+		return nil, err
+	}
+
+	offers, err := d.Store.Offers(ctx, orderid)
+	if err != nil {
+		return nil, err
+	}
+	for i := range offers {
+		if offers[i].Status == Accepted {
+			return nil, nil
+		} else if offers[i].Open(now) {
+			return &offers[i], nil
+		}
+	}
+
+	// expired offers the sweep has not closed yet
+	for _, offer := range offers {
+		if offer.Status == Offered || offer.Status == Waiting {
+			if err := d.Store.Close(ctx, offer, Expired, now); err != nil && !errors.Is(err, ErrOfferClosed) {
+				return nil, err
+			}
+		}
+	}
+
+	candidates, err := d.Store.Candidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	offer, err := d.Engine.Next(*job, candidates, offers, now)
+	if errors.Is(err, ErrAttemptsExceeded) {
+		return nil, err
+	} else if err := d.Store.PutOffer(ctx, offer); err != nil {
+		return nil, err
+	}
+	return &offer, err
+}
+
+// Respond records the rider's response. Declined jobs are offered to the next rider.
+func (d Dispatcher) Respond(ctx context.Context, riderid, orderid string, attempt int, accept bool, now time.Time) (*Offer, error) {
+	offers, err := d.Store.Offers(ctx, orderid)
+	if err != nil {
+		return nil, err
+	}
+
+	var offer *Offer
+	for i := range offers {
+		if offers[i].Attempt == attempt && offers[i].RiderId == riderid {
+			offer = &offers[i]
+		}
+	}
+	if offer == nil {
+		return nil, ErrOfferNotFound
+	} else if offer.Status != Offered || !offer.Open(now) {
+		return nil, ErrOfferClosed
+	}
+
+	status := Declined
+	if accept {
+		status = Accepted
+	}
+	if err := d.Store.Close(ctx, *offer, status, now); err != nil {
+		return nil, err
+	}
+	offer.Status, offer.RespondedOn = status, now.UnixMilli()
+
+	if !accept {
+		if _, err := d.Dispatch(ctx, offer.UserId, orderid, now); err != nil && !errors.Is(err, ErrNoRiderAvailable) {
+			return offer, err
+		}
+	}
+	return offer, nil
+}
+
+// Sweep dispatches the jobs of expired offers again. The number of jobs dispatched is returned.
+func (d Dispatcher) Sweep(ctx context.Context, now time.Time) (int, error) {
+	due, err := d.Store.Due(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	dispatched := 0
+	for _, offer := range due {
+		if err := d.Store.Close(ctx, offer, Expired, now); errors.Is(err, ErrOfferClosed) {
+			continue
+		} else if err != nil {
+			return dispatched, err
+		}
+
+		if _, err := d.Dispatch(ctx, offer.UserId, offer.OrderId, now); err != nil && !errors.Is(err, ErrNoRiderAvailable) && !errors.Is(err, ErrAttemptsExceeded) {
+			return dispatched, err
+		}
+		dispatched++
+	}
+	return dispatched, nil
+}
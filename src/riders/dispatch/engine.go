@@ -0,0 +1,190 @@
+// Package dispatch assigns acknowledged orders to riders on shift. The engine scores riders by
+// pickup distance, remaining bike range and current load without any AWS dependency; the
+// Dispatcher offers the job to the best rider through a Store, e.g. ddbstore.
+package dispatch
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+var (
+	ErrNoRiderAvailable = errors.New("no rider available")
+	ErrAttemptsExceeded = errors.New("dispatch attempts exceeded")
+)
+
+// Point is a WGS84 coordinate
+type Point struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+const earthRadiusKm = 6371.0
+
+// DistanceKm is the haversine great circle distance
+func DistanceKm(a, b Point) float64 {
+	lat1, lat2 := a.Latitude*math.Pi/180, b.Latitude*math.Pi/180
+	dlat := lat2 - lat1
+	dlon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dlat/2)*math.Sin(dlat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dlon/2)*math.Sin(dlon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Job is an acknowledged order waiting for a rider
+type Job struct {
+	OrderId      string
+	UserId       string
+	RestaurantId string
+	Pickup       Point
+	Dropoff      Point
+}
+
+// TripKm is the restaurant to delivery address distance
+func (job Job) TripKm() float64 {
+	return DistanceKm(job.Pickup, job.Dropoff)
+}
+
+// Candidate is a rider on shift. RangeKm is the full battery range; zero when unknown.
+// Battery is the last reported battery percentage; zero when unknown.
+type Candidate struct {
+	RiderId    string
+	Location   Point
+	LocatedOn  int64
+	RangeKm    float64
+	Battery    int
+	ActiveJobs int
+}
+
+// RemainingKm is the range left on the battery. Unknown battery levels assume a full battery.
+func (c Candidate) RemainingKm() float64 {
+	if c.Battery <= 0 {
+		return c.RangeKm
+	}
+	return c.RangeKm * float64(c.Battery) / 100
+}
+
+// Weights of the score terms. Lower scores are better.
+type Weights struct {
+	PickupKm  float64 // per km from the rider to the restaurant
+	ActiveJob float64 // per job the rider is already carrying
+	RangeUse  float64 // per fraction of the remaining range the trip uses
+}
+
+// Engine is the dispatch policy
+type Engine struct {
+	Weights Weights
+
+	MaxPickupKm    float64       // riders further from the restaurant are not offered the job
+	RangeReserve   float64       // remaining range must cover the trip times the reserve
+	MaxActiveJobs  int           // riders carrying this many jobs are not offered another
+	MaxLocationAge time.Duration // riders with older pings are not offered the job
+
+	OfferTimeout time.Duration // offers expire without a response
+	ReofferAfter time.Duration // riders who declined or ignored the job are offered it again after
+	RetryAfter   time.Duration // jobs without an available rider are dispatched again after
+	MaxAttempts  int
+}
+
+var Default = Engine{
+	Weights:        Weights{PickupKm: 1, ActiveJob: 1.5, RangeUse: 2},
+	MaxPickupKm:    5,
+	RangeReserve:   1.5,
+	MaxActiveJobs:  2,
+	MaxLocationAge: 2 * time.Minute,
+	OfferTimeout:   45 * time.Second,
+	ReofferAfter:   5 * time.Minute,
+	RetryAfter:     time.Minute,
+	MaxAttempts:    20,
+}
+
+// Ranked is a candidate able to take the job and its score
+type Ranked struct {
+	Candidate
+	PickupKm float64
+	Score    float64
+}
+
+// Score returns false when the rider is not able to take the job
+func (e Engine) Score(job Job, c Candidate, now time.Time) (Ranked, bool) {
+	ranked := Ranked{Candidate: c, PickupKm: DistanceKm(c.Location, job.Pickup)}
+
+	if now.Sub(time.UnixMilli(c.LocatedOn)) > e.MaxLocationAge {
+		return ranked, false
+	} else if ranked.PickupKm > e.MaxPickupKm {
+		return ranked, false
+	} else if c.ActiveJobs >= e.MaxActiveJobs {
+		return ranked, false
+	}
+
+	rangeUse := 0.0
+	if c.RangeKm > 0 {
+		remaining := c.RemainingKm()
+		trip := ranked.PickupKm + job.TripKm()
+		if remaining < trip*e.RangeReserve {
+			return ranked, false
+		}
+		rangeUse = trip / remaining
+	}
+
+	ranked.Score = e.Weights.PickupKm*ranked.PickupKm + e.Weights.ActiveJob*float64(c.ActiveJobs) + e.Weights.RangeUse*rangeUse
+	return ranked, true
+}
+
+// Rank returns the riders able to take the job, best first. Riders offered the job within
+// ReofferAfter are excluded.
+func (e Engine) Rank(job Job, candidates []Candidate, offers []Offer, now time.Time) []Ranked {
+	recent := map[string]bool{}
+	for _, offer := range offers {
+		if offer.RiderId != "" && now.Sub(time.UnixMilli(offer.OfferedOn)) < e.ReofferAfter {
+			recent[offer.RiderId] = true
+		}
+	}
+
+	ranked := []Ranked{}
+	for _, c := range candidates {
+		if recent[c.RiderId] {
+			continue
+		} else if r, ok := e.Score(job, c, now); ok {
+			ranked = append(ranked, r)
+		}
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score < ranked[j].Score
+		}
+		return ranked[i].RiderId < ranked[j].RiderId
+	})
+	return ranked
+}
+
+// Next returns the next offer of the job: the best rider, or a waiting offer retried after
+// RetryAfter when no rider is available.
+func (e Engine) Next(job Job, candidates []Candidate, offers []Offer, now time.Time) (Offer, error) {
+	if len(offers) >= e.MaxAttempts {
+		return Offer{}, ErrAttemptsExceeded
+	}
+
+	offer := Offer{
+		OrderId:   job.OrderId,
+		UserId:    job.UserId,
+		Attempt:   len(offers) + 1,
+		OfferedOn: now.UnixMilli(),
+	}
+
+	if ranked := e.Rank(job, candidates, offers, now); len(ranked) == 0 {
+		offer.Status = Waiting
+		offer.ExpiresOn = now.Add(e.RetryAfter).UnixMilli()
+		return offer, ErrNoRiderAvailable
+	} else {
+		offer.Status = Offered
+		offer.RiderId = ranked[0].RiderId
+		offer.PickupKm = ranked[0].PickupKm
+		offer.Score = ranked[0].Score
+		offer.ExpiresOn = now.Add(e.OfferTimeout).UnixMilli()
+		return offer, nil
+	}
+}
@@ -0,0 +1,117 @@
+package dispatch
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 0.01 degrees of latitude is 1.112 km
+var job = Job{OrderId: "o-1", UserId: "u-1", Pickup: Point{}, Dropoff: Point{Latitude: 0.02}}
+
+func candidate(riderid string, latitude float64, now time.Time) Candidate {
+	return Candidate{RiderId: riderid, Location: Point{Latitude: latitude}, LocatedOn: now.UnixMilli()}
+}
+
+func TestScore(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+
+	tests := []struct {
+		name   string
+		modify func(c *Candidate)
+		ok     bool
+		score  float64
+	}{
+		{"pickup distance", func(c *Candidate) {}, true, 1.112},
+		{"active job", func(c *Candidate) { c.ActiveJobs = 1 }, true, 1.112 + 1.5},
+		// the 3.336 km trip uses 0.2224 of the 15 km remaining
+		{"range use", func(c *Candidate) { c.RangeKm, c.Battery = 30, 50 }, true, 1.112 + 2*0.2224},
+		{"unknown battery is full", func(c *Candidate) { c.RangeKm = 15 }, true, 1.112 + 2*0.2224},
+		{"stale location", func(c *Candidate) { c.LocatedOn = now.Add(-3 * time.Minute).UnixMilli() }, false, 0},
+		{"too far", func(c *Candidate) { c.Location.Latitude = 0.05 }, false, 0},
+		{"too many jobs", func(c *Candidate) { c.ActiveJobs = 2 }, false, 0},
+		{"range below the reserve", func(c *Candidate) { c.RangeKm, c.Battery = 20, 20 }, false, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := candidate("r-1", 0.01, now)
+			test.modify(&c)
+
+			ranked, ok := Default.Score(job, c, now)
+			if ok != test.ok {
+				t.Fatalf("Score ok = %t, want %t", ok, test.ok)
+			} else if ok && math.Abs(ranked.Score-test.score) > 0.001 {
+				t.Errorf("Score = %.4f, want %.4f", ranked.Score, test.score)
+			}
+		})
+	}
+}
+
+func TestRank(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+	near, far, busy := candidate("r-near", 0.005, now), candidate("r-far", 0.02, now), candidate("r-busy", 0.01, now)
+	busy.ActiveJobs = 1
+	twin := candidate("r-twin", 0.005, now)
+
+	tests := []struct {
+		name       string
+		candidates []Candidate
+		offers     []Offer
+		want       string
+	}{
+		{"nearest first", []Candidate{far, near}, nil, "r-near,r-far"},
+		{"load before distance", []Candidate{busy, far}, nil, "r-far,r-busy"},
+		{"ties by rider id", []Candidate{twin, near}, nil, "r-near,r-twin"},
+		{"recently offered", []Candidate{near, far}, []Offer{{RiderId: "r-near", OfferedOn: now.Add(-time.Minute).UnixMilli()}}, "r-far"},
+		{"offered again after reoffer", []Candidate{near, far}, []Offer{{RiderId: "r-near", OfferedOn: now.Add(-10 * time.Minute).UnixMilli()}}, "r-near,r-far"},
+		{"nobody", nil, nil, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			riders := []string{}
+			for _, ranked := range Default.Rank(job, test.candidates, test.offers, now) {
+				riders = append(riders, ranked.RiderId)
+			}
+			if got := strings.Join(riders, ","); got != test.want {
+				t.Errorf("Rank = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+	near := candidate("r-near", 0.005, now)
+
+	tests := []struct {
+		name       string
+		candidates []Candidate
+		offers     []Offer
+		err        error
+		status     OfferStatus
+		riderid    string
+		attempt    int
+		expiresOn  time.Time
+	}{
+		{"offered", []Candidate{near}, nil, nil, Offered, "r-near", 1, now.Add(Default.OfferTimeout)},
+		{"waiting", nil, []Offer{{Status: Expired}}, ErrNoRiderAvailable, Waiting, "", 2, now.Add(Default.RetryAfter)},
+		{"attempts exceeded", []Candidate{near}, make([]Offer, Default.MaxAttempts), ErrAttemptsExceeded, "", "", 0, time.UnixMilli(0)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			offer, err := Default.Next(job, test.candidates, test.offers, now)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Next err = %v, want %v", err, test.err)
+			} else if offer.Status != test.status || offer.RiderId != test.riderid || offer.Attempt != test.attempt {
+				t.Errorf("Next = %s %s attempt %d, want %s %s attempt %d", offer.Status, offer.RiderId, offer.Attempt, test.status, test.riderid, test.attempt)
+			} else if offer.ExpiresOn != test.expiresOn.UnixMilli() {
+				t.Errorf("Next expires on %d, want %d", offer.ExpiresOn, test.expiresOn.UnixMilli())
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/config"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/internal/tracing"
+	"github.com/kscott5/fds/riders/dispatch"
+	"github.com/kscott5/fds/riders/dispatch/ddbstore"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	_ "github.com/aws/aws-lambda-go/lambdacontext" // IMPORTANT: package level init() in use.
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+var settings config.Dispatcher
+
+// event is a dispatch.Request of an acknowledged order, or the EventBridge schedule that
+// dispatches the jobs of expired offers again
+type event struct {
+	dispatch.Request
+	DetailType string `json:"detail-type"`
+}
+
+func dispatchOrders(ctx context.Context, payload json.RawMessage) (err error) {
+	ctx, span := tracing.StartInvocation(ctx, "dispatch orders", attribute.String("faas.trigger", "other"))
+	defer func() { tracing.EndInvocation(ctx, span, err) }()
+
+	logger := logging.FromContext(ctx)
+
+	e := event{}
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return err
+	}
+
+	dispatcher := dispatch.Dispatcher{
+		Engine: dispatch.Default,
+		Store: ddbstore.New(client.NewDynamodb(), ddbstore.Tables{
+			Orders:      settings.OrdersTable,
+			Restaurants: settings.RestaurantsTable,
+			Riders:      settings.RidersTable,
+			Offers:      settings.OffersTable,
+		}),
+	}
+
+	if e.DetailType == "Scheduled Event" {
+		logger.Info("lambda function: dispatch expired offers")
+		dispatched, err := dispatcher.Sweep(ctx, time.Now())
+		logger.Info("expired offers dispatched", zap.Int("dispatched", dispatched))
+		return err
+	}
+
+	logger = logger.With(zap.String("orderid", e.OrderId))
+	logger.Info("lambda function: dispatch order")
+
+	offer, err := dispatcher.Dispatch(ctx, e.UserId, e.OrderId, time.Now())
+	if errors.Is(err, dispatch.ErrNoRiderAvailable) {
+		logger.Info("no rider available. dispatch retried with the schedule.")
+		return nil
+	} else if errors.Is(err, dispatch.ErrAttemptsExceeded) {
+		logger.Error("order not dispatched", zap.Error(err))
+		return nil
+	} else if err != nil {
+		return err
+	} else if offer == nil {
+		logger.Info("order does not need a rider")
+		return nil
+	}
+
+	logger.Info("order offered", zap.String("riderid", offer.RiderId), zap.Int("attempt", offer.Attempt), zap.String("status", string(offer.Status)))
+	return nil
+}
+
+func main() {
+	config.MustLoad(&settings)
+	lambda.Start(dispatchOrders)
+}
@@ -0,0 +1,205 @@
+// Package fleet is the rider record and the rider location pings shared by the riders api and
+// order dispatch. The rider id is the Cognito sub of the rider.
+package fleet
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	DefaultRidersTable    = "FDSAppsRiders"
+	DefaultLocationsTable = "FDSAppsRiderLocations"
+
+	// location pings are kept for a day
+	LocationTTL = 24 * time.Hour
+)
+
+var (
+	ErrRiderNotFound = errors.New("rider not found")
+	ErrNotOnShift    = errors.New("rider not on shift")
+)
+
+type Shift string
+
+const (
+	OffShift Shift = "off"
+	OnShift  Shift = "on"
+)
+
+// Bike is the rider's electric bike
+type Bike struct {
+	BikeId    string  `json:"bikeid" dynamodbav:"bikeid"`
+	Model     string  `json:"model,omitempty" dynamodbav:"model,omitempty"`
+	BatteryWh int     `json:"batterywh,omitempty" dynamodbav:"batterywh,omitempty"`
+	RangeKm   float64 `json:"rangekm,omitempty" dynamodbav:"rangekm,omitempty"`
+}
+
+type Rider struct {
+	RiderId  string `json:"riderid" dynamodbav:"riderid"`
+	FullName string `json:"fullname" dynamodbav:"fullname"`
+	Phone    string `json:"phone" dynamodbav:"phone"`
+	Bike     Bike   `json:"bike" dynamodbav:"bike"`
+
+	Shift          Shift     `json:"shift" dynamodbav:"shift"`
+	ShiftStartedOn int64     `json:"shiftstartedon,omitempty" dynamodbav:"shiftstartedon,omitempty"`
+	LastLocation   *Location `json:"lastlocation,omitempty" dynamodbav:"lastlocation,omitempty"`
+	ActiveJobs     int       `json:"activejobs" dynamodbav:"activejobs,omitempty"`
+	Jobs           []string  `json:"jobs,omitempty" dynamodbav:"jobs,stringset,omitempty"`
+
+	CreatedOn  int64 `json:"createdon" dynamodbav:"createdon"`
+	ModifiedOn int64 `json:"modifiedon" dynamodbav:"modifiedon"`
+}
+
+// Location is a rider location ping. Battery is the bike battery percentage.
+type Location struct {
+	RiderId    string  `json:"riderid" dynamodbav:"riderid"`
+	RecordedOn int64   `json:"recordedon" dynamodbav:"recordedon"`
+	Latitude   float64 `json:"latitude" dynamodbav:"latitude"`
+	Longitude  float64 `json:"longitude" dynamodbav:"longitude"`
+	SpeedKmh   float64 `json:"speedkmh,omitempty" dynamodbav:"speedkmh,omitempty"`
+	Battery    int     `json:"battery,omitempty" dynamodbav:"battery,omitempty"`
+	ExpiresAt  int64   `json:"-" dynamodbav:"expiresat,omitempty"`
+}
+
+// JobKey is the rider job of an order, userid/orderid, the orders table key
+func JobKey(userid, orderid string) string {
+	return userid + "/" + orderid
+}
+
+// SplitJobKey returns the userid and orderid of the job
+func SplitJobKey(job string) (string, string) {
+	userid, orderid, _ := strings.Cut(job, "/")
+	return userid, orderid
+}
+
+func riderKey(riderid string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"riderid": &types.AttributeValueMemberS{Value: riderid}}
+}
+
+// GetRider returns nil when the rider does not exist
+func GetRider(ctx context.Context, ddb *dynamodb.Client, tableName, riderid string) (*Rider, error) {
+	output, err := ddb.GetItem(ctx, &dynamodb.GetItemInput{TableName: aws.String(tableName), Key: riderKey(riderid)})
+	if err != nil {
+		return nil, err
+	} else if output.Item == nil {
+		return nil, nil
+	}
+
+	rider := Rider{}
+	if err := attributevalue.UnmarshalMap(output.Item, &rider); err != nil {
+		return nil, err
+	}
+	return &rider, nil
+}
+
+// PutProfile creates or updates the rider profile and bike. New riders start off shift.
+func PutProfile(ctx context.Context, ddb *dynamodb.Client, tableName string, rider Rider) (*Rider, error) {
+	now := time.Now().UnixMilli()
+	values, err := attributevalue.MarshalMap(map[string]interface{}{
+		":fullname": rider.FullName,
+		":phone":    rider.Phone,
+		":bike":     rider.Bike,
+		":now":      now,
+		":off":      OffShift,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := ddb.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(tableName),
+		Key:                       riderKey(rider.RiderId),
+		UpdateExpression:          aws.String("SET fullname = :fullname, phone = :phone, bike = :bike, modifiedon = :now, createdon = if_not_exists(createdon, :now), #shift = if_not_exists(#shift, :off)"),
+		ExpressionAttributeNames:  map[string]string{"#shift": "shift"},
+		ExpressionAttributeValues: values,
+		ReturnValues:              types.ReturnValueAllNew,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	updated := Rider{}
+	if err := attributevalue.UnmarshalMap(output.Attributes, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// SetShift starts or ends the rider's shift
+func SetShift(ctx context.Context, ddb *dynamodb.Client, tableName, riderid string, shift Shift) (*Rider, error) {
+	now := time.Now().UnixMilli()
+	values, err := attributevalue.MarshalMap(map[string]interface{}{":shift": shift, ":now": now})
+	if err != nil {
+		return nil, err
+	}
+
+	expression := "SET #shift = :shift, modifiedon = :now, shiftstartedon = :now"
+	if shift == OffShift {
+		expression = "SET #shift = :shift, modifiedon = :now REMOVE shiftstartedon"
+	}
+
+	var conditionFailed *types.ConditionalCheckFailedException
+	output, err := ddb.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(tableName),
+		Key:                       riderKey(riderid),
+		UpdateExpression:          aws.String(expression),
+		ConditionExpression:       aws.String("attribute_exists(riderid)"),
+		ExpressionAttributeNames:  map[string]string{"#shift": "shift"},
+		ExpressionAttributeValues: values,
+		ReturnValues:              types.ReturnValueAllNew,
+	})
+	if errors.As(err, &conditionFailed) {
+		return nil, ErrRiderNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	rider := Rider{}
+	if err := attributevalue.UnmarshalMap(output.Attributes, &rider); err != nil {
+		return nil, err
+	}
+	return &rider, nil
+}
+
+// PutLocation stores the ping with the rider's last location. Riders off shift are not tracked.
+func PutLocation(ctx context.Context, ddb *dynamodb.Client, ridersTable, locationsTable string, location Location) error {
+	location.ExpiresAt = time.UnixMilli(location.RecordedOn).Add(LocationTTL).Unix()
+
+	item, err := attributevalue.MarshalMap(location)
+	if err != nil {
+		return err
+	}
+	values, err := attributevalue.MarshalMap(map[string]interface{}{":location": location, ":on": OnShift})
+	if err != nil {
+		return err
+	}
+
+	_, err = ddb.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Update: &types.Update{
+				TableName:                 aws.String(ridersTable),
+				Key:                       riderKey(location.RiderId),
+				UpdateExpression:          aws.String("SET lastlocation = :location"),
+				ConditionExpression:       aws.String("#shift = :on"),
+				ExpressionAttributeNames:  map[string]string{"#shift": "shift"},
+				ExpressionAttributeValues: values,
+			}},
+			{Put: &types.Put{TableName: aws.String(locationsTable), Item: item}},
+		},
+	})
+
+	var cancelled *types.TransactionCanceledException
+	if errors.As(err, &cancelled) && len(cancelled.CancellationReasons) > 0 && aws.ToString(cancelled.CancellationReasons[0].Code) == "ConditionalCheckFailed" {
+		return ErrNotOnShift
+	}
+	return err
+}
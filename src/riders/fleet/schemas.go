@@ -0,0 +1,41 @@
+package fleet
+
+import (
+	"github.com/kscott5/fds/internal/schema"
+)
+
+// RiderSchema validates PUT /riders/{id} request bodies
+var RiderSchema = schema.Object(map[string]*schema.Field{
+	"fullname": schema.String().Required().MaxLength(128),
+	"phone":    schema.String().Required().Pattern(`^\+?[0-9 ()-]{7,20}$`),
+	"bike": schema.Object(map[string]*schema.Field{
+		"bikeid":    schema.String().Required().MaxLength(64),
+		"model":     schema.String().MaxLength(64),
+		"batterywh": schema.Integer().Min(0).Max(5000),
+		"rangekm":   schema.Number().Min(0).Max(500),
+	}).Required(),
+
+	// read only attributes returned with GET /riders/{id}
+	"riderid":        schema.String().MaxLength(64),
+	"shift":          schema.String().Enum(string(OffShift), string(OnShift)),
+	"shiftstartedon": schema.Integer(),
+	"lastlocation":   schema.AnyValue(),
+	"activejobs":     schema.Integer(),
+	"jobs":           schema.Array(schema.String()),
+	"createdon":      schema.Integer(),
+	"modifiedon":     schema.Integer(),
+})
+
+// ShiftSchema validates POST /riders/{id}/shift request bodies
+var ShiftSchema = schema.Object(map[string]*schema.Field{
+	"shift": schema.String().Required().Enum(string(OffShift), string(OnShift)),
+})
+
+// LocationSchema validates POST /riders/{id}/locations request bodies. recordedon defaults to now.
+var LocationSchema = schema.Object(map[string]*schema.Field{
+	"latitude":   schema.Number().Required().Min(-90).Max(90),
+	"longitude":  schema.Number().Required().Min(-180).Max(180),
+	"speedkmh":   schema.Number().Min(0).Max(80),
+	"battery":    schema.Integer().Min(0).Max(100),
+	"recordedon": schema.Integer().Min(0),
+})
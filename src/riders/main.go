@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/config"
+	"github.com/kscott5/fds/internal/push"
+	"github.com/kscott5/fds/internal/router"
+	orders "github.com/kscott5/fds/orders/services"
+	"github.com/kscott5/fds/riders/services"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	_ "github.com/aws/aws-lambda-go/lambdacontext" // IMPORTANT: package level init() in use.
+)
+
+func main() {
+	config.MustLoad(&services.Config)
+	orders.EtaProfile.SpeedKmh = services.Config.EtaSpeedKmh
+	orders.EtaProfile.DetourFactor = services.Config.EtaDetourFactor
+	orders.Push = push.New(client.LoadConfig(), client.NewDynamodb(), services.Config.ConnectionsTable, services.Config.WebSocketEndpoint)
+
+	routes := router.New("riders")
+	services.Register(routes)
+
+	// AWS SDK lambda function handler
+	lambdaHandler := lambda.NewHandler(func(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+		return routes.Dispatch(ctx, request)
+	})
+
+	lambda.Start(lambdaHandler)
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/logging"
+	orders "github.com/kscott5/fds/orders/services"
+	"github.com/kscott5/fds/orders/zones"
+	"github.com/kscott5/fds/riders/fleet"
+
+	"go.uber.org/zap"
+)
+
+// EtaRefreshInterval bounds the delivery estimate updates of a rider's jobs between pings
+const EtaRefreshInterval = 30 * time.Second
+
+// refreshEtas updates the delivery estimates of the rider's jobs with the rider location.
+// Failures are logged; the rider state is already stored.
+func refreshEtas(ctx context.Context, jobs []string, location *fleet.Location, maxAge time.Duration) {
+	logger := logging.FromContext(ctx)
+
+	var rider *zones.Point
+	if location != nil {
+		rider = &zones.Point{Latitude: location.Latitude, Longitude: location.Longitude}
+	}
+
+	ddb := client.NewDynamodb()
+	tables := orders.EtaTables{Orders: Config.OrdersTable, Restaurants: Config.RestaurantsTable}
+	for _, job := range jobs {
+		userid, orderid := fleet.SplitJobKey(job)
+		if order, err := orders.RefreshEta(ctx, ddb, tables, userid, orderid, rider, maxAge); err != nil {
+			logger.Warn("delivery estimate not updated", zap.String("orderid", orderid), zap.Error(err))
+		} else if order != nil && order.Eta != nil {
+			logger.Debug("delivery estimate updated", zap.String("orderid", orderid), zap.Int64("deliveron", order.Eta.DeliverOn))
+		}
+	}
+}
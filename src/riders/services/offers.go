@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/internal/schema"
+	"github.com/kscott5/fds/riders/dispatch"
+	"github.com/kscott5/fds/riders/dispatch/ddbstore"
+	"github.com/kscott5/fds/riders/fleet"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"go.uber.org/zap"
+)
+
+// OfferResponseSchema validates POST /riders/{id}/offers/{orderid} request bodies
+var OfferResponseSchema = schema.Object(map[string]*schema.Field{
+	"attempt":  schema.Integer().Required().Min(1),
+	"response": schema.String().Required().Enum("accept", "decline"),
+})
+
+func newStore() *ddbstore.Store {
+	return ddbstore.New(client.NewDynamodb(), ddbstore.Tables{
+		Orders:      Config.OrdersTable,
+		Restaurants: Config.RestaurantsTable,
+		Riders:      Config.RidersTable,
+		Offers:      Config.OffersTable,
+	})
+}
+
+// GetOffers returns the jobs offered to the rider and waiting for a response
+func GetOffers(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb query rider offers")
+
+	riderid, denied := riderFrom(request)
+	if denied != nil {
+		return denied, nil
+	}
+
+	if offers, err := newStore().RiderOffers(ctx, riderid, time.Now()); err != nil {
+		return nil, err
+	} else {
+		return newJsonResponse(200, offers)
+	}
+}
+
+// RespondToOffer accepts or declines an offered job. Declined jobs are offered to the next rider.
+func RespondToOffer(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: respond to dispatch offer")
+	logger.Debug("request body", logging.Body(request.Body))
+
+	riderid, denied := riderFrom(request)
+	if denied != nil {
+		return denied, nil
+	}
+
+	orderid := request.PathParameters["orderid"]
+	requires := map[string]string{"orderid": "string"}
+	if orderid == "" {
+		return client.NewErrorResponse(400, fmt.Sprintf("requires: %s", requires)), nil
+	}
+
+	if violations := OfferResponseSchema.Validate([]byte(request.Body)); len(violations) > 0 {
+		return client.NewValidationResponse(violations), nil
+	}
+
+	body := struct {
+		Attempt  int    `json:"attempt"`
+		Response string `json:"response"`
+	}{}
+	if err := json.Unmarshal([]byte(request.Body), &body); err != nil {
+		return nil, err
+	}
+
+	dispatcher := dispatch.Dispatcher{Engine: dispatch.Default, Store: newStore()}
+	offer, err := dispatcher.Respond(ctx, riderid, orderid, body.Attempt, body.Response == "accept", time.Now())
+	if errors.Is(err, dispatch.ErrOfferNotFound) {
+		return client.NewErrorResponse(404, fmt.Sprintf("offer %d of order %s not found", body.Attempt, orderid)), nil
+	} else if errors.Is(err, dispatch.ErrOfferClosed) || errors.Is(err, fleet.ErrNotOnShift) {
+		return client.NewErrorResponse(409, err.Error()), nil
+	} else if err != nil && offer == nil {
+		return nil, err
+	} else if err != nil {
+		// the decline is recorded. the sweep offers the job again.
+		logger.Warn("declined order not dispatched", zap.Error(err))
+	}
+
+	logger.Info("dispatch offer closed", zap.String("orderid", orderid), zap.String("status", string(offer.Status)))
+
+	// the estimate of an assigned order starts from the rider location
+	if offer.Status == dispatch.Accepted {
+		if rider, err := fleet.GetRider(ctx, client.NewDynamodb(), Config.RidersTable, riderid); err != nil {
+			logger.Warn("rider location not available", zap.Error(err))
+		} else if rider != nil {
+			refreshEtas(ctx, []string{fleet.JobKey(offer.UserId, orderid)}, rider.LastLocation, 0)
+		}
+	}
+	return newJsonResponse(200, offer)
+}
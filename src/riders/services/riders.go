@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/config"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/riders/fleet"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/aws/aws-lambda-go/events"
+	_ "github.com/aws/aws-lambda-go/lambdacontext" // IMPORTANT: package level init() in use.
+
+	"go.uber.org/zap"
+)
+
+// Config is loaded by the lambda main before the first request
+var Config config.Riders
+
+// riderFrom returns the path rider id when the caller is the rider or an administrator
+func riderFrom(request *events.APIGatewayProxyRequest) (string, *events.APIGatewayProxyResponse) {
+	riderid := request.PathParameters["id"]
+	if riderid == "" {
+		return "", client.NewErrorResponse(400, fmt.Sprintf("requires: %s", map[string]string{"id": "string"}))
+	}
+
+	principalId, _ := client.GetPrincipalIdFrom(request.RequestContext.Authorizer)
+	if riderid != principalId && !client.IsMemberOf(request.RequestContext.Authorizer, Config.AdminGroupName) {
+		return "", client.NewErrorResponse(403, fmt.Sprintf("rider %s not available to the caller", riderid))
+	}
+	return riderid, nil
+}
+
+func newJsonResponse(statusCode int, v interface{}) (*events.APIGatewayProxyResponse, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	response := events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers:    client.HttpResponseHeaders,
+		Body:       string(body),
+	}
+	return &response, nil
+}
+
+func GetRiders(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb scan get riders")
+
+	if !client.IsMemberOf(request.RequestContext.Authorizer, Config.AdminGroupName) {
+		return client.NewErrorResponse(403, "list riders requires administrator"), nil
+	}
+
+	limit, startKey, err := client.GetPageFrom(request.QueryStringParameters)
+	if err != nil {
+		return client.NewErrorResponse(400, err.Error()), nil
+	}
+
+	params := dynamodb.ScanInput{
+		TableName:         aws.String(Config.RidersTable),
+		Limit:             aws.Int32(limit),
+		ExclusiveStartKey: startKey,
+	}
+
+	// ?shift=on lists the riders on shift. a page can hold fewer than limit riders.
+	if shift := request.QueryStringParameters["shift"]; shift != "" {
+		if shift != string(fleet.OnShift) && shift != string(fleet.OffShift) {
+			return client.NewErrorResponse(400, "shift requires on or off"), nil
+		}
+		params.FilterExpression = aws.String("#shift = :shift")
+		params.ExpressionAttributeNames = map[string]string{"#shift": "shift"}
+		params.ExpressionAttributeValues = map[string]types.AttributeValue{":shift": &types.AttributeValueMemberS{Value: shift}}
+	}
+
+	riders := []fleet.Rider{}
+	if output, err := client.NewDynamodb().Scan(ctx, &params); err != nil {
+		return nil, err
+	} else if err := attributevalue.UnmarshalListOfMaps(output.Items, &riders); err != nil {
+		return nil, err
+	} else if body, err := json.Marshal(riders); err != nil {
+		return nil, err
+	} else if headers, err := client.NewPageHeaders(output.LastEvaluatedKey); err != nil {
+		return nil, err
+	} else {
+		response := events.APIGatewayProxyResponse{
+			StatusCode: 200,
+			Headers:    headers,
+			Body:       string(body),
+		}
+
+		return &response, nil
+	}
+}
+
+func GetRider(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb get rider")
+	logger.Debug("path parameters", zap.Any("parameters", request.PathParameters))
+
+	riderid, denied := riderFrom(request)
+	if denied != nil {
+		return denied, nil
+	}
+
+	if rider, err := fleet.GetRider(ctx, client.NewDynamodb(), Config.RidersTable, riderid); err != nil {
+		return nil, err
+	} else if rider == nil {
+		return client.NewErrorResponse(404, fmt.Sprintf("rider %s not found", riderid)), nil
+	} else {
+		return newJsonResponse(200, rider)
+	}
+}
+
+// PutRider creates or updates the rider profile and bike. The shift is not changed.
+func PutRider(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb put rider")
+	logger.Debug("request body", logging.Body(request.Body))
+
+	riderid, denied := riderFrom(request)
+	if denied != nil {
+		return denied, nil
+	}
+
+	if violations := fleet.RiderSchema.Validate([]byte(request.Body)); len(violations) > 0 {
+		return client.NewValidationResponse(violations), nil
+	}
+
+	rider := fleet.Rider{}
+	if err := json.Unmarshal([]byte(request.Body), &rider); err != nil {
+		return nil, err
+	}
+	rider.RiderId = riderid
+
+	if updated, err := fleet.PutProfile(ctx, client.NewDynamodb(), Config.RidersTable, rider); err != nil {
+		return nil, err
+	} else {
+		return newJsonResponse(200, updated)
+	}
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"github.com/kscott5/fds/internal/router"
+	"github.com/kscott5/fds/riders/dispatch"
+	"github.com/kscott5/fds/riders/fleet"
+)
+
+// Register adds the riders service routes. The order is the OpenAPI document order.
+func Register(r *router.Router) {
+	r.Handle(router.Route{
+		Method: "GET", Resource: "/riders", Handler: GetRiders,
+		Summary: "List riders. administrators only.",
+		Query: map[string]string{
+			"shift": "on or off filters riders by shift",
+			"limit": "page size. defaults to 100.",
+			"next":  "next page token from the x-fds-next-token response header",
+		},
+		Response: []fleet.Rider{},
+	})
+	r.Handle(router.Route{
+		Method: "GET", Resource: "/riders/{id}", Handler: GetRider,
+		Summary:  "Get a rider",
+		Response: fleet.Rider{},
+	})
+	r.Handle(router.Route{
+		Method: "PUT", Resource: "/riders/{id}", Handler: PutRider,
+		Summary:  "Create or update a rider profile and bike",
+		Request:  fleet.RiderSchema,
+		Response: fleet.Rider{},
+	})
+	r.Handle(router.Route{
+		Method: "POST", Resource: "/riders/{id}/shift", Handler: SetShift,
+		Summary:  "Start or end a rider's shift",
+		Request:  fleet.ShiftSchema,
+		Response: fleet.Rider{},
+	})
+	r.Handle(router.Route{
+		Method: "POST", Resource: "/riders/{id}/locations", Handler: PutLocation,
+		Summary:  "Record a location ping of a rider on shift",
+		Request:  fleet.LocationSchema,
+		Status:   201,
+		Response: fleet.Location{},
+	})
+	r.Handle(router.Route{
+		Method: "GET", Resource: "/riders/{id}/locations", Handler: GetLocations,
+		Summary: "List a rider's recent location pings, newest first",
+		Query: map[string]string{
+			"limit": "page size. defaults to 100.",
+			"next":  "next page token from the x-fds-next-token response header",
+		},
+		Response: []fleet.Location{},
+	})
+	r.Handle(router.Route{
+		Method: "GET", Resource: "/riders/{id}/offers", Handler: GetOffers,
+		Summary:  "List the jobs offered to a rider and waiting for a response",
+		Response: []dispatch.Offer{},
+	})
+	r.Handle(router.Route{
+		Method: "POST", Resource: "/riders/{id}/offers/{orderid}", Handler: RespondToOffer,
+		Summary:  "Accept or decline an offered job before it expires",
+		Request:  OfferResponseSchema,
+		Response: dispatch.Offer{},
+	})
+}
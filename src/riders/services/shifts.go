@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/riders/fleet"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// pings recorded further in the future are rejected
+	MaxClockSkew = time.Minute
+)
+
+// SetShift starts, {"shift": "on"}, or ends, {"shift": "off"}, the rider's shift
+func SetShift(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb set rider shift")
+	logger.Debug("request body", logging.Body(request.Body))
+
+	riderid, denied := riderFrom(request)
+	if denied != nil {
+		return denied, nil
+	}
+
+	if violations := fleet.ShiftSchema.Validate([]byte(request.Body)); len(violations) > 0 {
+		return client.NewValidationResponse(violations), nil
+	}
+
+	shift := struct {
+		Shift fleet.Shift `json:"shift"`
+	}{}
+	if err := json.Unmarshal([]byte(request.Body), &shift); err != nil {
+		return nil, err
+	}
+
+	if rider, err := fleet.SetShift(ctx, client.NewDynamodb(), Config.RidersTable, riderid, shift.Shift); errors.Is(err, fleet.ErrRiderNotFound) {
+		return client.NewErrorResponse(404, fmt.Sprintf("rider %s not found", riderid)), nil
+	} else if err != nil {
+		return nil, err
+	} else {
+		logger.Info("rider shift changed", zap.String("shift", string(rider.Shift)))
+		return newJsonResponse(200, rider)
+	}
+}
+
+// PutLocation records a location ping of a rider on shift
+func PutLocation(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Debug("lambda function: dynamodb put rider location")
+
+	riderid, denied := riderFrom(request)
+	if denied != nil {
+		return denied, nil
+	}
+
+	if violations := fleet.LocationSchema.Validate([]byte(request.Body)); len(violations) > 0 {
+		return client.NewValidationResponse(violations), nil
+	}
+
+	location := fleet.Location{}
+	if err := json.Unmarshal([]byte(request.Body), &location); err != nil {
+		return nil, err
+	}
+	location.RiderId = riderid
+
+	now := time.Now()
+	if location.RecordedOn == 0 {
+		location.RecordedOn = now.UnixMilli()
+	} else if location.RecordedOn > now.Add(MaxClockSkew).UnixMilli() {
+		return client.NewErrorResponse(400, "recordedon can not be in the future"), nil
+	}
+
+	if err := fleet.PutLocation(ctx, client.NewDynamodb(), Config.RidersTable, Config.LocationsTable, location); errors.Is(err, fleet.ErrNotOnShift) {
+		return client.NewErrorResponse(409, fmt.Sprintf("rider %s not found or not on shift", riderid)), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if rider, err := fleet.GetRider(ctx, client.NewDynamodb(), Config.RidersTable, riderid); err != nil {
+		logger.Warn("rider jobs not available", zap.Error(err))
+	} else if rider != nil && len(rider.Jobs) > 0 {
+		refreshEtas(ctx, rider.Jobs, &location, EtaRefreshInterval)
+	}
+	return newJsonResponse(201, location)
+}
+
+// GetLocations returns the rider's recent location pings, newest first
+func GetLocations(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb query rider locations")
+
+	riderid, denied := riderFrom(request)
+	if denied != nil {
+		return denied, nil
+	}
+
+	limit, startKey, err := client.GetPageFrom(request.QueryStringParameters)
+	if err != nil {
+		return client.NewErrorResponse(400, err.Error()), nil
+	}
+
+	params := dynamodb.QueryInput{
+		TableName:                 aws.String(Config.LocationsTable),
+		KeyConditionExpression:    aws.String("riderid = :riderid"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":riderid": &types.AttributeValueMemberS{Value: riderid}},
+		ScanIndexForward:          aws.Bool(false),
+		Limit:                     aws.Int32(limit),
+		ExclusiveStartKey:         startKey,
+	}
+
+	locations := []fleet.Location{}
+	if output, err := client.NewDynamodb().Query(ctx, &params); err != nil {
+		return nil, err
+	} else if err := attributevalue.UnmarshalListOfMaps(output.Items, &locations); err != nil {
+		return nil, err
+	} else if body, err := json.Marshal(locations); err != nil {
+		return nil, err
+	} else if headers, err := client.NewPageHeaders(output.LastEvaluatedKey); err != nil {
+		return nil, err
+	} else {
+		response := events.APIGatewayProxyResponse{
+			StatusCode: 200,
+			Headers:    headers,
+			Body:       string(body),
+		}
+
+		return &response, nil
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/config"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/internal/tracing"
+	"github.com/kscott5/fds/users/erasure"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	_ "github.com/aws/aws-lambda-go/lambdacontext" // IMPORTANT: package level init() in use.
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+var (
+	settings config.Eraser
+	tables   erasure.Tables
+)
+
+// Asynchronously invoked by POST /users/{id}/erasure
+func eraseUser(ctx context.Context, request erasure.Request) (err error) {
+	ctx, span := tracing.StartInvocation(ctx, "erase user", attribute.String("faas.trigger", "other"))
+	defer func() { tracing.EndInvocation(ctx, span, err) }()
+
+	logger := logging.FromContext(ctx).With(zap.String("receiptId", request.ReceiptId))
+	logger.Info("lambda function: user erasure")
+
+	ddb := client.NewDynamodb()
+	receipt, err := erasure.GetReceipt(ctx, ddb, settings.AuditTable, request.ReceiptId)
+	if err != nil {
+		return err
+	} else if receipt == nil || !receipt.IsReceiptOf(request.UserId) {
+		return fmt.Errorf("erasure %s not found", request.ReceiptId)
+	} else if receipt.Status != erasure.Pending {
+		// asynchronous invocations are retried
+		logger.Info("erasure already processed", zap.String("status", string(receipt.Status)))
+		return nil
+	}
+
+	job := erasure.NewJob(ddb, client.NewS3(), tables, settings.ExportsBucket)
+	if err := job.Run(ctx, request.UserId, receipt); err != nil {
+		receipt.Status, receipt.Error = erasure.Failed, err.Error()
+	} else {
+		receipt.Status = erasure.Completed
+	}
+
+	receipt.CompletedOn = time.Now().UnixMilli()
+	logger.Info("erasure processed", zap.String("status", string(receipt.Status)))
+
+	return erasure.PutReceipt(ctx, ddb, settings.AuditTable, receipt)
+}
+
+func main() {
+	config.MustLoad(&settings)
+	tables = erasure.Tables{
+		Users:    settings.UsersTable,
+		Orders:   settings.OrdersTable,
+		Address:  settings.AddressTable,
+		Favorite: settings.FavoriteTable,
+		Exports:  settings.ExportsTable,
+	}
+
+	lambda.Start(eraseUser)
+}
@@ -0,0 +1,391 @@
+package erasure
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/google/uuid"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/users/export"
+	"github.com/kscott5/fds/users/profiles"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// retained orders are moved to an anonymous partition
+	AnonymousUserPrefix = "erased#"
+)
+
+// order data attributes holding personal data or the ids of people
+var personalOrderAttributes = []string{"deliveryaddress", "notes", "cancelreason", "riderid"}
+
+// order event attributes holding personal data or the ids of people. The event actor
+// is rewritten with anonymizeActor.
+var personalEventAttributes = []string{"reason", "riderid"}
+
+// anonymous actors of order events
+const (
+	anonymousRiderActor = "rider"
+	anonymousOtherActor = "anonymous"
+)
+
+// Tables used with the erasure job
+type Tables struct {
+	Users    string
+	Orders   string
+	Address  string
+	Favorite string
+	Exports  string
+}
+
+type Status string
+
+const (
+	Pending   Status = "pending"
+	Completed Status = "completed"
+	Failed    Status = "failed"
+)
+
+// Receipt is the audit record and status of an erasure. The subject is a sha256 digest of the
+// user id so auditors can verify a request without the receipt holding personal data.
+type Receipt struct {
+	ReceiptId        string `json:"receiptid" dynamodbav:"receiptid"`
+	Subject          string `json:"subject" dynamodbav:"subject"`
+	RequestedBy      string `json:"requestedby" dynamodbav:"requestedby"`
+	Status           Status `json:"status" dynamodbav:"status"`
+	StartedOn        int64  `json:"startedon" dynamodbav:"startedon"`
+	CompletedOn      int64  `json:"completedon" dynamodbav:"completedon"`
+	OrdersAnonymized int    `json:"ordersanonymized" dynamodbav:"ordersanonymized"`
+	AddressesDeleted int    `json:"addressesdeleted" dynamodbav:"addressesdeleted"`
+	FavoritesDeleted int    `json:"favoritesdeleted" dynamodbav:"favoritesdeleted"`
+	ExportsDeleted   int    `json:"exportsdeleted" dynamodbav:"exportsdeleted"`
+	ObjectsDeleted   int    `json:"objectsdeleted" dynamodbav:"objectsdeleted"`
+	ProfileDeleted   bool   `json:"profiledeleted" dynamodbav:"profiledeleted"`
+	Error            string `json:"error,omitempty" dynamodbav:"error,omitempty"`
+}
+
+// Request is the asynchronous invocation payload of the eraser lambda. The user id is not
+// stored with the receipt.
+type Request struct {
+	ReceiptId string `json:"receiptid"`
+	UserId    string `json:"userid"`
+}
+
+// NewReceipt returns the pending receipt of an erasure requested by requestedBy
+func NewReceipt(userid, requestedBy string, now time.Time) Receipt {
+	return Receipt{
+		ReceiptId:   uuid.New().String(),
+		Subject:     SubjectFrom(userid),
+		RequestedBy: requestedBy,
+		Status:      Pending,
+		StartedOn:   now.UnixMilli(),
+	}
+}
+
+// IsReceiptOf reports whether the receipt is of the erasure of the user
+func (receipt Receipt) IsReceiptOf(userid string) bool {
+	return userid != "" && receipt.Subject == SubjectFrom(userid)
+}
+
+func GetReceipt(ctx context.Context, ddb *dynamodb.Client, tableName, receiptid string) (*Receipt, error) {
+	attr, _ := attributevalue.Marshal(receiptid)
+	output, err := ddb.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key:       map[string]types.AttributeValue{"receiptid": attr},
+	})
+	if err != nil {
+		return nil, err
+	} else if output.Item == nil {
+		return nil, nil
+	}
+
+	receipt := Receipt{}
+	if err := attributevalue.UnmarshalMap(output.Item, &receipt); err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+func PutReceipt(ctx context.Context, ddb *dynamodb.Client, tableName string, receipt *Receipt) error {
+	item, err := attributevalue.MarshalMap(receipt)
+	if err != nil {
+		return err
+	}
+
+	_, err = ddb.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(tableName), Item: item})
+	return err
+}
+
+func SubjectFrom(userid string) string {
+	digest := sha256.Sum256([]byte(userid))
+	return hex.EncodeToString(digest[:])
+}
+
+// Job erases a user across the FDS tables and the exports bucket. Every step is idempotent so
+// a failed job can run again.
+type Job struct {
+	ddb           *dynamodb.Client
+	s3            *s3.Client
+	tables        Tables
+	exportsBucket string
+}
+
+func NewJob(ddb *dynamodb.Client, s3 *s3.Client, tables Tables, exportsBucket string) *Job {
+	return &Job{ddb: ddb, s3: s3, tables: tables, exportsBucket: exportsBucket}
+}
+
+// Run erases the user and counts the erased data with the receipt. The caller records the
+// receipt status.
+func (job *Job) Run(ctx context.Context, userid string, receipt *Receipt) error {
+	logger := logging.FromContext(ctx).With(zap.String("receiptId", receipt.ReceiptId))
+	logger.Info("erasure job started")
+
+	var err error
+	if receipt.OrdersAnonymized, err = job.anonymizeOrders(ctx, userid); err != nil {
+		return fmt.Errorf("erasure orders: %w", err)
+	}
+	if receipt.AddressesDeleted, err = job.deleteByUser(ctx, job.tables.Address, userid); err != nil {
+		return fmt.Errorf("erasure address: %w", err)
+	}
+	if receipt.FavoritesDeleted, err = job.deleteByUser(ctx, job.tables.Favorite, userid); err != nil {
+		return fmt.Errorf("erasure favorite: %w", err)
+	}
+	if receipt.ExportsDeleted, err = job.deleteExports(ctx, userid); err != nil {
+		return fmt.Errorf("erasure exports: %w", err)
+	}
+	if receipt.ObjectsDeleted, err = job.deleteExportObjects(ctx, userid); err != nil {
+		return fmt.Errorf("erasure exports bucket: %w", err)
+	}
+	if receipt.ProfileDeleted, err = job.deleteProfile(ctx, userid); err != nil {
+		return fmt.Errorf("erasure users: %w", err)
+	}
+
+	logger.Info("erasure job completed")
+	return nil
+}
+
+// anonymizeOrders moves every order of the user to an anonymous partition without personal data.
+// Orders are retained for accounting.
+func (job *Job) anonymizeOrders(ctx context.Context, userid string) (int, error) {
+	useridAttr, _ := attributevalue.Marshal(userid)
+	anonymous, _ := attributevalue.Marshal(AnonymousUserPrefix + uuid.New().String())
+
+	count := 0
+	paginator := dynamodb.NewQueryPaginator(job.ddb, &dynamodb.QueryInput{
+		TableName:                 aws.String(job.tables.Orders),
+		KeyConditionExpression:    aws.String("userid = :userid"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":userid": useridAttr},
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return count, err
+		}
+
+		for _, item := range page.Items {
+			retained := anonymizeOrder(item, userid, anonymous)
+
+			// the anonymous copy and removal of the original commit together
+			params := dynamodb.TransactWriteItemsInput{
+				TransactItems: []types.TransactWriteItem{
+					{Put: &types.Put{
+						TableName: aws.String(job.tables.Orders),
+						Item:      retained,
+					}},
+					{Delete: &types.Delete{
+						TableName: aws.String(job.tables.Orders),
+						Key:       map[string]types.AttributeValue{"userid": item["userid"], "orderid": item["orderid"]},
+					}},
+				},
+			}
+			if _, err := job.ddb.TransactWriteItems(ctx, &params); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// anonymizeOrder returns a copy of the order item moved to the anonymous user without
+// personal data. Events keep their type, status and time; the customer actor becomes the
+// anonymous user and rider actors lose the rider id.
+func anonymizeOrder(item map[string]types.AttributeValue, userid string, anonymous types.AttributeValue) map[string]types.AttributeValue {
+	retained := map[string]types.AttributeValue{}
+	for k, v := range item {
+		retained[k] = v
+	}
+	retained["userid"] = anonymous
+
+	if data, ok := item["data"].(*types.AttributeValueMemberM); ok {
+		anonymized := withoutAttributes(data.Value, personalOrderAttributes)
+		anonymized["userid"] = anonymous
+		retained["data"] = &types.AttributeValueMemberM{Value: anonymized}
+	}
+
+	if history, ok := item["events"].(*types.AttributeValueMemberL); ok {
+		events := make([]types.AttributeValue, 0, len(history.Value))
+		for _, v := range history.Value {
+			event, ok := v.(*types.AttributeValueMemberM)
+			if !ok {
+				continue
+			}
+
+			anonymized := withoutAttributes(event.Value, personalEventAttributes)
+			if actor, ok := event.Value["actor"].(*types.AttributeValueMemberS); ok {
+				anonymized["actor"] = anonymizeActor(actor.Value, userid, anonymous)
+			}
+			events = append(events, &types.AttributeValueMemberM{Value: anonymized})
+		}
+		retained["events"] = &types.AttributeValueMemberL{Value: events}
+	}
+
+	return retained
+}
+
+// anonymizeActor keeps the operator actor and replaces the id of a person
+func anonymizeActor(actor, userid string, anonymous types.AttributeValue) types.AttributeValue {
+	if actor == "operator" {
+		return &types.AttributeValueMemberS{Value: actor}
+	} else if actor == userid {
+		return anonymous
+	} else if strings.HasPrefix(actor, "rider:") {
+		return &types.AttributeValueMemberS{Value: anonymousRiderActor}
+	}
+	return &types.AttributeValueMemberS{Value: anonymousOtherActor}
+}
+
+func withoutAttributes(value map[string]types.AttributeValue, attributes []string) map[string]types.AttributeValue {
+	copied := map[string]types.AttributeValue{}
+	for k, v := range value {
+		copied[k] = v
+	}
+	for _, attribute := range attributes {
+		delete(copied, attribute)
+	}
+	return copied
+}
+
+// deleteByUser deletes the item keyed by userid, returning the number of deleted items
+func (job *Job) deleteByUser(ctx context.Context, tableName, userid string) (int, error) {
+	attr, _ := attributevalue.Marshal(userid)
+	params := dynamodb.DeleteItemInput{
+		TableName:    aws.String(tableName),
+		Key:          map[string]types.AttributeValue{"userid": attr},
+		ReturnValues: types.ReturnValueAllOld,
+	}
+
+	if output, err := job.ddb.DeleteItem(ctx, &params); err != nil {
+		return 0, err
+	} else if len(output.Attributes) == 0 {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+// deleteExports deletes the export job records of the user. Records are keyed by export id
+// and expire after a week, so the small table is scanned.
+func (job *Job) deleteExports(ctx context.Context, userid string) (int, error) {
+	attr, _ := attributevalue.Marshal(userid)
+	paginator := dynamodb.NewScanPaginator(job.ddb, &dynamodb.ScanInput{
+		TableName:                 aws.String(job.tables.Exports),
+		FilterExpression:          aws.String("userid = :userid"),
+		ProjectionExpression:      aws.String("exportid"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":userid": attr},
+	})
+
+	count := 0
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return count, err
+		}
+
+		for _, item := range page.Items {
+			if _, err := job.ddb.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+				TableName: aws.String(job.tables.Exports),
+				Key:       map[string]types.AttributeValue{"exportid": item["exportid"]},
+			}); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// deleteExportObjects deletes the export files of the user
+func (job *Job) deleteExportObjects(ctx context.Context, userid string) (int, error) {
+	paginator := s3.NewListObjectsV2Paginator(job.s3, &s3.ListObjectsV2Input{
+		Bucket: aws.String(job.exportsBucket),
+		Prefix: aws.String(export.PrefixFor(userid)),
+	})
+
+	count := 0
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return count, err
+		} else if len(page.Contents) == 0 {
+			continue
+		}
+
+		objects := []s3types.ObjectIdentifier{}
+		for _, object := range page.Contents {
+			objects = append(objects, s3types.ObjectIdentifier{Key: object.Key})
+		}
+
+		// pages have at most 1000 keys, the DeleteObjects limit
+		output, err := job.s3.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(job.exportsBucket),
+			Delete: &s3types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return count, err
+		} else if len(output.Errors) > 0 {
+			return count, fmt.Errorf("export object %s not deleted: %s", aws.ToString(output.Errors[0].Key), aws.ToString(output.Errors[0].Message))
+		}
+		count += len(objects)
+	}
+	return count, nil
+}
+
+// deleteProfile deletes the profile with its username claim. A failed job leaves both, so
+// running the job again releases the claim.
+func (job *Job) deleteProfile(ctx context.Context, userid string) (bool, error) {
+	attr, _ := attributevalue.Marshal(userid)
+	params := dynamodb.GetItemInput{
+		TableName:      aws.String(job.tables.Users),
+		Key:            map[string]types.AttributeValue{"userid": attr},
+		ConsistentRead: aws.Bool(true),
+	}
+
+	user := profiles.User{}
+	if output, err := job.ddb.GetItem(ctx, &params); err != nil {
+		return false, err
+	} else if output.Item == nil {
+		return false, nil
+	} else if err := attributevalue.UnmarshalMap(output.Item, &user); err != nil {
+		return false, err
+	} else if err := profiles.DeleteUser(ctx, job.ddb, job.tables.Users, user); profiles.CancelledAt(err) == profiles.UserModified {
+		return false, errors.New("user modified during erasure, run the erasure again")
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
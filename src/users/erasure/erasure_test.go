@@ -0,0 +1,129 @@
+package erasure
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kscott5/fds/users/export"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func s(value string) types.AttributeValue {
+	return &types.AttributeValueMemberS{Value: value}
+}
+
+func TestAnonymizeOrder(t *testing.T) {
+	anonymous := s("erased#1")
+	item := map[string]types.AttributeValue{
+		"userid":  s("user-1"),
+		"orderid": s("order-1"),
+		"data": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"userid":          s("user-1"),
+			"restaurantid":    s("restaurant-1"),
+			"notes":           s("ring twice"),
+			"deliveryaddress": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{"street": s("1 main st")}},
+			"riderid":         s("rider-1"),
+			"cancelreason":    s("moved house"),
+		}},
+		"events": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+			&types.AttributeValueMemberM{Value: map[string]types.AttributeValue{"type": s("placed"), "actor": s("user-1")}},
+			&types.AttributeValueMemberM{Value: map[string]types.AttributeValue{"type": s("assigned"), "actor": s("operator"), "riderid": s("rider-1")}},
+			&types.AttributeValueMemberM{Value: map[string]types.AttributeValue{"type": s("transitioned"), "actor": s("rider:rider-1")}},
+			&types.AttributeValueMemberM{Value: map[string]types.AttributeValue{"type": s("cancelled"), "actor": s("user-2"), "reason": s("moved house")}},
+		}},
+	}
+
+	retained := anonymizeOrder(item, "user-1", anonymous)
+	if retained["userid"] != anonymous {
+		t.Errorf("userid = %v, want the anonymous user", retained["userid"])
+	}
+
+	data := retained["data"].(*types.AttributeValueMemberM).Value
+	for _, attribute := range personalOrderAttributes {
+		if _, ok := data[attribute]; ok {
+			t.Errorf("data.%s retained", attribute)
+		}
+	}
+	if data["restaurantid"].(*types.AttributeValueMemberS).Value != "restaurant-1" {
+		t.Errorf("data.restaurantid not retained")
+	}
+
+	events := retained["events"].(*types.AttributeValueMemberL).Value
+	tests := []struct {
+		actor types.AttributeValue
+	}{
+		{anonymous},
+		{s("operator")},
+		{s(anonymousRiderActor)},
+		{s(anonymousOtherActor)},
+	}
+	for i, test := range tests {
+		event := events[i].(*types.AttributeValueMemberM).Value
+		if actor := event["actor"]; actor != test.actor && actor.(*types.AttributeValueMemberS).Value != test.actor.(*types.AttributeValueMemberS).Value {
+			t.Errorf("events[%d].actor = %v, want %v", i, actor, test.actor)
+		}
+		for _, attribute := range personalEventAttributes {
+			if _, ok := event[attribute]; ok {
+				t.Errorf("events[%d].%s retained", i, attribute)
+			}
+		}
+	}
+
+	// the original item is unchanged
+	if item["data"].(*types.AttributeValueMemberM).Value["notes"] == nil {
+		t.Errorf("original item changed")
+	}
+}
+
+func TestExportPrefix(t *testing.T) {
+	tests := []struct {
+		name   string
+		job    export.Job
+		userid string
+		erased bool
+	}{
+		{"json export", export.Job{ExportId: "e-1", UserId: "user-1", Format: export.JSON}, "user-1", true},
+		{"zip export", export.Job{ExportId: "e-2", UserId: "user-1", Format: export.Zip}, "user-1", true},
+		{"user id with the same prefix", export.Job{ExportId: "e-3", UserId: "user-10", Format: export.Zip}, "user-1", false},
+		{"another user", export.Job{ExportId: "e-4", UserId: "user-2", Format: export.JSON}, "user-1", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			key := test.job.ObjectKeyFor()
+			if erased := strings.HasPrefix(key, export.PrefixFor(test.userid)); erased != test.erased {
+				t.Errorf("object %s erased with %s = %t, want %t", key, test.userid, erased, test.erased)
+			}
+		})
+	}
+}
+
+func TestReceiptOf(t *testing.T) {
+	receipt := NewReceipt("user-1", "admin-1", time.Now())
+	body, _ := json.Marshal(receipt)
+
+	tests := []struct {
+		name   string
+		userid string
+		of     bool
+	}{
+		{"erased user", "user-1", true},
+		{"another user", "user-2", false},
+		{"no user", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if receipt.IsReceiptOf(test.userid) != test.of {
+				t.Errorf("IsReceiptOf(%q) = %t, want %t", test.userid, !test.of, test.of)
+			} else if receipt.Status != Pending {
+				t.Errorf("status = %s, want %s", receipt.Status, Pending)
+			} else if strings.Contains(string(body), "user-1") {
+				t.Errorf("receipt %s holds the user id", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	DefaultExportsTable = "FDSAppsExports"
+
+	// exports with more orders are generated asynchronously
+	MaxSyncOrders = 500
+
+	// export records and objects expire after seven days
+	ExportTTL = 7 * 24 * time.Hour
+)
+
+type Format string
+
+const (
+	JSON Format = "json"
+	Zip  Format = "zip"
+)
+
+func FormatFrom(value string) (Format, error) {
+	switch Format(value) {
+	case "", JSON:
+		return JSON, nil
+	case Zip:
+		return Zip, nil
+	default:
+		return "", fmt.Errorf("export format %s not available. formats: json, zip", value)
+	}
+}
+
+func (f Format) ContentType() string {
+	if f == Zip {
+		return "application/zip"
+	}
+	return "application/json"
+}
+
+type Status string
+
+const (
+	Pending   Status = "pending"
+	Completed Status = "completed"
+	Failed    Status = "failed"
+)
+
+// Tables read with the export
+type Tables struct {
+	Users    string
+	Orders   string
+	Address  string
+	Favorite string
+}
+
+// UserData is everything FDS stores about a user
+type UserData struct {
+	UserId     string                   `json:"userid"`
+	ExportedOn int64                    `json:"exportedon"`
+	Profile    map[string]interface{}   `json:"profile"`
+	Addresses  []map[string]interface{} `json:"addresses"`
+	Favorites  []map[string]interface{} `json:"favorites"`
+	Orders     []map[string]interface{} `json:"orders"`
+}
+
+// Job is the export record polled with the status endpoint
+type Job struct {
+	ExportId    string `json:"exportid" dynamodbav:"exportid"`
+	UserId      string `json:"userid" dynamodbav:"userid"`
+	Format      Format `json:"format" dynamodbav:"format"`
+	Status      Status `json:"status" dynamodbav:"status"`
+	CreatedOn   int64  `json:"createdon" dynamodbav:"createdon"`
+	CompletedOn int64  `json:"completedon,omitempty" dynamodbav:"completedon,omitempty"`
+	ObjectKey   string `json:"-" dynamodbav:"objectkey,omitempty"`
+	Error       string `json:"error,omitempty" dynamodbav:"error,omitempty"`
+	DownloadUrl string `json:"downloadurl,omitempty" dynamodbav:"-"`
+
+	// dynamodb time to live in unix seconds
+	ExpiresAt int64 `json:"expiresat" dynamodbav:"expiresat"`
+}
+
+// Request is the asynchronous invocation payload of the exporter lambda
+type Request struct {
+	ExportId string `json:"exportid"`
+}
+
+func (job Job) ObjectKeyFor() string {
+	extension := "json"
+	if job.Format == Zip {
+		extension = "zip"
+	}
+	return fmt.Sprintf("%s%s.%s", PrefixFor(job.UserId), job.ExportId, extension)
+}
+
+// PrefixFor returns the object key prefix of every export of the user
+func PrefixFor(userid string) string {
+	return fmt.Sprintf("exports/%s/", userid)
+}
+
+func useridKey(userid string) map[string]types.AttributeValue {
+	attr, _ := attributevalue.Marshal(userid)
+	return map[string]types.AttributeValue{"userid": attr}
+}
+
+// getByUser returns the item keyed by userid as a list with zero or one element
+func getByUser(ctx context.Context, ddb *dynamodb.Client, tableName, userid string) ([]map[string]interface{}, error) {
+	out := []map[string]interface{}{}
+	output, err := ddb.GetItem(ctx, &dynamodb.GetItemInput{TableName: aws.String(tableName), Key: useridKey(userid)})
+	if err != nil {
+		return nil, err
+	} else if output.Item == nil {
+		return out, nil
+	}
+
+	item := map[string]interface{}{}
+	if err := attributevalue.UnmarshalMap(output.Item, &item); err != nil {
+		return nil, err
+	}
+	return append(out, item), nil
+}
+
+func queryOrders(userid string, tableName string) *dynamodb.QueryInput {
+	attr, _ := attributevalue.Marshal(userid)
+	return &dynamodb.QueryInput{
+		TableName:                 aws.String(tableName),
+		KeyConditionExpression:    aws.String("userid = :userid"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":userid": attr},
+	}
+}
+
+// CountOrders returns the number of orders placed by the user
+func CountOrders(ctx context.Context, ddb *dynamodb.Client, tableName, userid string) (int, error) {
+	input := queryOrders(userid, tableName)
+	input.Select = types.SelectCount
+
+	count := 0
+	paginator := dynamodb.NewQueryPaginator(ddb, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return 0, err
+		}
+		count += int(page.Count)
+	}
+	return count, nil
+}
+
+// Collect reads the user profile, addresses, favorites and order history
+func Collect(ctx context.Context, ddb *dynamodb.Client, tables Tables, userid string) (*UserData, error) {
+	data := UserData{UserId: userid, ExportedOn: time.Now().UnixMilli(), Orders: []map[string]interface{}{}}
+
+	if profile, err := getByUser(ctx, ddb, tables.Users, userid); err != nil {
+		return nil, fmt.Errorf("export users: %w", err)
+	} else if len(profile) == 0 {
+		return nil, fmt.Errorf("user %s not found", userid)
+	} else {
+		data.Profile = profile[0]
+	}
+
+	var err error
+	if data.Addresses, err = getByUser(ctx, ddb, tables.Address, userid); err != nil {
+		return nil, fmt.Errorf("export address: %w", err)
+	}
+	if data.Favorites, err = getByUser(ctx, ddb, tables.Favorite, userid); err != nil {
+		return nil, fmt.Errorf("export favorite: %w", err)
+	}
+
+	paginator := dynamodb.NewQueryPaginator(ddb, queryOrders(userid, tables.Orders))
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("export orders: %w", err)
+		}
+
+		orders := []map[string]interface{}{}
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &orders); err != nil {
+			return nil, err
+		}
+		data.Orders = append(data.Orders, orders...)
+	}
+
+	return &data, nil
+}
+
+// Encode writes the user data as a single json document or a zip of ndjson files
+func Encode(data *UserData, format Format) ([]byte, error) {
+	if format != Zip {
+		return json.Marshal(data)
+	}
+
+	buffer := bytes.Buffer{}
+	archive := zip.NewWriter(&buffer)
+	files := []struct {
+		name    string
+		records []map[string]interface{}
+	}{
+		{"profile.ndjson", []map[string]interface{}{data.Profile}},
+		{"addresses.ndjson", data.Addresses},
+		{"favorites.ndjson", data.Favorites},
+		{"orders.ndjson", data.Orders},
+	}
+
+	for _, file := range files {
+		writer, err := archive.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+
+		encoder := json.NewEncoder(writer)
+		for _, record := range file.records {
+			if err := encoder.Encode(record); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func GetJob(ctx context.Context, ddb *dynamodb.Client, tableName, exportid string) (*Job, error) {
+	attr, _ := attributevalue.Marshal(exportid)
+	output, err := ddb.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key:       map[string]types.AttributeValue{"exportid": attr},
+	})
+	if err != nil {
+		return nil, err
+	} else if output.Item == nil {
+		return nil, nil
+	}
+
+	job := Job{}
+	if err := attributevalue.UnmarshalMap(output.Item, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func PutJob(ctx context.Context, ddb *dynamodb.Client, tableName string, job *Job) error {
+	item, err := attributevalue.MarshalMap(job)
+	if err != nil {
+		return err
+	}
+
+	_, err = ddb.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(tableName), Item: item})
+	return err
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/config"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/internal/tracing"
+	"github.com/kscott5/fds/users/export"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	_ "github.com/aws/aws-lambda-go/lambdacontext" // IMPORTANT: package level init() in use.
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+var (
+	settings config.Exporter
+	tables   export.Tables
+)
+
+// Asynchronously invoked by the users api for exports larger than export.MaxSyncOrders
+func exportUser(ctx context.Context, request export.Request) (err error) {
+	ctx, span := tracing.StartInvocation(ctx, "export user", attribute.String("faas.trigger", "other"))
+	defer func() { tracing.EndInvocation(ctx, span, err) }()
+
+	logger := logging.FromContext(ctx).With(zap.String("exportid", request.ExportId))
+	logger.Info("lambda function: user data export")
+
+	ddb := client.NewDynamodb()
+	job, err := export.GetJob(ctx, ddb, settings.ExportsTable, request.ExportId)
+	if err != nil {
+		return err
+	} else if job == nil {
+		return fmt.Errorf("export %s not found", request.ExportId)
+	} else if job.Status != export.Pending {
+		// asynchronous invocations are retried
+		logger.Info("export already processed", zap.String("status", string(job.Status)))
+		return nil
+	}
+
+	job.ObjectKey = job.ObjectKeyFor()
+	if data, err := export.Collect(ctx, ddb, tables, job.UserId); err != nil {
+		job.Status, job.Error = export.Failed, err.Error()
+	} else if body, err := export.Encode(data, job.Format); err != nil {
+		job.Status, job.Error = export.Failed, err.Error()
+	} else if _, err := client.NewS3().PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(settings.ExportsBucket),
+		Key:         aws.String(job.ObjectKey),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String(job.Format.ContentType()),
+	}); err != nil {
+		job.Status, job.Error = export.Failed, err.Error()
+	} else {
+		job.Status = export.Completed
+	}
+
+	job.CompletedOn = time.Now().UnixMilli()
+	logger.Info("export processed", zap.String("status", string(job.Status)))
+
+	return export.PutJob(ctx, ddb, settings.ExportsTable, job)
+}
+
+func main() {
+	config.MustLoad(&settings)
+	tables = export.Tables{
+		Users:    settings.UsersTable,
+		Orders:   settings.OrdersTable,
+		Address:  settings.AddressTable,
+		Favorite: settings.FavoriteTable,
+	}
+
+	lambda.Start(exportUser)
+}
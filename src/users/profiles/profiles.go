@@ -0,0 +1,158 @@
+package profiles
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/kscott5/fds/internal/notify"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	DefaultUsersTable = "FDSAppsUsers"
+
+	// username claim items share the users table hash key with a prefix
+	UserNameClaimPrefix = "username#"
+)
+
+// User profile. The user id is the Cognito sub of the owner.
+type User struct {
+	UserId   string `json:"userid" dynamodbav:"userid"`
+	UserName string `json:"username" dynamodbav:"UserName"`
+	FullName string `json:"fullname" dynamodbav:"FullName"`
+
+	// order notifications, managed with PUT /users/{id}/notifications
+	Notifications *notify.Preferences `json:"notifications,omitempty" dynamodbav:"notifications,omitempty"`
+
+	// soft deleted users are hidden until restored or erased
+	DeletedAt int64 `json:"deletedat,omitempty" dynamodbav:"deletedAt,omitempty"`
+}
+
+// UserNameClaim reserves a username for a single user id
+type UserNameClaim struct {
+	ClaimId   string `dynamodbav:"userid"`
+	ClaimedBy string `dynamodbav:"claimedby"`
+}
+
+func ClaimIdFrom(username string) string {
+	return UserNameClaimPrefix + strings.ToLower(strings.TrimSpace(username))
+}
+
+// CancelledAt returns the index of the transact item whose condition check failed, or -1
+func CancelledAt(err error) int {
+	var cancelled *types.TransactionCanceledException
+	if errors.As(err, &cancelled) {
+		for i, reason := range cancelled.CancellationReasons {
+			if aws.ToString(reason.Code) == "ConditionalCheckFailed" {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Transact item positions used with CancelledAt for CreateUser
+const (
+	UserExists = iota
+	UserNameTaken
+)
+
+// CreateUser puts the user and username claim together or not at all. Users without a
+// username do not claim one.
+func CreateUser(ctx context.Context, ddb *dynamodb.Client, tableName string, user User) error {
+	userItem, err := attributevalue.MarshalMap(user)
+	if err != nil {
+		return err
+	}
+
+	params := dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			UserExists: {Put: &types.Put{
+				TableName:           aws.String(tableName),
+				Item:                userItem,
+				ConditionExpression: aws.String("attribute_not_exists(userid)"),
+			}},
+		},
+	}
+
+	if user.UserName != "" {
+		claimItem, err := attributevalue.MarshalMap(UserNameClaim{ClaimId: ClaimIdFrom(user.UserName), ClaimedBy: user.UserId})
+		if err != nil {
+			return err
+		}
+		params.TransactItems = append(params.TransactItems, types.TransactWriteItem{Put: &types.Put{
+			TableName:           aws.String(tableName),
+			Item:                claimItem,
+			ConditionExpression: aws.String("attribute_not_exists(userid)"),
+		}})
+	}
+
+	_, err = ddb.TransactWriteItems(ctx, &params)
+	return err
+}
+
+// ReleaseUserNameClaim deletes the username claim held by the user
+func ReleaseUserNameClaim(ctx context.Context, ddb *dynamodb.Client, tableName string, user User) error {
+	if user.UserName == "" {
+		return nil
+	}
+
+	claimKey, _ := attributevalue.Marshal(ClaimIdFrom(user.UserName))
+	userid, _ := attributevalue.Marshal(user.UserId)
+	params := dynamodb.DeleteItemInput{
+		TableName:                 aws.String(tableName),
+		Key:                       map[string]types.AttributeValue{"userid": claimKey},
+		ConditionExpression:       aws.String("attribute_not_exists(userid) OR claimedby = :userid"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":userid": userid},
+	}
+
+	var conditionFailed *types.ConditionalCheckFailedException
+	if _, err := ddb.DeleteItem(ctx, &params); err != nil && !errors.As(err, &conditionFailed) {
+		return err
+	}
+	return nil
+}
+
+// Transact item positions used with CancelledAt for DeleteUser
+const (
+	UserModified = iota
+	UserNameClaimedByOther
+)
+
+// DeleteUser deletes the user and releases the username claim together or not at all. The
+// user must be unchanged since it was read. Claims held by another user are kept.
+func DeleteUser(ctx context.Context, ddb *dynamodb.Client, tableName string, user User) error {
+	userid, _ := attributevalue.Marshal(user.UserId)
+	username, _ := attributevalue.Marshal(user.UserName)
+
+	items := []types.TransactWriteItem{
+		UserModified: {Delete: &types.Delete{
+			TableName:                 aws.String(tableName),
+			Key:                       map[string]types.AttributeValue{"userid": userid},
+			ConditionExpression:       aws.String("attribute_exists(userid) AND (attribute_not_exists(#username) OR #username = :username)"),
+			ExpressionAttributeNames:  map[string]string{"#username": "UserName"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{":username": username},
+		}},
+	}
+
+	if user.UserName != "" {
+		claimKey, _ := attributevalue.Marshal(ClaimIdFrom(user.UserName))
+		items = append(items, types.TransactWriteItem{Delete: &types.Delete{
+			TableName:                 aws.String(tableName),
+			Key:                       map[string]types.AttributeValue{"userid": claimKey},
+			ConditionExpression:       aws.String("attribute_not_exists(userid) OR claimedby = :userid"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{":userid": userid},
+		}})
+	}
+
+	_, err := ddb.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	if CancelledAt(err) == UserNameClaimedByOther {
+		_, err = ddb.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items[:UserNameClaimedByOther]})
+	}
+	return err
+}
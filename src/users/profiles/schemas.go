@@ -0,0 +1,16 @@
+package profiles
+
+import (
+	"github.com/kscott5/fds/internal/notify"
+	"github.com/kscott5/fds/internal/schema"
+)
+
+// UserSchema validates POST /users and PUT /users/{id} request bodies
+var UserSchema = schema.Object(map[string]*schema.Field{
+	"username": schema.String().Required().MinLength(3).MaxLength(64).Pattern(`^[A-Za-z0-9_.@+-]+$`),
+	"fullname": schema.String().Required().MaxLength(128),
+
+	// read only attributes returned with GET /users/{id}
+	"userid":        schema.String().MaxLength(64),
+	"notifications": notify.PreferencesSchema,
+})
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/google/uuid"
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/users/export"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// presigned download urls of completed exports
+	DownloadUrlExpires = 15 * time.Minute
+)
+
+func exportTables() export.Tables {
+
+	return export.Tables{
+		Users:    Config.UsersTable,
+		Orders:   Config.OrdersTable,
+		Address:  Config.AddressTable,
+		Favorite: Config.FavoriteTable,
+	}
+}
+
+// exportUser returns the user data with small order histories. Larger histories, or ?async=true,
+// start an export job polled with GET /users/{id}/export/{exportid}.
+func ExportUser(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb export user")
+	logger.Debug("path parameters", zap.Any("parameters", request.PathParameters))
+
+	userid := request.PathParameters["id"]
+	if userid == "" {
+		return nil, fmt.Errorf("requires: %s", map[string]string{"userid": "string"})
+	}
+
+	format, err := export.FormatFrom(request.QueryStringParameters["format"])
+	if err != nil {
+		return client.NewErrorResponse(400, err.Error()), nil
+	}
+
+	tables := exportTables()
+	ddb := client.NewDynamodb()
+
+	count, err := export.CountOrders(ctx, ddb, tables.Orders, userid)
+	if err != nil {
+		return nil, err
+	}
+
+	if count > export.MaxSyncOrders || request.QueryStringParameters["async"] == "true" {
+		return startExport(ctx, userid, format)
+	}
+
+	data, err := export.Collect(ctx, ddb, tables, userid)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := export.Encode(data, format)
+	if err != nil {
+		return nil, err
+	}
+
+	response := events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers:    map[string]string{"content-type": format.ContentType(), "access-control-allow-orgin": "*"},
+		Body:       string(body),
+	}
+
+	if format == export.Zip {
+		response.Headers["content-disposition"] = fmt.Sprintf("attachment; filename=\"%s.zip\"", userid)
+		response.Body = base64.StdEncoding.EncodeToString(body)
+		response.IsBase64Encoded = true
+	}
+
+	return &response, nil
+}
+
+func startExport(ctx context.Context, userid string, format export.Format) (*events.APIGatewayProxyResponse, error) {
+
+	now := time.Now()
+	job := export.Job{
+		ExportId:  uuid.New().String(),
+		UserId:    userid,
+		Format:    format,
+		Status:    export.Pending,
+		CreatedOn: now.UnixMilli(),
+		ExpiresAt: now.Add(export.ExportTTL).Unix(),
+	}
+
+	if err := export.PutJob(ctx, client.NewDynamodb(), Config.ExportsTable, &job); err != nil {
+		return nil, err
+	}
+
+	payload, _ := json.Marshal(export.Request{ExportId: job.ExportId})
+	if _, err := client.NewLambda().Invoke(ctx, &lambda.InvokeInput{
+		FunctionName:   aws.String(Config.ExporterFunction),
+		InvocationType: lambdatypes.InvocationTypeEvent,
+		Payload:        payload,
+	}); err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(job)
+	if err != nil {
+		return nil, err
+	}
+
+	response := events.APIGatewayProxyResponse{
+		StatusCode: 202,
+		Headers: map[string]string{
+			"content-type":               "application/json",
+			"access-control-allow-orgin": "*",
+			"location":                   fmt.Sprintf("/users/%s/export/%s", userid, job.ExportId),
+		},
+		Body: string(body),
+	}
+
+	return &response, nil
+}
+
+func GetExportStatus(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb get export status")
+	logger.Debug("path parameters", zap.Any("parameters", request.PathParameters))
+
+	userid := request.PathParameters["id"]
+	exportid := request.PathParameters["exportid"]
+	if userid == "" || exportid == "" {
+		return nil, fmt.Errorf("requires: %s", map[string]string{"userid": "string", "exportid": "string"})
+	}
+
+	job, err := export.GetJob(ctx, client.NewDynamodb(), Config.ExportsTable, exportid)
+	if err != nil {
+		return nil, err
+	} else if job == nil || job.UserId != userid {
+		return client.NewErrorResponse(404, fmt.Sprintf("export %s not found", exportid)), nil
+	}
+
+	if job.Status == export.Completed {
+		presign := s3.NewPresignClient(client.NewS3(), s3.WithPresignExpires(DownloadUrlExpires))
+		if url, err := presign.PresignGetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(Config.ExportsBucket),
+			Key:    aws.String(job.ObjectKey),
+		}); err != nil {
+			return nil, err
+		} else {
+			job.DownloadUrl = url.URL
+		}
+	}
+
+	if body, err := json.Marshal(job); err != nil {
+		return nil, err
+	} else {
+		response := events.APIGatewayProxyResponse{
+			StatusCode: 200,
+			Headers:    client.HttpResponseHeaders,
+			Body:       string(body),
+		}
+
+		return &response, nil
+	}
+}
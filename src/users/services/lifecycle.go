@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/users/erasure"
+	"github.com/kscott5/fds/users/profiles"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"go.uber.org/zap"
+)
+
+// setDeletedAt marks (deletedAt > 0) or restores (deletedAt == 0) the user
+func setDeletedAt(ctx context.Context, userid string, deletedAt int64) (*events.APIGatewayProxyResponse, error) {
+
+	if userid == "" || strings.HasPrefix(userid, profiles.UserNameClaimPrefix) {
+		return nil, fmt.Errorf("requires: %s", map[string]string{"userid": "string"})
+	}
+
+	attr, _ := attributevalue.Marshal(userid)
+	params := dynamodb.UpdateItemInput{
+		TableName:                aws.String(Config.UsersTable),
+		Key:                      map[string]types.AttributeValue{"userid": attr},
+		ExpressionAttributeNames: map[string]string{"#deletedAt": "deletedAt"},
+	}
+
+	if deletedAt > 0 {
+		value, _ := attributevalue.Marshal(deletedAt)
+		params.UpdateExpression = aws.String("SET #deletedAt = :deletedAt")
+		params.ConditionExpression = aws.String("attribute_exists(userid) AND attribute_not_exists(#deletedAt)")
+		params.ExpressionAttributeValues = map[string]types.AttributeValue{":deletedAt": value}
+	} else {
+		params.UpdateExpression = aws.String("REMOVE #deletedAt")
+		params.ConditionExpression = aws.String("attribute_exists(userid) AND attribute_exists(#deletedAt)")
+	}
+
+	ddb := client.NewDynamodb()
+	var conditionFailed *types.ConditionalCheckFailedException
+	if _, err := ddb.UpdateItem(ctx, &params); errors.As(err, &conditionFailed) {
+		return client.NewErrorResponse(404, fmt.Sprintf("user %s not found", userid)), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	status := "restored"
+	if deletedAt > 0 {
+		status = "deleted"
+	}
+
+	response := events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers:    client.HttpResponseHeaders,
+		Body:       fmt.Sprintf("{\"userid\": \"%s\", \"status\": \"%s\"}", userid, status),
+	}
+
+	return &response, nil
+}
+
+// softDeleteUser hides the user with a deletedAt marker. The username stays claimed until erasure.
+func SoftDeleteUser(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb soft delete user")
+	logger.Debug("path parameters", zap.Any("parameters", request.PathParameters))
+
+	return setDeletedAt(ctx, request.PathParameters["id"], time.Now().UnixMilli())
+}
+
+func RestoreUser(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb restore user")
+	logger.Debug("path parameters", zap.Any("parameters", request.PathParameters))
+
+	if !client.IsMemberOf(request.RequestContext.Authorizer, Config.AdminGroupName) {
+		return client.NewErrorResponse(403, "restore requires administrator"), nil
+	}
+
+	return setDeletedAt(ctx, request.PathParameters["id"], 0)
+}
+
+// eraseUser starts the erasure job and returns the pending receipt, polled with
+// GET /users/{id}/erasure/{receiptid}
+func EraseUser(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb erase user")
+	logger.Debug("path parameters", zap.Any("parameters", request.PathParameters))
+
+	if !client.IsMemberOf(request.RequestContext.Authorizer, Config.AdminGroupName) {
+		return client.NewErrorResponse(403, "erasure requires administrator"), nil
+	}
+
+	userid := request.PathParameters["id"]
+	if userid == "" || strings.HasPrefix(userid, profiles.UserNameClaimPrefix) {
+		return nil, fmt.Errorf("requires: %s", map[string]string{"userid": "string"})
+	}
+
+	requestedBy, _ := client.GetPrincipalIdFrom(request.RequestContext.Authorizer)
+	receipt := erasure.NewReceipt(userid, requestedBy, time.Now())
+	if err := erasure.PutReceipt(ctx, client.NewDynamodb(), Config.AuditTable, &receipt); err != nil {
+		return nil, err
+	}
+
+	payload, _ := json.Marshal(erasure.Request{ReceiptId: receipt.ReceiptId, UserId: userid})
+	if _, err := client.NewLambda().Invoke(ctx, &lambda.InvokeInput{
+		FunctionName:   aws.String(Config.EraserFunction),
+		InvocationType: lambdatypes.InvocationTypeEvent,
+		Payload:        payload,
+	}); err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(receipt)
+	if err != nil {
+		return nil, err
+	}
+
+	response := events.APIGatewayProxyResponse{
+		StatusCode: 202,
+		Headers: map[string]string{
+			"content-type":               "application/json",
+			"access-control-allow-orgin": "*",
+			"location":                   fmt.Sprintf("/users/%s/erasure/%s", userid, receipt.ReceiptId),
+		},
+		Body: string(body),
+	}
+
+	return &response, nil
+}
+
+func GetErasureStatus(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb get erasure status")
+	logger.Debug("path parameters", zap.Any("parameters", request.PathParameters))
+
+	if !client.IsMemberOf(request.RequestContext.Authorizer, Config.AdminGroupName) {
+		return client.NewErrorResponse(403, "erasure requires administrator"), nil
+	}
+
+	userid := request.PathParameters["id"]
+	receiptid := request.PathParameters["receiptid"]
+	if userid == "" || receiptid == "" {
+		return nil, fmt.Errorf("requires: %s", map[string]string{"userid": "string", "receiptid": "string"})
+	}
+
+	receipt, err := erasure.GetReceipt(ctx, client.NewDynamodb(), Config.AuditTable, receiptid)
+	if err != nil {
+		return nil, err
+	} else if receipt == nil || !receipt.IsReceiptOf(userid) {
+		return client.NewErrorResponse(404, fmt.Sprintf("erasure %s not found", receiptid)), nil
+	}
+
+	if body, err := json.Marshal(receipt); err != nil {
+		return nil, err
+	} else {
+		response := events.APIGatewayProxyResponse{
+			StatusCode: 200,
+			Headers:    client.HttpResponseHeaders,
+			Body:       string(body),
+		}
+
+		return &response, nil
+	}
+}
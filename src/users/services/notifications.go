@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/internal/notify"
+	"github.com/kscott5/fds/users/profiles"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"go.uber.org/zap"
+)
+
+// notificationsUser returns the user id of the path when the caller is the user or an
+// administrator, or the error response
+func notificationsUser(request *events.APIGatewayProxyRequest) (string, *events.APIGatewayProxyResponse, error) {
+	userid := request.PathParameters["id"]
+	if userid == "" || strings.HasPrefix(userid, profiles.UserNameClaimPrefix) {
+		return "", nil, fmt.Errorf("requires: %s", map[string]string{"userid": "string"})
+	}
+
+	caller, _ := client.GetPrincipalIdFrom(request.RequestContext.Authorizer)
+	if caller != userid && !client.IsMemberOf(request.RequestContext.Authorizer, Config.AdminGroupName) {
+		return "", client.NewErrorResponse(403, "notifications of other users require administrator"), nil
+	}
+	return userid, nil, nil
+}
+
+// GetNotifications returns the notification preferences of the user. Users without
+// preferences have no opted in channels.
+func GetNotifications(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb get user notifications")
+	logger.Debug("path parameters", zap.Any("parameters", request.PathParameters))
+
+	userid, denied, err := notificationsUser(request)
+	if denied != nil || err != nil {
+		return denied, err
+	}
+
+	attr, _ := attributevalue.Marshal(userid)
+	ddb := client.NewDynamodb()
+	output, err := ddb.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(Config.UsersTable),
+		Key:       map[string]types.AttributeValue{"userid": attr},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	user := profiles.User{}
+	if err := attributevalue.UnmarshalMap(output.Item, &user); err != nil {
+		return nil, err
+	} else if output.Item == nil || user.DeletedAt != 0 {
+		return client.NewErrorResponse(404, fmt.Sprintf("user %s not found", userid)), nil
+	}
+
+	preferences := notify.Preferences{Locale: notify.DefaultLocale, Channels: []notify.Channel{}}
+	if user.Notifications != nil {
+		preferences = *user.Notifications
+	}
+
+	body, err := json.Marshal(preferences)
+	if err != nil {
+		return nil, err
+	}
+
+	response := events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers:    client.HttpResponseHeaders,
+		Body:       string(body),
+	}
+	return &response, nil
+}
+
+// UpdateNotifications replaces the notification preferences of the user. Opted in channels
+// require the channel address.
+func UpdateNotifications(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb update user notifications")
+	logger.Debug("path parameters", zap.Any("parameters", request.PathParameters))
+
+	userid, denied, err := notificationsUser(request)
+	if denied != nil || err != nil {
+		return denied, err
+	}
+
+	if violations := notify.PreferencesSchema.Validate([]byte(request.Body)); len(violations) > 0 {
+		return client.NewValidationResponse(violations), nil
+	}
+
+	preferences := notify.Preferences{}
+	if err := json.Unmarshal([]byte(request.Body), &preferences); err != nil {
+		return nil, err
+	}
+
+	missing := []string{}
+	for _, channel := range preferences.Channels {
+		if preferences.Address(channel) == "" {
+			missing = append(missing, string(channel))
+		}
+	}
+	if len(missing) > 0 {
+		return client.NewErrorResponse(400, fmt.Sprintf("channels %s require an address", strings.Join(missing, ", "))), nil
+	}
+
+	attr, _ := attributevalue.Marshal(userid)
+	value, err := attributevalue.Marshal(preferences)
+	if err != nil {
+		return nil, err
+	}
+
+	ddb := client.NewDynamodb()
+	_, err = ddb.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(Config.UsersTable),
+		Key:                       map[string]types.AttributeValue{"userid": attr},
+		UpdateExpression:          aws.String("SET notifications = :notifications"),
+		ConditionExpression:       aws.String("attribute_exists(userid) AND attribute_not_exists(deletedAt)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":notifications": value},
+	})
+
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return client.NewErrorResponse(404, fmt.Sprintf("user %s not found", userid)), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(preferences)
+	if err != nil {
+		return nil, err
+	}
+
+	response := events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers:    client.HttpResponseHeaders,
+		Body:       string(body),
+	}
+	return &response, nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"github.com/kscott5/fds/internal/notify"
+	"github.com/kscott5/fds/internal/router"
+	"github.com/kscott5/fds/users/erasure"
+	"github.com/kscott5/fds/users/export"
+	"github.com/kscott5/fds/users/profiles"
+)
+
+// Register adds the users service routes. The order is the OpenAPI document order.
+func Register(r *router.Router) {
+	r.Handle(router.Route{
+		Method: "GET", Resource: "/users", Handler: GetUsers,
+		Summary: "List users",
+		Query: map[string]string{
+			"deleted": "true includes soft deleted users. administrators only.",
+			"limit":   "page size. defaults to 100.",
+			"next":    "next page token from the x-fds-next-token response header",
+		},
+		Response: []profiles.User{},
+	})
+	r.Handle(router.Route{
+		Method: "POST", Resource: "/users", Aliases: []string{"/user"}, Handler: CreateUser,
+		Summary:  "Create the caller's user record",
+		Request:  profiles.UserSchema,
+		Status:   201,
+		Response: map[string]string{},
+	})
+	r.Handle(router.Route{
+		Method: "PUT", Resource: "/users", Aliases: []string{"/user"}, Handler: CreateUser,
+		Summary:  "Create the caller's user record",
+		Request:  profiles.UserSchema,
+		Status:   201,
+		Response: map[string]string{},
+	})
+	r.Handle(router.Route{
+		Method: "GET", Resource: "/users/{id}", Handler: GetUser,
+		Summary:  "Get a user",
+		Response: profiles.User{},
+	})
+	r.Handle(router.Route{
+		Method: "PUT", Resource: "/users/{id}", Handler: UpdateUser,
+		Summary:  "Update a user",
+		Request:  profiles.UserSchema,
+		Response: profiles.User{},
+	})
+	r.Handle(router.Route{
+		Method: "GET", Resource: "/users/{id}/notifications", Handler: GetNotifications,
+		Summary:  "Get a user's order notification preferences",
+		Response: notify.Preferences{},
+	})
+	r.Handle(router.Route{
+		Method: "PUT", Resource: "/users/{id}/notifications", Handler: UpdateNotifications,
+		Summary:  "Opt in to order notifications by email, sms or mobile push",
+		Request:  notify.PreferencesSchema,
+		Response: notify.Preferences{},
+	})
+	r.Handle(router.Route{
+		Method: "DELETE", Resource: "/users/{id}", Handler: SoftDeleteUser,
+		Summary:  "Soft delete a user",
+		Response: map[string]string{},
+	})
+	r.Handle(router.Route{
+		Method: "POST", Resource: "/users/{id}/restore", Handler: RestoreUser,
+		Summary:  "Restore a soft deleted user. administrators only.",
+		Response: map[string]string{},
+	})
+	r.Handle(router.Route{
+		Method: "POST", Resource: "/users/{id}/erasure", Handler: EraseUser,
+		Summary:  "Start the erasure of a user's personal data. administrators only.",
+		Response: erasure.Receipt{},
+	})
+	r.Handle(router.Route{
+		Method: "GET", Resource: "/users/{id}/erasure/{receiptid}", Handler: GetErasureStatus,
+		Summary:  "Get an erasure status and receipt. administrators only.",
+		Response: erasure.Receipt{},
+	})
+	r.Handle(router.Route{
+		Method: "GET", Resource: "/users/{id}/export", Handler: ExportUser,
+		Summary: "Export a user's data",
+		Query: map[string]string{
+			"format": "json or zip. defaults to json.",
+			"async":  "true always starts an export job",
+		},
+		Response: export.UserData{},
+	})
+	r.Handle(router.Route{
+		Method: "GET", Resource: "/users/{id}/export/{exportid}", Handler: GetExportStatus,
+		Summary:  "Get an export job status and download url",
+		Response: export.Job{},
+	})
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/internal/config"
+	"github.com/kscott5/fds/users/profiles"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/aws/aws-lambda-go/events"
+	_ "github.com/aws/aws-lambda-go/lambdacontext" // IMPORTANT: package level init() in use.
+
+	"go.uber.org/zap"
+)
+
+// Config is loaded by the lambda main before the first request
+var Config config.Users
+
+func CreateUser(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb create user")
+
+	if violations := profiles.UserSchema.Validate([]byte(request.Body)); len(violations) > 0 {
+		return client.NewValidationResponse(violations), nil
+	}
+
+	user := profiles.User{}
+	if err := json.Unmarshal([]byte(request.Body), &user); err != nil {
+		return nil, err
+	}
+
+	// the user record is bound to the caller's Cognito identity
+	if principalId, err := client.GetPrincipalIdFrom(request.RequestContext.Authorizer); err != nil {
+		return client.NewErrorResponse(401, err.Error()), nil
+	} else {
+		user.UserId = principalId
+	}
+
+	ddb := client.NewDynamodb()
+	if err := profiles.CreateUser(ctx, ddb, Config.UsersTable, user); err != nil {
+		switch profiles.CancelledAt(err) {
+		case profiles.UserExists:
+			return client.NewErrorResponse(409, fmt.Sprintf("user %s already exists", user.UserId)), nil
+		case profiles.UserNameTaken:
+			return client.NewErrorResponse(409, fmt.Sprintf("username %s not available", user.UserName)), nil
+		default:
+			return nil, err
+		}
+	} else {
+		response := events.APIGatewayProxyResponse{
+			StatusCode: 201,
+			Headers:    client.HttpResponseHeaders,
+			Body:       fmt.Sprintf("{\"userid\": \"%s\"}", user.UserId),
+		}
+
+		return &response, nil
+	}
+}
+
+func UpdateUser(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb update user")
+	logger.Debug("path parameters", zap.Any("parameters", request.PathParameters))
+
+	userid := request.PathParameters["id"]
+	if userid == "" || strings.HasPrefix(userid, profiles.UserNameClaimPrefix) {
+		return nil, fmt.Errorf("requires: %s", map[string]string{"userid": "string"})
+	}
+
+	if violations := profiles.UserSchema.Validate([]byte(request.Body)); len(violations) > 0 {
+		return client.NewValidationResponse(violations), nil
+	}
+
+	user := profiles.User{}
+	if err := json.Unmarshal([]byte(request.Body), &user); err != nil {
+		return nil, err
+	}
+	user.UserId = userid
+
+	attr, _ := attributevalue.Marshal(userid)
+	key := map[string]types.AttributeValue{
+		"userid": attr,
+	}
+
+	ddb := client.NewDynamodb()
+	current := profiles.User{}
+	if output, err := ddb.GetItem(ctx, &dynamodb.GetItemInput{TableName: aws.String(Config.UsersTable), Key: key}); err != nil {
+		return nil, err
+	} else if err := attributevalue.UnmarshalMap(output.Item, &current); err != nil {
+		return nil, err
+	} else if output.Item == nil || current.DeletedAt != 0 {
+		return client.NewErrorResponse(404, fmt.Sprintf("user %s not found", userid)), nil
+	}
+
+	names := map[string]string{"#username": "UserName", "#fullname": "FullName"}
+	values, err := attributevalue.MarshalMap(map[string]string{
+		":username": user.UserName,
+		":fullname": user.FullName,
+		":previous": current.UserName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the previous username guards against concurrent updates of the same profile. profiles
+	// created without a username do not have the attribute.
+	update := types.Update{
+		TableName:                 aws.String(Config.UsersTable),
+		Key:                       key,
+		UpdateExpression:          aws.String("SET #username = :username, #fullname = :fullname"),
+		ConditionExpression:       aws.String("attribute_exists(userid) AND attribute_not_exists(deletedAt) AND (attribute_not_exists(#username) OR #username = :previous)"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	}
+	items := []types.TransactWriteItem{{Update: &update}}
+
+	if previous, next := profiles.ClaimIdFrom(current.UserName), profiles.ClaimIdFrom(user.UserName); previous != next {
+		claimItem, err := attributevalue.MarshalMap(profiles.UserNameClaim{ClaimId: next, ClaimedBy: userid})
+		if err != nil {
+			return nil, err
+		}
+		previousKey, _ := attributevalue.Marshal(previous)
+
+		// users created before username claims do not have a previous claim
+		items = append(items,
+			types.TransactWriteItem{Put: &types.Put{
+				TableName:           aws.String(Config.UsersTable),
+				Item:                claimItem,
+				ConditionExpression: aws.String("attribute_not_exists(userid)"),
+			}},
+			types.TransactWriteItem{Delete: &types.Delete{
+				TableName:                 aws.String(Config.UsersTable),
+				Key:                       map[string]types.AttributeValue{"userid": previousKey},
+				ConditionExpression:       aws.String("attribute_not_exists(userid) OR claimedby = :userid"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{":userid": attr},
+			}})
+	}
+
+	if _, err := ddb.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items}); profiles.CancelledAt(err) >= 0 {
+		return client.NewErrorResponse(409, fmt.Sprintf("username %s not available or user %s modified", user.UserName, userid)), nil
+	} else if err != nil {
+		return nil, err
+	} else if body, err := json.Marshal(user); err != nil {
+		return nil, err
+	} else {
+		response := events.APIGatewayProxyResponse{
+			StatusCode: 200,
+			Headers:    client.HttpResponseHeaders,
+			Body:       string(body),
+		}
+
+		return &response, nil
+	}
+}
+
+func GetUser(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+
+	logger.Info("lambda function: dynamodb get item user")
+	logger.Debug("path parameters", zap.Any("parameters", request.PathParameters))
+
+	userid := request.PathParameters["id"]
+	requires := map[string]string{"userid": "string"}
+	if userid == "" {
+		return nil, fmt.Errorf("requires: %s", requires)
+	} else if strings.HasPrefix(userid, profiles.UserNameClaimPrefix) {
+		// username claims share the table with user profiles
+		return client.NewErrorResponse(404, fmt.Sprintf("user %s not found", userid)), nil
+	}
+
+	attr, _ := attributevalue.Marshal(userid)
+	key := map[string]types.AttributeValue{
+		"userid": attr,
+	}
+
+	ddb := client.NewDynamodb()
+	params := dynamodb.GetItemInput{
+		TableName: aws.String(Config.UsersTable),
+		Key:       key,
+	}
+
+	out := map[string]interface{}{}
+	if output, err := ddb.GetItem(ctx, &params); err != nil {
+		return nil, err
+	} else if _, deleted := output.Item["deletedAt"]; output.Item == nil || (deleted && !client.IsMemberOf(request.RequestContext.Authorizer, Config.AdminGroupName)) {
+		return client.NewErrorResponse(404, fmt.Sprintf("user %s not found", userid)), nil
+	} else if err := attributevalue.UnmarshalMap(output.Item, &out); err != nil {
+		return nil, err
+	} else if body, err := json.Marshal(out); err != nil {
+		return nil, err
+	} else {
+		response := events.APIGatewayProxyResponse{
+			StatusCode:      200,
+			Headers:         client.HttpResponseHeaders,
+			Body:            string(body),
+			IsBase64Encoded: true,
+		}
+
+		return &response, nil
+	}
+}
+
+func GetUsers(ctx context.Context, request *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: dynamodb scan get users")
+	logger.Warn("filter expression or parameters not in use with this request")
+
+	// username claims share the table with user profiles
+	filter := "NOT begins_with(userid, :claim)"
+
+	// administrators list soft deleted users with ?deleted=true
+	if request.QueryStringParameters["deleted"] != "true" || !client.IsMemberOf(request.RequestContext.Authorizer, Config.AdminGroupName) {
+		filter = filter + " AND attribute_not_exists(deletedAt)"
+	}
+
+	// a page can hold fewer than limit users after the filter is applied
+	limit, startKey, err := client.GetPageFrom(request.QueryStringParameters)
+	if err != nil {
+		return client.NewErrorResponse(400, err.Error()), nil
+	}
+
+	prefix, _ := attributevalue.Marshal(profiles.UserNameClaimPrefix)
+	ddb := client.NewDynamodb()
+	params := dynamodb.ScanInput{
+		TableName:                 aws.String(Config.UsersTable),
+		FilterExpression:          aws.String(filter),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":claim": prefix},
+		Limit:                     aws.Int32(limit),
+		ExclusiveStartKey:         startKey,
+	}
+
+	out := []interface{}{}
+	if output, err := ddb.Scan(ctx, &params); err != nil {
+		return nil, err
+	} else if err := attributevalue.UnmarshalListOfMaps(output.Items, &out); err != nil {
+		return nil, err
+	} else if body, err := json.Marshal(out); err != nil {
+		return nil, err
+	} else if headers, err := client.NewPageHeaders(output.LastEvaluatedKey); err != nil {
+		return nil, err
+	} else {
+		response := events.APIGatewayProxyResponse{
+			StatusCode:      200,
+			Headers:         headers,
+			Body:            string(body),
+			IsBase64Encoded: true,
+		}
+
+		return &response, nil
+
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kscott5/fds/internal/client"
+	"github.com/kscott5/fds/internal/config"
+	"github.com/kscott5/fds/internal/logging"
+	"github.com/kscott5/fds/internal/tracing"
+	"github.com/kscott5/fds/users/profiles"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	_ "github.com/aws/aws-lambda-go/lambdacontext" // IMPORTANT: package level init() in use.
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+var settings config.SignUp
+
+// Cognito post confirmation trigger provisions the users table row for the new identity.
+// https://docs.aws.amazon.com/cognito/latest/developerguide/user-pool-lambda-post-confirmation.html
+func postConfirmation(ctx context.Context, event *events.CognitoEventUserPoolsPostConfirmation) (_ *events.CognitoEventUserPoolsPostConfirmation, err error) {
+	ctx, span := tracing.StartInvocation(ctx, "cognito post confirmation", attribute.String("faas.trigger", "other"))
+	defer func() { tracing.EndInvocation(ctx, span, err) }()
+
+	logger := logging.FromContext(ctx)
+	logger.Info("lambda function: cognito post confirmation")
+	logger.Debug("trigger source", zap.String("triggerSource", event.TriggerSource))
+
+	// forgot password confirmations do not create identities
+	if event.TriggerSource != "PostConfirmation_ConfirmSignUp" {
+		return event, nil
+	}
+
+	attributes := event.Request.UserAttributes
+	user := profiles.User{
+		UserId:   attributes["sub"],
+		FullName: attributes["name"],
+	}
+
+	requires := map[string]string{"sub": "string", "email": "string", "name": "string"}
+	if user.UserId == "" || attributes["email"] == "" || user.FullName == "" {
+		return nil, fmt.Errorf("requires: %s", requires)
+	}
+
+	// a taken username falls back to the next candidate. the profile is provisioned without a
+	// username when every candidate is taken, the user picks one with PUT /users/{id}.
+	ddb := client.NewDynamodb()
+	for _, username := range append(userNamesFrom(attributes), "") {
+		user.UserName = username
+		err := profiles.CreateUser(ctx, ddb, settings.UsersTable, user)
+		if at := profiles.CancelledAt(err); at == profiles.UserExists {
+			// trigger retries are expected
+			logger.Info("user already provisioned", zap.String("userid", user.UserId))
+			return event, nil
+		} else if at == profiles.UserNameTaken {
+			logger.Warn("username not available", zap.String("userid", user.UserId), zap.String("username", username))
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		break
+	}
+
+	logger.Info("user provisioned", zap.String("userid", user.UserId), zap.String("username", user.UserName))
+	return event, nil
+}
+
+// userNamesFrom returns the distinct username candidates of the new identity. The user pool
+// uses email as the sign in username, the sub is unique to the identity.
+func userNamesFrom(attributes map[string]string) []string {
+	usernames, claims := []string{}, map[string]bool{}
+	for _, username := range []string{attributes["preferred_username"], attributes["email"], attributes["sub"]} {
+		if strings.TrimSpace(username) != "" && !claims[profiles.ClaimIdFrom(username)] {
+			usernames = append(usernames, username)
+			claims[profiles.ClaimIdFrom(username)] = true
+		}
+	}
+	return usernames
+}
+
+func main() {
+	config.MustLoad(&settings)
+	lambda.Start(postConfirmation)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUserNamesFrom(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes map[string]string
+		usernames  []string
+	}{
+		{"preferred username", map[string]string{"preferred_username": "ana", "email": "ana@example.com", "sub": "s-1"}, []string{"ana", "ana@example.com", "s-1"}},
+		{"email sign in", map[string]string{"email": "ana@example.com", "sub": "s-1"}, []string{"ana@example.com", "s-1"}},
+		{"blank preferred username", map[string]string{"preferred_username": " ", "email": "ana@example.com", "sub": "s-1"}, []string{"ana@example.com", "s-1"}},
+		{"preferred username of the email", map[string]string{"preferred_username": "Ana@Example.com", "email": "ana@example.com", "sub": "s-1"}, []string{"Ana@Example.com", "s-1"}},
+		{"no attributes", map[string]string{}, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if usernames := userNamesFrom(test.attributes); !reflect.DeepEqual(usernames, test.usernames) {
+				t.Errorf("userNamesFrom = %v, want %v", usernames, test.usernames)
+			}
+		})
+	}
+}